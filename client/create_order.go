@@ -14,19 +14,20 @@ func createOrder(c proto.OrderServiceClient) {
 		{
 			OrderedItemId:   int64(12),
 			OrderedQuantity: 1,
-			Price:           25,
+			PriceMoney:      &proto.Money{AmountMinor: 2500, Currency: "USD"},
 			Name:            "V60",
 		},
 	}
 	req := &proto.Order{
-		CustomerId:   1,
-		RestaurantId: 33,
-		GrandTotal:   30,
-		Items:        items,
-		Status:       "New",
+		CustomerId:      1,
+		RestaurantId:    33,
+		GrandTotalMoney: &proto.Money{AmountMinor: 2500, Currency: "USD"},
+		Items:           items,
+		Status:          "New",
 	}
 
-	md := metadata.Pairs("correlation-id", uuid.New().String())
+	// reuse the same idempotency-key when retrying, so a timed out request does not place the order twice
+	md := metadata.Pairs("correlation-id", uuid.New().String(), "idempotency-key", uuid.New().String())
 	ctx, cancel := context.WithTimeout(metadata.NewOutgoingContext(context.Background(), md), time.Second*10)
 	defer cancel()
 	res, err := c.Create(ctx, req)
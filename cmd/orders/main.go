@@ -5,10 +5,20 @@ import (
 	"flag"
 	"fmt"
 	"github.com/joho/godotenv"
+	"github.com/nawafswe/orders-service/internal/app/orders/exchange"
+	"github.com/nawafswe/orders-service/internal/app/orders/expiry"
+	"github.com/nawafswe/orders-service/internal/app/orders/fees"
+	"github.com/nawafswe/orders-service/internal/app/orders/hub"
+	"github.com/nawafswe/orders-service/internal/app/orders/outbox"
+	"github.com/nawafswe/orders-service/internal/app/orders/pricing"
+	"github.com/nawafswe/orders-service/internal/app/orders/promotion"
 	"github.com/nawafswe/orders-service/internal/app/orders/repository"
+	"github.com/nawafswe/orders-service/internal/app/orders/tax"
 	grpc2 "github.com/nawafswe/orders-service/internal/app/orders/transport/grpc"
 	"github.com/nawafswe/orders-service/internal/app/orders/usecase"
 	"github.com/nawafswe/orders-service/internal/db"
+	"github.com/nawafswe/orders-service/internal/models"
+	"github.com/nawafswe/orders-service/pkg/leader"
 	"github.com/nawafswe/orders-service/pkg/logger"
 	"github.com/nawafswe/orders-service/pkg/messaging"
 	"google.golang.org/grpc"
@@ -17,15 +27,17 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 	"log"
 	"net"
+	"net/http"
 	"os"
-	"reflect"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
 var (
-	port = flag.Int("port", 9000, "gRPC server port")
+	port        = flag.Int("port", 9000, "gRPC server port")
+	metricsPort = flag.Int("metrics-port", 9100, "health and metrics http port")
 )
 
 func main() {
@@ -67,50 +79,159 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed connecting to the db, err:%v\n", err)
 	}
+	// amounts sent without a currency, and rows stored before amounts had one, are in the currency of the service
+	currency := os.Getenv("DEFAULT_CURRENCY")
+	if currency == "" {
+		currency = "USD"
+	}
+	if err := models.ValidateCurrency(currency); err != nil {
+		log.Fatalf("invalid DEFAULT_CURRENCY, err: %v\n", err)
+	}
+	if err := db.MigrateLegacyAmounts(dbConn, currency); err != nil {
+		log.Fatalf("failed to migrate amounts, err: %v\n", err)
+	}
+	if err := db.BackfillStatusChangedAt(dbConn); err != nil {
+		log.Fatalf("failed to migrate orders, err: %v\n", err)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	// generate pub sub client
-	ps := messaging.New(ctx, os.Getenv("GOOGLE_PROJECT_ID"))
+	ps := newMessageService(ctx)
 	if err != nil {
 		log.Fatalf("failed to connect to pub sub, err: %v\n", err)
 	}
-	// on main exist make sure to prevent resources leaks and close the connection of pubsub client
-	defer func(service messaging.MessageService) {
-		// if failure occurred, and you want to recover and not stop the gprc server
-		//defer func(){
-		//if v:= recover(); v!=nil{
-		//	// gracefully handle the recover, maybe log to datadog, or continue processing,
-		//}
-		//}()
-		v, ok := service.(messaging.MessageServiceImpl)
-		if ok {
-			err := v.C.Close()
-			if err != nil {
-				log.Printf("failed to close the messaging client connection, err: %v\n", err)
-			}
-			return
+	// on main exist make sure to prevent resources leaks and close the connection to the broker
+	defer func() {
+		if err := ps.Close(); err != nil {
+			log.Printf("failed to close the messaging client connection, err: %v\n", err)
 		}
-		log.Printf("failed to assert the type of messaging service, expected MessageServiceImpl struct but recived %v\n", reflect.TypeOf(service))
-	}(ps)
+	}()
 
-	ordersRepo := repo.repo.NewOrderRepo(dbConn)
-	orderUseCase := usecase.NewOrderUseCase(ordersRepo, ps, l)
+	ordersRepo := repo.NewOrderRepo(dbConn)
+	statusHistoryRepo := repo.NewStatusHistoryRepo(dbConn)
+	outboxRepo := repo.NewOutboxRepo(dbConn)
+	idempotencyRepo := repo.NewIdempotencyRepo(dbConn, durationFromEnv("IDEMPOTENCY_KEY_RETENTION", 24*time.Hour))
+	processedMessageRepo := repo.NewProcessedMessageRepo(dbConn)
+	promotionRepo := repo.NewPromotionRepo(dbConn)
+	transactor := repo.NewTransactor(dbConn)
+	orderHub := hub.NewOrderHub(16)
+	pricingMode := pricing.Reject
+	if mode := os.Getenv("PRICING_MODE"); mode != "" {
+		if pricingMode, err = pricing.ParseMode(mode); err != nil {
+			log.Fatalf("invalid PRICING_MODE, err: %v\n", err)
+		}
+	}
+	cancellationPolicy := usecase.DefaultCancellationPolicy()
+	cancellationPolicy.AllowApproved = boolFromEnv("CANCELLATION_ALLOW_APPROVED", cancellationPolicy.AllowApproved)
+	cancellationPolicy.ApprovedFeeRate = floatFromEnv("CANCELLATION_APPROVED_FEE_RATE", cancellationPolicy.ApprovedFeeRate)
+	if cancellationPolicy.ApprovedFeeRate < 0 || cancellationPolicy.ApprovedFeeRate > 1 {
+		log.Fatalf("invalid CANCELLATION_APPROVED_FEE_RATE %v, expected a rate between 0 and 1\n", cancellationPolicy.ApprovedFeeRate)
+	}
+	restaurantCurrencies, err := pricing.ParseRestaurantCurrencies(os.Getenv("RESTAURANT_CURRENCIES"))
+	if err != nil {
+		log.Fatalf("invalid RESTAURANT_CURRENCIES, err: %v\n", err)
+	}
+	rates := exchange.NewStaticRates(currency, nil)
+	if path := os.Getenv("EXCHANGE_RATES_FILE"); path != "" {
+		if rates, err = exchange.LoadStaticRates(path); err != nil {
+			log.Fatalf("invalid EXCHANGE_RATES_FILE, err: %v\n", err)
+		}
+	}
+	taxes := tax.NewEngine("", nil, nil, nil)
+	if path := os.Getenv("TAX_RULES_FILE"); path != "" {
+		if taxes, err = tax.LoadRules(path); err != nil {
+			log.Fatalf("invalid TAX_RULES_FILE, err: %v\n", err)
+		}
+	}
+	feePolicy := fees.NewPolicy(nil, nil)
+	if path := os.Getenv("FEE_POLICY_FILE"); path != "" {
+		if feePolicy, err = fees.LoadPolicy(path); err != nil {
+			log.Fatalf("invalid FEE_POLICY_FILE, err: %v\n", err)
+		}
+	}
+	pricer := pricing.NewPricer(pricingMode, pricing.NewStaticRestaurantCurrencies(currency, restaurantCurrencies), taxes, promotion.NewEvaluator(promotionRepo), feePolicy)
+	orderUseCase := usecase.NewOrderUseCase(ordersRepo, statusHistoryRepo, outboxRepo, idempotencyRepo, processedMessageRepo, promotionRepo, transactor, orderHub, pricer, rates, cancellationPolicy, l)
+	outboxRelay := outbox.NewRelay(outboxRepo, transactor, ps, l, outbox.DefaultConfig())
+	expiryCfg := expiry.DefaultConfig()
+	expiryCfg.PollInterval = durationFromEnv("ORDER_EXPIRY_INTERVAL", expiryCfg.PollInterval)
+	if timeouts := os.Getenv("ORDER_EXPIRY_TIMEOUTS"); timeouts != "" {
+		if expiryCfg.Rules, err = expiry.ParseRules(timeouts); err != nil {
+			log.Fatalf("invalid ORDER_EXPIRY_TIMEOUTS, err: %v\n", err)
+		}
+	}
+	expiryScheduler := expiry.NewScheduler(ordersRepo, orderUseCase, l, expiryCfg)
 	grpc2.NewOrderService(s, orderUseCase, l)
+	consumerMetrics := messaging.NewConsumerMetrics()
+	consumer := messaging.NewConsumer(ps,
+		messaging.WithRecovery(l),
+		messaging.WithCorrelationId(),
+		messaging.WithTracing(),
+		messaging.WithLogging(l),
+		messaging.WithMetrics(consumerMetrics),
+	)
+	usecase.RegisterConsumers(consumer, orderUseCase)
+	for _, subscription := range consumer.Subscriptions() {
+		policy := messaging.DefaultRetryPolicy(subscription)
+		policy.MaxAttempts = intFromEnv("MESSAGE_MAX_DELIVERY_ATTEMPTS", policy.MaxAttempts)
+		policy.MinBackoff = durationFromEnv("MESSAGE_MIN_BACKOFF", policy.MinBackoff)
+		policy.MaxBackoff = durationFromEnv("MESSAGE_MAX_BACKOFF", policy.MaxBackoff)
+		consumer.SetRetryPolicy(subscription, policy)
+	}
+	consumer.ProvisionDeadLetters()
 
 	log.Printf("successfully connected to pub sub client...\n")
 	log.Printf("Server listening at %v", lis.Addr())
 
+	// periodic jobs run on a single replica at a time, the others take over when it stops or loses its db session
+	locker := leader.NewPostgres(dbConn, durationFromEnv("LEADER_RENEW_INTERVAL", 5*time.Second))
+	leaderRetryInterval := durationFromEnv("LEADER_RETRY_INTERVAL", 10*time.Second)
+
 	var wg sync.WaitGroup
-	wg.Add(3)
+	wg.Add(7)
 
 	defer cancel()
 	go func() {
 		defer wg.Done()
-		orderUseCase.HandleOrderApproval(ctx)
+		consumer.Start(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		serveHealthAndMetrics(ctx, *metricsPort, consumerMetrics)
+	}()
+	go func() {
+		defer wg.Done()
+		leader.RunAsLeader(ctx, locker, "orders-service.outbox-relay", leaderRetryInterval, outboxRelay.Run)
 	}()
 	go func() {
 		defer wg.Done()
-		orderUseCase.HandleOrderRejection(ctx)
+		leader.RunAsLeader(ctx, locker, "orders-service.order-expiry", leaderRetryInterval, expiryScheduler.Run)
+	}()
+	go func() {
+		defer wg.Done()
+		leader.RunAsLeader(ctx, locker, "orders-service.idempotency-cleanup", leaderRetryInterval, func(ctx context.Context) {
+			runPeriodically(ctx, time.Hour, func(ctx context.Context) {
+				deleted, err := idempotencyRepo.DeleteExpired(ctx)
+				if err != nil {
+					log.Printf("failed to delete expired idempotency keys, err: %v\n", err)
+					return
+				}
+				log.Printf("deleted %d expired idempotency keys\n", deleted)
+			})
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		retention := durationFromEnv("PROCESSED_MESSAGES_RETENTION", 7*24*time.Hour)
+		leader.RunAsLeader(ctx, locker, "orders-service.processed-messages-cleanup", leaderRetryInterval, func(ctx context.Context) {
+			runPeriodically(ctx, time.Hour, func(ctx context.Context) {
+				deleted, err := processedMessageRepo.DeleteProcessedBefore(ctx, time.Now().Add(-retention))
+				if err != nil {
+					log.Printf("failed to delete old processed messages, err: %v\n", err)
+					return
+				}
+				log.Printf("deleted %d old processed messages\n", deleted)
+			})
+		})
 	}()
 	go func() {
 		defer wg.Done()
@@ -132,3 +253,147 @@ func main() {
 	defer cancel()
 	wg.Wait()
 }
+
+// serveHealthAndMetrics
+// serves /healthz and the consumer metrics on /metrics until ctx is cancelled
+func serveHealthAndMetrics(ctx context.Context, port int, metrics *messaging.ConsumerMetrics) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.Handle("/metrics", metrics)
+	srv := &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Shutdown(context.Background())
+	}()
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("error ocurred when serving health and metrics, err: %v\n", err)
+	}
+}
+
+// newMessageService
+// MESSAGE_BROKER selects the broker: pubsub (default), kafka, jetstream, or memory to run locally without any broker
+func newMessageService(ctx context.Context) messaging.MessageService {
+	switch os.Getenv("MESSAGE_BROKER") {
+	case "memory":
+		log.Printf("using the in memory message broker\n")
+		ms := messaging.NewInMemory(durationFromEnv("MESSAGE_ACK_DEADLINE", 10*time.Second))
+		createServiceTopics(ms)
+		// subscriptions share the name of the topic they consume
+		ms.CreateSub(usecase.ApproveOrderSubscription, usecase.ApproveOrderSubscription)
+		ms.CreateSub(usecase.RejectOrderSubscription, usecase.RejectOrderSubscription)
+		return ms
+	case "kafka":
+		log.Printf("using the kafka message broker\n")
+		cfg := messaging.DefaultKafkaConfig()
+		if group := os.Getenv("KAFKA_CONSUMER_GROUP"); group != "" {
+			cfg.ConsumerGroup = group
+		}
+		cfg.MinBackoff = durationFromEnv("MESSAGE_MIN_BACKOFF", cfg.MinBackoff)
+		cfg.MaxBackoff = durationFromEnv("MESSAGE_MAX_BACKOFF", cfg.MaxBackoff)
+		cfg.MaxDeliver = intFromEnv("KAFKA_MAX_DELIVER", cfg.MaxDeliver)
+		client := messaging.NewKafkaClient(strings.Split(os.Getenv("KAFKA_BROKERS"), ","), intFromEnv("KAFKA_PARTITIONS", 3), intFromEnv("KAFKA_REPLICATION_FACTOR", 1))
+		ms := messaging.NewKafka(client, cfg)
+		createServiceTopics(ms)
+		return ms
+	case "jetstream":
+		log.Printf("using the nats jetstream message broker\n")
+		cfg := messaging.DefaultJetStreamConfig()
+		cfg.AckWait = durationFromEnv("NATS_ACK_WAIT", cfg.AckWait)
+		cfg.MaxDeliver = intFromEnv("NATS_MAX_DELIVER", cfg.MaxDeliver)
+		ms, err := messaging.NewJetStream(ctx, os.Getenv("NATS_URL"), cfg)
+		if err != nil {
+			log.Fatalf("failed to connect to jetstream, err: %v\n", err)
+		}
+		// durable consumers share the name of the subscription
+		ms.CreateSub(usecase.ApproveOrderSubscription, usecase.ApproveOrderSubscription)
+		ms.CreateSub(usecase.RejectOrderSubscription, usecase.RejectOrderSubscription)
+		return ms
+	default:
+		return messaging.NewPubSub(ctx, os.Getenv("GOOGLE_PROJECT_ID"))
+	}
+}
+
+// createServiceTopics
+// the topics the service publishes to and consumes from
+func createServiceTopics(ms messaging.MessageService) {
+	for _, topic := range []string{"orderCreated", "orderStatusChanged", "orderCancelled", usecase.ApproveOrderSubscription, usecase.RejectOrderSubscription} {
+		ms.CreateTopic(topic)
+	}
+}
+
+// durationFromEnv
+// parses an env var like "30s" or "24h", falling back to the given default when it is unset or invalid
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("invalid duration %v for %v, using default %v\n", v, key, fallback)
+		return fallback
+	}
+	return d
+}
+
+// intFromEnv
+// parses an integer env var, falling back to the given default when it is unset or invalid
+func intFromEnv(key string, fallback int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("invalid integer %v for %v, using default %v\n", v, key, fallback)
+		return fallback
+	}
+	return n
+}
+
+// floatFromEnv
+// parses a decimal env var, falling back to the given default when it is unset or invalid
+func floatFromEnv(key string, fallback float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Printf("invalid number %v for %v, using default %v\n", v, key, fallback)
+		return fallback
+	}
+	return f
+}
+
+// boolFromEnv
+// parses a boolean env var, falling back to the given default when it is unset or invalid
+func boolFromEnv(key string, fallback bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("invalid boolean %v for %v, using default %v\n", v, key, fallback)
+		return fallback
+	}
+	return b
+}
+
+// runPeriodically
+// calls fn every interval until the context is cancelled
+func runPeriodically(ctx context.Context, interval time.Duration, fn func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn(ctx)
+		}
+	}
+}
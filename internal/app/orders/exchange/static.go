@@ -0,0 +1,77 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
+	"github.com/nawafswe/orders-service/internal/models"
+	"os"
+)
+
+// StaticRates
+// fixed rates against a base currency, a rate is the price of one base unit in the currency.
+// Any pair of known currencies is converted through the base
+type StaticRates struct {
+	base  string
+	rates map[string]float64
+}
+
+func NewStaticRates(base string, rates map[string]float64) interfaces.ExchangeRateProvider {
+	return StaticRates{base: base, rates: rates}
+}
+
+// ratesFile
+// e.g. {"base": "USD", "rates": {"SAR": 3.75, "EUR": 0.92}}
+type ratesFile struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// LoadStaticRates
+// reads the rates of a json file such as {"base": "USD", "rates": {"SAR": 3.75}}
+func LoadStaticRates(path string) (interfaces.ExchangeRateProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exchange rates, err: %w", err)
+	}
+	var f ratesFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse exchange rates of %v, err: %w", path, err)
+	}
+	if err := models.ValidateCurrency(f.Base); err != nil {
+		return nil, fmt.Errorf("invalid base of exchange rates, err: %w", err)
+	}
+	for currency, rate := range f.Rates {
+		if err := models.ValidateCurrency(currency); err != nil {
+			return nil, err
+		}
+		if rate <= 0 {
+			return nil, fmt.Errorf("exchange rate of %v should be positive, given %v", currency, rate)
+		}
+	}
+	return NewStaticRates(f.Base, f.Rates), nil
+}
+
+func (s StaticRates) Rate(ctx context.Context, from string, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	fromRate, ok := s.rate(from)
+	if !ok {
+		return 0, models.ExchangeRateNotFoundErr{From: from, To: to}
+	}
+	toRate, ok := s.rate(to)
+	if !ok {
+		return 0, models.ExchangeRateNotFoundErr{From: from, To: to}
+	}
+	return toRate / fromRate, nil
+}
+
+func (s StaticRates) rate(currency string) (float64, bool) {
+	if currency == s.base {
+		return 1, true
+	}
+	r, ok := s.rates[currency]
+	return r, ok
+}
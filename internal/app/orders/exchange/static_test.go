@@ -0,0 +1,69 @@
+package exchange_test
+
+import (
+	"context"
+	"errors"
+	"github.com/nawafswe/orders-service/internal/app/orders/exchange"
+	"github.com/nawafswe/orders-service/internal/models"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStaticRates(t *testing.T) {
+	rates := exchange.NewStaticRates("USD", map[string]float64{"SAR": 3.75, "EUR": 0.9})
+	tests := map[string]struct {
+		From         string
+		To           string
+		ExpectedRate float64
+		ExpectedErr  error
+	}{
+		"SameCurrency":      {From: "SAR", To: "SAR", ExpectedRate: 1},
+		"FromTheBase":       {From: "USD", To: "SAR", ExpectedRate: 3.75},
+		"ToTheBase":         {From: "SAR", To: "USD", ExpectedRate: 1 / 3.75},
+		"ThroughTheBase":    {From: "EUR", To: "SAR", ExpectedRate: 3.75 / 0.9},
+		"FailForUnknownOne": {From: "JPY", To: "SAR", ExpectedErr: models.ExchangeRateNotFoundErr{From: "JPY", To: "SAR"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rate, err := rates.Rate(context.Background(), test.From, test.To)
+			if !errors.Is(err, test.ExpectedErr) {
+				t.Fatalf("expected error %v, but got %v", test.ExpectedErr, err)
+			}
+			if math.Abs(rate-test.ExpectedRate) > 1e-9 {
+				t.Errorf("expected rate %v, but got %v", test.ExpectedRate, rate)
+			}
+		})
+	}
+}
+
+func TestLoadStaticRates(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write %v, err: %v", path, err)
+		}
+		return path
+	}
+	rates, err := exchange.LoadStaticRates(write("rates.json", `{"base": "USD", "rates": {"SAR": 3.75}}`))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if rate, err := rates.Rate(context.Background(), "USD", "SAR"); err != nil || rate != 3.75 {
+		t.Errorf("expected a rate of 3.75, but got %v, err: %v", rate, err)
+	}
+	for name, content := range map[string]string{
+		"invalid.json":  `{"base": "USD", "rates": `,
+		"no-base.json":  `{"rates": {"SAR": 3.75}}`,
+		"negative.json": `{"base": "USD", "rates": {"SAR": -3.75}}`,
+	} {
+		if _, err := exchange.LoadStaticRates(write(name, content)); err == nil {
+			t.Errorf("expected %v to be rejected", name)
+		}
+	}
+	if _, err := exchange.LoadStaticRates(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("expected a missing file to be rejected")
+	}
+}
@@ -0,0 +1,166 @@
+package expiry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
+	"github.com/nawafswe/orders-service/internal/app/orders/usecase"
+	"github.com/nawafswe/orders-service/internal/models"
+	"github.com/nawafswe/orders-service/pkg/logger"
+	"strings"
+	"time"
+)
+
+// Rule
+// orders staying in Status for longer than Timeout are moved to To with the given reason
+type Rule struct {
+	Status     string
+	Timeout    time.Duration
+	To         string
+	ReasonCode string
+	Reason     string
+}
+
+type Config struct {
+	PollInterval time.Duration
+	// BatchSize caps the orders expired per rule on every poll
+	BatchSize int
+	Rules     []Rule
+}
+
+// DefaultConfig
+// cancels orders the restaurant did not answer within 15 minutes
+func DefaultConfig() Config {
+	return Config{
+		PollInterval: time.Minute,
+		BatchSize:    100,
+		Rules:        []Rule{NewRule(models.New.String(), 15*time.Minute)},
+	}
+}
+
+// NewRule
+// a rule cancelling orders stuck in the status, an unanswered new order is a restaurant_timeout, other statuses a <status>_timeout
+func NewRule(status string, timeout time.Duration) Rule {
+	reasonCode := strings.ToLower(status) + "_timeout"
+	if status == models.New.String() {
+		reasonCode = "restaurant_timeout"
+	}
+	return Rule{
+		Status:     status,
+		Timeout:    timeout,
+		To:         models.Cancelled.String(),
+		ReasonCode: reasonCode,
+		Reason:     fmt.Sprintf("order stayed %v for more than %v", status, timeout),
+	}
+}
+
+// ParseRules
+// parses per status timeouts such as "New=15m,Approved=2h" into cancelling rules, a status that cannot be cancelled is rejected
+func ParseRules(timeouts string) ([]Rule, error) {
+	var rules []Rule
+	for _, entry := range strings.Split(timeouts, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		status, timeout, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid expiry timeout %v, expected <status>=<duration>", entry)
+		}
+		parsed, err := models.ParseOrderStatus(status)
+		if err != nil {
+			return nil, err
+		}
+		if !usecase.CanChangeStatus(parsed, models.Cancelled) {
+			return nil, fmt.Errorf("invalid expiry timeout of status %v, orders in it cannot be cancelled", status)
+		}
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid expiry timeout %v of status %v", timeout, status)
+		}
+		rules = append(rules, NewRule(status, d))
+	}
+	return rules, nil
+}
+
+// Scheduler
+// moves orders stuck in a status through the use case, so the change is validated, recorded and published like any other.
+// Replicas can run it side by side: an order is only updated while still in the status it was listed in,
+// the replica losing the race, or racing with an approval, gets an InvalidStatusChangeErr and skips the order
+type Scheduler struct {
+	repo    interfaces.OrderRepo
+	useCase interfaces.OrderUseCase
+	l       logger.Logger
+	cfg     Config
+}
+
+func NewScheduler(repo interfaces.OrderRepo, useCase interfaces.OrderUseCase, l logger.Logger, cfg Config) *Scheduler {
+	return &Scheduler{repo: repo, useCase: useCase, l: l, cfg: cfg}
+}
+
+// Run
+// expires stuck orders every poll interval until the context is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ExpireOnce(ctx); err != nil {
+				s.l.Error(map[string]any{"process": "OrderExpiry", "error": err.Error()}, "failed to expire orders")
+			}
+		}
+	}
+}
+
+// ExpireOnce
+// applies every rule to one batch of stuck orders and returns how many orders were expired.
+// An order failing to expire is logged and retried on the next poll
+func (s *Scheduler) ExpireOnce(ctx context.Context) (int, error) {
+	expired := 0
+	for _, rule := range s.cfg.Rules {
+		orders, err := s.repo.ListStale(ctx, rule.Status, time.Now().Add(-rule.Timeout), s.cfg.BatchSize)
+		if err != nil {
+			return expired, fmt.Errorf("failed to list orders stuck in %v, err: %w", rule.Status, err)
+		}
+		for _, o := range orders {
+			_, err := s.useCase.UpdateOrderStatus(ctx, models.StatusChange{
+				OrderId:        int64(o.ID),
+				PreviousStatus: rule.Status,
+				Status:         rule.To,
+				ReasonCode:     rule.ReasonCode,
+				Reason:         rule.Reason,
+				Actor:          models.SystemActor,
+			})
+			var invalidStatusErr models.InvalidStatusChangeErr
+			if errors.As(err, &invalidStatusErr) {
+				// answered or expired by another replica since it was listed
+				s.l.Info(map[string]any{
+					"process": "OrderExpiry",
+					"orderId": o.ID,
+					"status":  rule.Status,
+					"error":   err.Error(),
+				}, "skipped expiring order, its status changed since it was listed")
+				continue
+			}
+			if err != nil {
+				s.l.Warn(map[string]any{
+					"process": "OrderExpiry",
+					"orderId": o.ID,
+					"status":  rule.Status,
+					"error":   err.Error(),
+				}, "failed to expire order, it will be retried")
+				continue
+			}
+			s.l.Info(map[string]any{
+				"process": "OrderExpiry",
+				"orderId": o.ID,
+				"context": fmt.Sprintf("order %v stayed %v for more than %v, moved to %v", o.ID, rule.Status, rule.Timeout, rule.To),
+			}, "Order expired")
+			expired++
+		}
+	}
+	return expired, nil
+}
@@ -0,0 +1,108 @@
+package expiry_test
+
+import (
+	"context"
+	"errors"
+	"github.com/nawafswe/orders-service/internal/app/orders/expiry"
+	"github.com/nawafswe/orders-service/internal/models"
+	ordersMock "github.com/nawafswe/orders-service/mocks/github.com/nawafswe/orders-service/pkg/v1"
+	"github.com/nawafswe/orders-service/pkg/logger"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRules(t *testing.T) {
+	tests := map[string]struct {
+		Timeouts      string
+		ExpectedRules []expiry.Rule
+		ExpectedErr   bool
+	}{
+		"ParsePerStatusTimeouts": {
+			Timeouts: "New=15m, Approved=2h",
+			ExpectedRules: []expiry.Rule{
+				{Status: "New", Timeout: 15 * time.Minute, To: "Cancelled", ReasonCode: "restaurant_timeout", Reason: "order stayed New for more than 15m0s"},
+				{Status: "Approved", Timeout: 2 * time.Hour, To: "Cancelled", ReasonCode: "approved_timeout", Reason: "order stayed Approved for more than 2h0m0s"},
+			},
+		},
+		"FailForUnknownStatus": {
+			Timeouts:    "Cooking=15m",
+			ExpectedErr: true,
+		},
+		"FailForAStatusThatCannotBeCancelled": {
+			Timeouts:    "New=15m, Delivered=1h",
+			ExpectedErr: true,
+		},
+		"FailForInvalidTimeout": {
+			Timeouts:    "New=soon",
+			ExpectedErr: true,
+		},
+		"FailForNegativeTimeout": {
+			Timeouts:    "New=-1m",
+			ExpectedErr: true,
+		},
+		"FailForMissingTimeout": {
+			Timeouts:    "New",
+			ExpectedErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rules, err := expiry.ParseRules(test.Timeouts)
+			if (err != nil) != test.ExpectedErr {
+				t.Fatalf("expected error %v, but got %v", test.ExpectedErr, err)
+			}
+			if !reflect.DeepEqual(rules, test.ExpectedRules) {
+				t.Errorf("expected rules %v, but got %v", test.ExpectedRules, rules)
+			}
+		})
+	}
+}
+
+func TestExpireOnce(t *testing.T) {
+	ordersRepoMock := ordersMock.NewMockOrderRepo(t)
+	useCaseMock := ordersMock.NewMockOrderUseCase(t)
+	cfg := expiry.Config{BatchSize: 10, Rules: []expiry.Rule{expiry.NewRule("New", 15*time.Minute)}}
+	scheduler := expiry.NewScheduler(ordersRepoMock, useCaseMock, logger.NewLogger(), cfg)
+
+	start := time.Now()
+	ordersRepoMock.On("ListStale", mock.Anything, "New", mock.MatchedBy(func(before time.Time) bool {
+		return !before.Before(start.Add(-15*time.Minute)) && !before.After(time.Now().Add(-15*time.Minute))
+	}), 10).Return([]models.Order{{Model: gorm.Model{ID: 1}}, {Model: gorm.Model{ID: 2}}, {Model: gorm.Model{ID: 3}}}, nil)
+	expire := func(orderId int64) models.StatusChange {
+		return models.StatusChange{
+			OrderId:        orderId,
+			PreviousStatus: "New",
+			Status:         "Cancelled",
+			ReasonCode:     "restaurant_timeout",
+			Reason:         "order stayed New for more than 15m0s",
+			Actor:          models.SystemActor,
+		}
+	}
+	useCaseMock.On("UpdateOrderStatus", mock.Anything, expire(1)).Return(models.Order{Model: gorm.Model{ID: 1}, Status: "Cancelled"}, nil)
+	// approved by the restaurant, or expired by another replica, since it was listed
+	useCaseMock.On("UpdateOrderStatus", mock.Anything, expire(2)).Return(models.Order{}, models.InvalidStatusChangeErr{Message: "order with id 2 is no longer in status 'New'"})
+	useCaseMock.On("UpdateOrderStatus", mock.Anything, expire(3)).Return(models.Order{}, errors.New("connection reset"))
+
+	expired, err := scheduler.ExpireOnce(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if expired != 1 {
+		t.Errorf("expected 1 expired order, but got %d", expired)
+	}
+}
+
+func TestExpireOnceFailsWhenListingFails(t *testing.T) {
+	ordersRepoMock := ordersMock.NewMockOrderRepo(t)
+	useCaseMock := ordersMock.NewMockOrderUseCase(t)
+	scheduler := expiry.NewScheduler(ordersRepoMock, useCaseMock, logger.NewLogger(), expiry.DefaultConfig())
+	ordersRepoMock.On("ListStale", mock.Anything, "New", mock.Anything, 100).Return(nil, errors.New("connection reset"))
+
+	if _, err := scheduler.ExpireOnce(context.Background()); err == nil {
+		t.Errorf("expected an error")
+	}
+	useCaseMock.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,111 @@
+package fees
+
+import (
+	"context"
+	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
+	"github.com/nawafswe/orders-service/internal/models"
+)
+
+// Band
+// the delivery fee of the orders delivered up to a distance in meters
+type Band struct {
+	UpTo int64 `json:"up_to_meters"`
+	Fee  int64 `json:"fee"`
+}
+
+// Schedule
+// the fees of the orders of a restaurant, amounts are in minor units of the currency of the schedule. Zero values charge nothing.
+// Delivery bands are sorted by distance, an order further than the last band cannot be delivered.
+// The service fee is a share of the basket between a minimum and a maximum (no maximum when zero), the small basket fee is charged below a basket amount
+type Schedule struct {
+	Currency             string  `json:"currency"`
+	DeliveryBands        []Band  `json:"delivery_bands"`
+	ServiceRate          float64 `json:"service_rate"`
+	ServiceMinimum       int64   `json:"service_minimum"`
+	ServiceMaximum       int64   `json:"service_maximum"`
+	SmallBasketThreshold int64   `json:"small_basket_threshold"`
+	SmallBasketFee       int64   `json:"small_basket_fee"`
+}
+
+// Policy
+// charges every order the fees of the schedule of its restaurant, restaurants without their own schedule get the default one of the currency of the order,
+// none when there is no default in that currency. A restaurant schedule in another currency than the order fails with CurrencyMismatchErr.
+// The basket is the price times the quantity of every item, before taxes and discounts
+type Policy struct {
+	defaults    map[string]Schedule
+	restaurants map[int64]Schedule
+}
+
+// NewPolicy
+// defaults are keyed by currency, the currency of a default schedule is the one it is keyed by
+func NewPolicy(defaults map[string]Schedule, restaurants map[int64]Schedule) interfaces.FeePolicy {
+	keyed := make(map[string]Schedule, len(defaults))
+	for currency, s := range defaults {
+		s.Currency = currency
+		keyed[currency] = s
+	}
+	return Policy{defaults: keyed, restaurants: restaurants}
+}
+
+func (p Policy) Fees(ctx context.Context, order models.Order) ([]models.Fee, error) {
+	s, ok := p.restaurants[order.RestaurantId]
+	if !ok {
+		if s, ok = p.defaults[order.Currency]; !ok {
+			return nil, nil
+		}
+	}
+	if s.Currency != order.Currency {
+		return nil, models.CurrencyMismatchErr{Expected: order.Currency, Given: s.Currency}
+	}
+	basket := models.Money{Currency: order.Currency}
+	for _, i := range order.Items {
+		var err error
+		if basket, err = basket.Add(i.Price.Times(int64(i.OrderedQuantity))); err != nil {
+			return nil, err
+		}
+	}
+	var fees []models.Fee
+	charge := func(kind models.FeeKind, amount int64) {
+		if amount > 0 {
+			fees = append(fees, models.Fee{Kind: kind.String(), Amount: models.Money{Amount: amount, Currency: s.Currency}})
+		}
+	}
+	delivery, err := s.delivery(order)
+	if err != nil {
+		return nil, err
+	}
+	charge(models.DeliveryFee, delivery)
+	charge(models.ServiceFee, s.service(basket))
+	if basket.Amount < s.SmallBasketThreshold {
+		charge(models.SmallBasketFee, s.SmallBasketFee)
+	}
+	return fees, nil
+}
+
+// delivery
+// the fee of the first band the order is within, DeliveryOutOfRangeErr when it is further than all of them
+func (s Schedule) delivery(order models.Order) (int64, error) {
+	if len(s.DeliveryBands) == 0 {
+		return 0, nil
+	}
+	for _, b := range s.DeliveryBands {
+		if order.DeliveryDistance <= b.UpTo {
+			return b.Fee, nil
+		}
+	}
+	return 0, models.DeliveryOutOfRangeErr{RestaurantId: order.RestaurantId, Distance: order.DeliveryDistance, MaxDistance: s.DeliveryBands[len(s.DeliveryBands)-1].UpTo}
+}
+
+func (s Schedule) service(basket models.Money) int64 {
+	if s.ServiceRate == 0 {
+		return 0
+	}
+	fee := basket.Scale(s.ServiceRate).Amount
+	if fee < s.ServiceMinimum {
+		fee = s.ServiceMinimum
+	}
+	if s.ServiceMaximum > 0 && fee > s.ServiceMaximum {
+		fee = s.ServiceMaximum
+	}
+	return fee
+}
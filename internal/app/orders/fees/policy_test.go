@@ -0,0 +1,152 @@
+package fees_test
+
+import (
+	"context"
+	"errors"
+	"github.com/nawafswe/orders-service/internal/app/orders/fees"
+	"github.com/nawafswe/orders-service/internal/models"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func sar(amount int64) models.Money {
+	return models.Money{Amount: amount, Currency: "SAR"}
+}
+
+func TestPolicy(t *testing.T) {
+	policy := fees.NewPolicy(map[string]fees.Schedule{"SAR": {
+		DeliveryBands:        []fees.Band{{UpTo: 3000, Fee: 500}, {UpTo: 8000, Fee: 900}},
+		ServiceRate:          0.05,
+		ServiceMinimum:       100,
+		ServiceMaximum:       400,
+		SmallBasketThreshold: 2000,
+		SmallBasketFee:       300,
+	}}, map[int64]fees.Schedule{
+		41: {Currency: "SAR", DeliveryBands: []fees.Band{{UpTo: 5000, Fee: 0}}},
+		52: {Currency: "JPY", DeliveryBands: []fees.Band{{UpTo: 5000, Fee: 300}}},
+		53: {Currency: "SAR", DeliveryBands: []fees.Band{{UpTo: 5000, Fee: 500}}},
+	})
+	tests := map[string]struct {
+		RestaurantId int64
+		Currency     string
+		Distance     int64
+		Price        int64
+		Expected     []models.Fee
+		ExpectedErr  error
+	}{
+		"ChargeTheFirstBandAndTheMinimumServiceFee": {
+			RestaurantId: 33,
+			Distance:     2500,
+			Price:        1500,
+			Expected:     []models.Fee{{Kind: "delivery", Amount: sar(500)}, {Kind: "service", Amount: sar(100)}, {Kind: "small_basket", Amount: sar(300)}},
+		},
+		"ChargeTheBandOfTheDistance": {
+			RestaurantId: 33,
+			Distance:     3001,
+			Price:        4000,
+			Expected:     []models.Fee{{Kind: "delivery", Amount: sar(900)}, {Kind: "service", Amount: sar(200)}},
+		},
+		"CapTheServiceFee": {
+			RestaurantId: 33,
+			Distance:     8000,
+			Price:        20000,
+			Expected:     []models.Fee{{Kind: "delivery", Amount: sar(900)}, {Kind: "service", Amount: sar(400)}},
+		},
+		"FailBeyondTheLastBand": {
+			RestaurantId: 33,
+			Distance:     8001,
+			Price:        4000,
+			ExpectedErr:  models.DeliveryOutOfRangeErr{RestaurantId: 33, Distance: 8001, MaxDistance: 8000},
+		},
+		"UseTheScheduleOfTheRestaurant": {
+			RestaurantId: 41,
+			Distance:     4000,
+			Price:        1500,
+		},
+		"ChargeTheScheduleOfARestaurantPricedInAnotherCurrency": {
+			RestaurantId: 52,
+			Currency:     "JPY",
+			Distance:     4000,
+			Price:        1500,
+			Expected:     []models.Fee{{Kind: "delivery", Amount: models.Money{Amount: 300, Currency: "JPY"}}},
+		},
+		"ChargeNothingWithoutADefaultScheduleInTheCurrencyOfTheOrder": {
+			RestaurantId: 33,
+			Currency:     "JPY",
+			Distance:     2500,
+			Price:        1500,
+		},
+		"RejectAScheduleInAnotherCurrencyThanTheOrder": {
+			RestaurantId: 53,
+			Currency:     "JPY",
+			Distance:     2500,
+			Price:        1500,
+			ExpectedErr:  models.CurrencyMismatchErr{Expected: "JPY", Given: "SAR"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			currency := test.Currency
+			if currency == "" {
+				currency = "SAR"
+			}
+			price := models.Money{Amount: test.Price / 2, Currency: currency}
+			order := models.Order{RestaurantId: test.RestaurantId, Currency: currency, DeliveryDistance: test.Distance, Items: []models.OrderedItem{{Price: price, OrderedQuantity: 2}}}
+			charged, err := policy.Fees(context.Background(), order)
+			if test.ExpectedErr != nil {
+				if !errors.Is(err, test.ExpectedErr) {
+					t.Fatalf("expected error %v, but got %v", test.ExpectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(charged, test.Expected) {
+				t.Errorf("expected %+v, but got %+v", test.Expected, charged)
+			}
+		})
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write %v, err: %v", path, err)
+		}
+		return path
+	}
+	policy, err := fees.LoadPolicy(write("fees.json", `{"defaults": {"SAR": {"delivery_bands": [{"up_to_meters": 8000, "fee": 900}, {"up_to_meters": 3000, "fee": 500}]}},
+		"restaurants": {"41": {"currency": "SAR", "small_basket_threshold": 2000, "small_basket_fee": 300}}}`))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	items := []models.OrderedItem{{Price: sar(1000), OrderedQuantity: 1}}
+	charged, err := policy.Fees(context.Background(), models.Order{RestaurantId: 33, Currency: "SAR", DeliveryDistance: 1000, Items: items})
+	if err != nil || !reflect.DeepEqual(charged, []models.Fee{{Kind: "delivery", Amount: sar(500)}}) {
+		t.Errorf("expected the delivery fee of the closest band, but got %+v, err: %v", charged, err)
+	}
+	charged, err = policy.Fees(context.Background(), models.Order{RestaurantId: 41, Currency: "SAR", DeliveryDistance: 1000, Items: items})
+	if err != nil || !reflect.DeepEqual(charged, []models.Fee{{Kind: "small_basket", Amount: sar(300)}}) {
+		t.Errorf("expected the schedule of restaurant 41, but got %+v, err: %v", charged, err)
+	}
+	for name, content := range map[string]string{
+		"invalid.json":          `{"defaults": `,
+		"negative-band.json":    `{"defaults": {"SAR": {"delivery_bands": [{"up_to_meters": 3000, "fee": -500}]}}}`,
+		"service-rate.json":     `{"defaults": {"SAR": {"service_rate": 1.5}}}`,
+		"service-maximum.json":  `{"restaurants": {"41": {"currency": "SAR", "service_rate": 0.1, "service_minimum": 500, "service_maximum": 100}}}`,
+		"negative-fee.json":     `{"defaults": {"SAR": {"small_basket_fee": -300}}}`,
+		"unknown-currency.json": `{"defaults": {"sar": {"small_basket_fee": 300}}}`,
+		"not-iso-currency.json": `{"defaults": {"XYZ": {"small_basket_fee": 300}}}`,
+		"other-currency.json":   `{"defaults": {"SAR": {"currency": "USD", "small_basket_fee": 300}}}`,
+		"without-currency.json": `{"restaurants": {"41": {"small_basket_fee": 300}}}`,
+	} {
+		if _, err := fees.LoadPolicy(write(name, content)); err == nil {
+			t.Errorf("expected %v to be rejected", name)
+		}
+	}
+}
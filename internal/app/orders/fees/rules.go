@@ -0,0 +1,70 @@
+package fees
+
+import (
+	"encoding/json"
+	"fmt"
+	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
+	"github.com/nawafswe/orders-service/internal/models"
+	"os"
+	"sort"
+)
+
+// policyFile
+// e.g. {"defaults": {"USD": {"delivery_bands": [{"up_to_meters": 3000, "fee": 500}], "service_rate": 0.05}},
+// "restaurants": {"41": {"currency": "AED", "small_basket_threshold": 2000, "small_basket_fee": 300}}}
+type policyFile struct {
+	Defaults    map[string]Schedule `json:"defaults"`
+	Restaurants map[int64]Schedule  `json:"restaurants"`
+}
+
+// LoadPolicy
+// reads the default fee schedules per currency and the schedules of restaurants overriding them from a json file
+func LoadPolicy(path string) (interfaces.FeePolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fee policy, err: %w", err)
+	}
+	var f policyFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse fee policy of %v, err: %w", path, err)
+	}
+	for currency, s := range f.Defaults {
+		if s.Currency != "" && s.Currency != currency {
+			return nil, fmt.Errorf("default fee schedule of %v is in %v", currency, s.Currency)
+		}
+		s.Currency = currency
+		if err := validate(s); err != nil {
+			return nil, fmt.Errorf("invalid default fee schedule of %v, err: %w", currency, err)
+		}
+	}
+	for restaurantId, s := range f.Restaurants {
+		if err := validate(s); err != nil {
+			return nil, fmt.Errorf("invalid fee schedule of restaurant %v, err: %w", restaurantId, err)
+		}
+	}
+	return NewPolicy(f.Defaults, f.Restaurants), nil
+}
+
+// validate
+// rejects schedules without a valid currency, negative amounts and rates out of range, and sorts the delivery bands by distance
+func validate(s Schedule) error {
+	if err := models.ValidateCurrency(s.Currency); err != nil {
+		return err
+	}
+	if s.ServiceRate < 0 || s.ServiceRate > 1 {
+		return fmt.Errorf("service rate %v should be between 0 and 1", s.ServiceRate)
+	}
+	if s.ServiceMinimum < 0 || s.ServiceMaximum < 0 || s.SmallBasketThreshold < 0 || s.SmallBasketFee < 0 {
+		return fmt.Errorf("fee amounts should not be negative")
+	}
+	if s.ServiceMaximum > 0 && s.ServiceMaximum < s.ServiceMinimum {
+		return fmt.Errorf("service maximum %v is below the minimum %v", s.ServiceMaximum, s.ServiceMinimum)
+	}
+	sort.Slice(s.DeliveryBands, func(i, j int) bool { return s.DeliveryBands[i].UpTo < s.DeliveryBands[j].UpTo })
+	for _, b := range s.DeliveryBands {
+		if b.UpTo <= 0 || b.Fee < 0 {
+			return fmt.Errorf("invalid delivery band up to %v meters with fee %v", b.UpTo, b.Fee)
+		}
+	}
+	return nil
+}
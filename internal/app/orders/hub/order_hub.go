@@ -0,0 +1,69 @@
+package hub
+
+import (
+	"github.com/nawafswe/orders-service/internal/models"
+	"sync"
+)
+
+// OrderHub
+// an in-process fan-out of order changes to every interested subscriber.
+// Notify never blocks, a subscriber whose buffer is full is considered too slow, it gets unsubscribed and its channel closed
+type OrderHub struct {
+	mu     sync.Mutex
+	subs   map[*subscription]struct{}
+	buffer int
+}
+
+type subscription struct {
+	ch    chan models.Order
+	match func(order models.Order) bool
+}
+
+func NewOrderHub(buffer int) *OrderHub {
+	return &OrderHub{subs: make(map[*subscription]struct{}), buffer: buffer}
+}
+
+// Subscribe
+// returns a channel receiving every notified order accepted by match, and a function to stop receiving them.
+// The channel is closed once unsubscribed, either by calling the returned function or because the subscriber fell behind
+func (h *OrderHub) Subscribe(match func(order models.Order) bool) (<-chan models.Order, func()) {
+	s := &subscription{ch: make(chan models.Order, h.buffer), match: match}
+	h.mu.Lock()
+	h.subs[s] = struct{}{}
+	h.mu.Unlock()
+	return s.ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(s)
+	}
+}
+
+func (h *OrderHub) Notify(order models.Order) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		if !s.match(order) {
+			continue
+		}
+		select {
+		case s.ch <- order:
+		default:
+			h.remove(s)
+		}
+	}
+}
+
+// remove must be called while holding the lock, removing an already removed subscription is a no-op
+func (h *OrderHub) remove(s *subscription) {
+	if _, ok := h.subs[s]; !ok {
+		return
+	}
+	delete(h.subs, s)
+	close(s.ch)
+}
+
+func (h *OrderHub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
@@ -0,0 +1,61 @@
+package hub_test
+
+import (
+	"github.com/nawafswe/orders-service/internal/app/orders/hub"
+	"github.com/nawafswe/orders-service/internal/models"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestNotifyMatchingSubscribers(t *testing.T) {
+	h := hub.NewOrderHub(4)
+	first, unsubscribeFirst := h.Subscribe(func(o models.Order) bool { return o.ID == 1 })
+	defer unsubscribeFirst()
+	restaurant, unsubscribeRestaurant := h.Subscribe(func(o models.Order) bool { return o.RestaurantId == 33 })
+	defer unsubscribeRestaurant()
+
+	h.Notify(models.Order{Model: gorm.Model{ID: 1}, RestaurantId: 33, Status: "Approved"})
+	h.Notify(models.Order{Model: gorm.Model{ID: 2}, RestaurantId: 33, Status: "New"})
+
+	if len(first) != 1 {
+		t.Errorf("expected order subscriber to receive 1 update, but got %d", len(first))
+	}
+	if len(restaurant) != 2 {
+		t.Errorf("expected restaurant subscriber to receive 2 updates, but got %d", len(restaurant))
+	}
+	if o := <-first; o.Status != "Approved" {
+		t.Errorf("expected order status to be Approved, but got %v", o.Status)
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	h := hub.NewOrderHub(1)
+	updates, unsubscribe := h.Subscribe(func(models.Order) bool { return true })
+
+	h.Notify(models.Order{Status: "New"})
+	h.Notify(models.Order{Status: "Approved"})
+
+	if h.Subscribers() != 0 {
+		t.Errorf("expected slow subscriber to be dropped, but hub still has %d subscribers", h.Subscribers())
+	}
+	<-updates
+	if _, ok := <-updates; ok {
+		t.Errorf("expected the channel of a dropped subscriber to be closed")
+	}
+	// unsubscribing after being dropped must not panic on closing the channel twice
+	unsubscribe()
+}
+
+func TestUnsubscribeClosesChannel(t *testing.T) {
+	h := hub.NewOrderHub(1)
+	updates, unsubscribe := h.Subscribe(func(models.Order) bool { return true })
+	unsubscribe()
+	unsubscribe()
+	if _, ok := <-updates; ok {
+		t.Errorf("expected channel to be closed after unsubscribing")
+	}
+	h.Notify(models.Order{Status: "New"})
+	if h.Subscribers() != 0 {
+		t.Errorf("expected no subscribers, but got %d", h.Subscribers())
+	}
+}
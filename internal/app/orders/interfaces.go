@@ -3,18 +3,133 @@ package orders
 import (
 	"context"
 	"github.com/nawafswe/orders-service/internal/models"
+	"time"
 )
 
 type OrderRepo interface {
 	Create(ctx context.Context, order models.Order) (models.Order, error)
-	UpdateOrderStatus(ctx context.Context, id int64, status string) (models.Order, error)
+	GetById(ctx context.Context, id int64) (models.Order, error)
+	List(ctx context.Context, filter models.OrderFilter) ([]models.Order, error)
+	UpdateOrderStatus(ctx context.Context, change models.StatusChange) (models.Order, error)
+	SetCancellationFee(ctx context.Context, orderId int64, fee models.Money) error
+	// ListStale returns up to limit orders in the given status whose status last changed before the given time, oldest first
+	ListStale(ctx context.Context, status string, changedBefore time.Time, limit int) ([]models.Order, error)
+	// SalesByCurrency sums the grand totals of the orders that are not rejected or cancelled, per currency
+	SalesByCurrency(ctx context.Context, filter models.SalesReportFilter) ([]models.CurrencyTotal, error)
 }
 
 type OrderUseCase interface {
-	PlaceOrder(ctx context.Context, order models.Order) (models.Order, error)
-	UpdateOrderStatus(ctx context.Context, orderId int64, status string) (models.Order, error)
-	HandleOrderApproval(ctx context.Context)
-	HandleOrderRejection(ctx context.Context)
-	PublishOrderStatusChanged(ctx context.Context, order models.Order)
-	PublishOrderCreatedEvent(ctx context.Context, order models.Order)
+	// PlaceOrder returns the order created earlier with the same idempotency key instead of creating a new one, an empty key disables the check
+	PlaceOrder(ctx context.Context, order models.Order, idempotencyKey string) (models.Order, error)
+	// UpdateOrderStatus applies the change to the order, its previous status and time are filled in.
+	// A change made with a previous status is only applied while the order is still in it
+	UpdateOrderStatus(ctx context.Context, change models.StatusChange) (models.Order, error)
+	// CancelOrder cancels the order of the customer as far as the cancellation policy allows, the order carries the fee charged for it
+	CancelOrder(ctx context.Context, cancellation models.Cancellation) (models.Order, error)
+	GetOrder(ctx context.Context, orderId int64) (models.Order, error)
+	// GetOrderTimeline returns the status transitions of the order, oldest first
+	GetOrderTimeline(ctx context.Context, orderId int64) ([]models.StatusChange, error)
+	ListOrders(ctx context.Context, filter models.OrderFilter) (models.OrderPage, error)
+	// GetSalesReport sums the sales per currency and converts them to the currency of the filter
+	GetSalesReport(ctx context.Context, filter models.SalesReportFilter) (models.SalesReport, error)
+	WatchOrder(ctx context.Context, orderId int64) (OrderFeed, error)
+	WatchRestaurantOrders(ctx context.Context, restaurantId int64) (OrderFeed, error)
+	HandleOrderApproval(ctx context.Context, eventId string, change models.StatusChange) error
+	HandleOrderRejection(ctx context.Context, eventId string, change models.StatusChange) error
+}
+
+// Pricer
+// computes the price breakdown and grand total of an order from its items
+type Pricer interface {
+	Price(ctx context.Context, order models.Order) (models.Order, error)
+}
+
+// TaxCalculator
+// the taxes of an order, computed for the region of its restaurant from the prices and tax categories of its items
+type TaxCalculator interface {
+	Calculate(ctx context.Context, order models.Order) (models.TaxAssessment, error)
+}
+
+// Discounter
+// the discounts the coupon of an order gives, none for an order without coupon
+type Discounter interface {
+	Discount(ctx context.Context, order models.Order) ([]models.Discount, error)
+}
+
+// FeePolicy
+// the fees charged on an order, such as delivery, service and small basket fees
+type FeePolicy interface {
+	Fees(ctx context.Context, order models.Order) ([]models.Fee, error)
+}
+
+// RestaurantCurrencies
+// the currency every restaurant prices its items in
+type RestaurantCurrencies interface {
+	Currency(ctx context.Context, restaurantId int64) (string, error)
+}
+
+// ExchangeRateProvider
+// the price of one major unit of a currency in another, fails with ExchangeRateNotFoundErr for an unknown pair
+type ExchangeRateProvider interface {
+	Rate(ctx context.Context, from string, to string) (float64, error)
+}
+
+// StatusHistoryRepo
+// the status transitions of orders, Add takes part in the transaction of the transition
+type StatusHistoryRepo interface {
+	Add(ctx context.Context, change models.StatusChange) error
+	List(ctx context.Context, orderId int64) ([]models.StatusChange, error)
+}
+
+// Transactor
+// runs fn in a single db transaction, repositories called with the context given to fn take part in it
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type OutboxRepo interface {
+	Add(ctx context.Context, messages []models.OutboxMessage) error
+	Claim(ctx context.Context, limit int, leaseUntil time.Time) ([]models.OutboxMessage, error)
+	Release(ctx context.Context, ids []uint) error
+	MarkSent(ctx context.Context, id uint) error
+	MarkFailed(ctx context.Context, id uint, nextAttemptAt time.Time, reason string) error
+	Stats(ctx context.Context) (models.OutboxStats, error)
+}
+
+// OrderNotifier
+// fans out order changes to in-process subscribers, e.g. the streaming rpc calls
+type OrderNotifier interface {
+	Notify(order models.Order)
+	Subscribe(match func(order models.Order) bool) (<-chan models.Order, func())
+}
+
+// OrderFeed
+// the state of the watched orders at subscription time followed by their subsequent changes.
+// Updates is closed when the subscriber falls behind, Close must be called once the feed is no longer consumed
+type OrderFeed struct {
+	Current []models.Order
+	Updates <-chan models.Order
+	Close   func()
+}
+
+type IdempotencyRepo interface {
+	Find(ctx context.Context, key string) (models.IdempotencyKey, bool, error)
+	Save(ctx context.Context, key models.IdempotencyKey) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// PromotionRepo
+// promotions by code and their redemptions, Redeem and RevertRedemptions take part in the transaction of the order
+type PromotionRepo interface {
+	// GetByCode fails with InvalidCouponErr for an unknown code
+	GetByCode(ctx context.Context, code string) (models.Promotion, error)
+	// Redeem records the redemption unless the customer already used the promotion as often as allowed, which fails with InvalidCouponErr
+	Redeem(ctx context.Context, redemption models.Redemption) error
+	// RevertRedemptions gives the promotions used by the order back to its customer
+	RevertRedemptions(ctx context.Context, orderId int64, revertedAt time.Time) error
+}
+
+type ProcessedMessageRepo interface {
+	MarkProcessed(ctx context.Context, subscription string, messageId string) (bool, error)
+	DeleteProcessedBefore(ctx context.Context, before time.Time) (int64, error)
 }
@@ -0,0 +1,153 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
+	"github.com/nawafswe/orders-service/internal/models"
+	"github.com/nawafswe/orders-service/pkg/logger"
+	"github.com/nawafswe/orders-service/pkg/messaging"
+	"time"
+)
+
+type Config struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// the delay before the first retry of a failed message, doubled on each following attempt up to MaxBackoff
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// lag above which the relay logs a warning instead of info
+	LagWarnThreshold time.Duration
+	// how long claimed messages are kept from other relays while being published, they are claimed again once it passes, e.g. after a crash
+	ClaimLease time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		PollInterval:     time.Second,
+		BatchSize:        100,
+		BaseBackoff:      time.Second,
+		MaxBackoff:       5 * time.Minute,
+		LagWarnThreshold: time.Minute,
+		ClaimLease:       time.Minute,
+	}
+}
+
+// Relay
+// publishes the messages of the outbox in insertion order, marking them sent once the broker acknowledged them.
+// A batch is claimed in a short transaction and published outside of it, so no row lock is held across broker calls.
+// Once a message fails, the rest of the batch with the same ordering key is left for a later run so the events of an order keep their order
+type Relay struct {
+	repo      interfaces.OutboxRepo
+	tx        interfaces.Transactor
+	publisher messaging.Publisher
+	l         logger.Logger
+	cfg       Config
+}
+
+func NewRelay(repo interfaces.OutboxRepo, tx interfaces.Transactor, publisher messaging.Publisher, l logger.Logger, cfg Config) *Relay {
+	return &Relay{repo: repo, tx: tx, publisher: publisher, l: l, cfg: cfg}
+}
+
+// Run
+// relays the outbox every poll interval until the context is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.RelayBatch(ctx); err != nil {
+				r.l.Error(map[string]any{"process": "OutboxRelay", "error": err.Error()}, "failed to relay outbox messages")
+			}
+			r.reportLag(ctx)
+		}
+	}
+}
+
+// RelayBatch
+// publishes one batch of due messages and returns how many of them were sent
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	var messages []models.OutboxMessage
+	err := r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		messages, err = r.repo.Claim(ctx, r.cfg.BatchSize, time.Now().Add(r.cfg.ClaimLease))
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim outbox batch, err: %w", err)
+	}
+	sent := 0
+	failedKeys := make(map[string]bool)
+	var held []uint
+	for _, m := range messages {
+		if m.OrderingKey != "" && failedKeys[m.OrderingKey] {
+			held = append(held, m.ID)
+			continue
+		}
+		_, err := r.publisher.Publish(ctx, m.Topic, &messaging.Message{Data: m.Data, Attributes: m.Attributes, OrderingKey: m.OrderingKey})
+		if err != nil {
+			r.l.Warn(map[string]any{
+				"process":     "OutboxRelay",
+				"topic":       m.Topic,
+				"outboxId":    m.ID,
+				"orderingKey": m.OrderingKey,
+				"attempts":    m.Attempts + 1,
+				"error":       err.Error(),
+			}, "failed to publish outbox message, it will be retried")
+			failedKeys[m.OrderingKey] = true
+			if err := r.repo.MarkFailed(ctx, m.ID, time.Now().Add(r.Backoff(m.Attempts+1)), err.Error()); err != nil {
+				return sent, fmt.Errorf("failed to relay outbox batch, err: %w", err)
+			}
+			continue
+		}
+		if err := r.repo.MarkSent(ctx, m.ID); err != nil {
+			// the message is published again with the same ce-id once its claim expires
+			return sent, fmt.Errorf("failed to relay outbox batch, err: %w", err)
+		}
+		sent++
+	}
+	if err := r.repo.Release(ctx, held); err != nil {
+		return sent, fmt.Errorf("failed to relay outbox batch, err: %w", err)
+	}
+	return sent, nil
+}
+
+// Backoff
+// the delay before retrying a message that failed the given number of times
+func (r *Relay) Backoff(attempts int) time.Duration {
+	d := r.cfg.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= r.cfg.MaxBackoff {
+			return r.cfg.MaxBackoff
+		}
+	}
+	return d
+}
+
+// Stats
+// the number of unsent messages and the age of the oldest one
+func (r *Relay) Stats(ctx context.Context) (models.OutboxStats, error) {
+	return r.repo.Stats(ctx)
+}
+
+func (r *Relay) reportLag(ctx context.Context) {
+	stats, err := r.repo.Stats(ctx)
+	if err != nil {
+		r.l.Error(map[string]any{"process": "OutboxRelay", "error": err.Error()}, "failed to get outbox stats")
+		return
+	}
+	data := map[string]any{
+		"process": "OutboxRelay",
+		"pending": stats.Pending,
+		"lag":     stats.Lag().String(),
+	}
+	if stats.Lag() > r.cfg.LagWarnThreshold {
+		r.l.Warn(data, "outbox relay is lagging behind")
+		return
+	}
+	r.l.Debug(data, "outbox relay lag")
+}
@@ -0,0 +1,147 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"github.com/nawafswe/orders-service/internal/app/orders/outbox"
+	"github.com/nawafswe/orders-service/internal/models"
+	loggerMock "github.com/nawafswe/orders-service/mocks/github.com/nawafswe/orders-service/pkg/logger"
+	messagesMock "github.com/nawafswe/orders-service/mocks/github.com/nawafswe/orders-service/pkg/messaging"
+	ordersMock "github.com/nawafswe/orders-service/mocks/github.com/nawafswe/orders-service/pkg/v1"
+	"github.com/nawafswe/orders-service/pkg/messaging"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func runInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func TestRelayBatch(t *testing.T) {
+	outboxMock := ordersMock.NewMockOutboxRepo(t)
+	txMock := ordersMock.NewMockTransactor(t)
+	pubSubMock := messagesMock.NewMockPublisher(t)
+	l := loggerMock.NewMockLogger(t)
+	relay := outbox.NewRelay(outboxMock, txMock, pubSubMock, l, outbox.DefaultConfig())
+
+	txMock.EXPECT().WithinTransaction(mock.Anything, mock.Anything).RunAndReturn(runInTransaction).Once()
+	outboxMock.On("Claim", mock.Anything, 100, mock.AnythingOfType("time.Time")).Return([]models.OutboxMessage{
+		{ID: 1, Topic: "orderCreated", Data: []byte("created"), OrderingKey: "1"},
+		{ID: 2, Topic: "orderStatusChanged", Data: []byte("changed"), OrderingKey: "2", Attempts: 2},
+	}, nil)
+	pubSubMock.On("Publish", mock.Anything, "orderCreated", mock.MatchedBy(func(m *messaging.Message) bool {
+		return string(m.Data) == "created"
+	})).Return("srv-1", nil)
+	pubSubMock.On("Publish", mock.Anything, "orderStatusChanged", mock.Anything).Return("", errors.New("pubsub is down"))
+	outboxMock.On("MarkSent", mock.Anything, uint(1)).Return(nil)
+	l.On("Warn", mock.Anything, mock.Anything).Return()
+	before := time.Now()
+	outboxMock.On("MarkFailed", mock.Anything, uint(2), mock.MatchedBy(func(next time.Time) bool {
+		// third attempt failed, so the message waits 4 times the base backoff
+		return !next.Before(before.Add(4*time.Second)) && next.Before(time.Now().Add(5*time.Second))
+	}), "pubsub is down").Return(nil)
+	outboxMock.On("Release", mock.Anything, []uint(nil)).Return(nil)
+
+	sent, err := relay.RelayBatch(context.Background())
+	if err != nil {
+		t.Fatalf("expected relaying the batch to succeed, but got %v", err)
+	}
+	if sent != 1 {
+		t.Errorf("expected 1 sent message, but got %d", sent)
+	}
+}
+
+func TestRelayBatchHoldsBackTheKeyOfAFailedMessage(t *testing.T) {
+	outboxMock := ordersMock.NewMockOutboxRepo(t)
+	txMock := ordersMock.NewMockTransactor(t)
+	pubSubMock := messagesMock.NewMockPublisher(t)
+	l := loggerMock.NewMockLogger(t)
+	relay := outbox.NewRelay(outboxMock, txMock, pubSubMock, l, outbox.DefaultConfig())
+
+	txMock.EXPECT().WithinTransaction(mock.Anything, mock.Anything).RunAndReturn(runInTransaction).Once()
+	outboxMock.On("Claim", mock.Anything, 100, mock.AnythingOfType("time.Time")).Return([]models.OutboxMessage{
+		{ID: 1, Topic: "orderCreated", OrderingKey: "7"},
+		{ID: 2, Topic: "orderCreated", OrderingKey: "8"},
+		{ID: 3, Topic: "orderStatusChanged", OrderingKey: "7"},
+	}, nil)
+	pubSubMock.On("Publish", mock.Anything, "orderCreated", mock.MatchedBy(func(m *messaging.Message) bool {
+		return m.OrderingKey == "7"
+	})).Return("", errors.New("pubsub is down"))
+	pubSubMock.On("Publish", mock.Anything, "orderCreated", mock.MatchedBy(func(m *messaging.Message) bool {
+		return m.OrderingKey == "8"
+	})).Return("srv-2", nil)
+	l.On("Warn", mock.Anything, mock.Anything).Return()
+	outboxMock.On("MarkFailed", mock.Anything, uint(1), mock.AnythingOfType("time.Time"), "pubsub is down").Return(nil)
+	outboxMock.On("MarkSent", mock.Anything, uint(2)).Return(nil)
+	outboxMock.On("Release", mock.Anything, []uint{3}).Return(nil)
+
+	sent, err := relay.RelayBatch(context.Background())
+	if err != nil {
+		t.Fatalf("expected relaying the batch to succeed, but got %v", err)
+	}
+	if sent != 1 {
+		t.Errorf("expected 1 sent message, but got %d", sent)
+	}
+	pubSubMock.AssertNotCalled(t, "Publish", mock.Anything, "orderStatusChanged", mock.Anything)
+}
+
+func TestRelayBatchFailsWhenMarkingSentFails(t *testing.T) {
+	outboxMock := ordersMock.NewMockOutboxRepo(t)
+	txMock := ordersMock.NewMockTransactor(t)
+	pubSubMock := messagesMock.NewMockPublisher(t)
+	relay := outbox.NewRelay(outboxMock, txMock, pubSubMock, loggerMock.NewMockLogger(t), outbox.DefaultConfig())
+
+	txMock.EXPECT().WithinTransaction(mock.Anything, mock.Anything).RunAndReturn(runInTransaction).Once()
+	outboxMock.On("Claim", mock.Anything, 100, mock.AnythingOfType("time.Time")).Return([]models.OutboxMessage{{ID: 1, Topic: "orderCreated"}, {ID: 2, Topic: "orderCreated"}}, nil)
+	pubSubMock.On("Publish", mock.Anything, "orderCreated", mock.Anything).Return("srv-1", nil).Once()
+	outboxMock.On("MarkSent", mock.Anything, uint(1)).Return(errors.New("connection reset"))
+
+	if _, err := relay.RelayBatch(context.Background()); err == nil {
+		t.Errorf("expected relaying the batch to stop, the unmarked message is relayed again once its claim expires")
+	}
+	pubSubMock.AssertNumberOfCalls(t, "Publish", 1)
+}
+
+func TestRelayBatchPublishesOutsideOfTheClaimTransaction(t *testing.T) {
+	outboxMock := ordersMock.NewMockOutboxRepo(t)
+	txMock := ordersMock.NewMockTransactor(t)
+	pubSubMock := messagesMock.NewMockPublisher(t)
+	relay := outbox.NewRelay(outboxMock, txMock, pubSubMock, loggerMock.NewMockLogger(t), outbox.DefaultConfig())
+
+	inTransaction := false
+	txMock.EXPECT().WithinTransaction(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+		inTransaction = true
+		defer func() { inTransaction = false }()
+		return fn(ctx)
+	}).Once()
+	outboxMock.On("Claim", mock.Anything, 100, mock.AnythingOfType("time.Time")).Return([]models.OutboxMessage{{ID: 1, Topic: "orderCreated"}}, nil)
+	pubSubMock.On("Publish", mock.Anything, "orderCreated", mock.Anything).Run(func(mock.Arguments) {
+		if inTransaction {
+			t.Errorf("expected the message to be published after the claim transaction ended")
+		}
+	}).Return("srv-1", nil)
+	outboxMock.On("MarkSent", mock.Anything, uint(1)).Return(nil)
+	outboxMock.On("Release", mock.Anything, []uint(nil)).Return(nil)
+
+	if _, err := relay.RelayBatch(context.Background()); err != nil {
+		t.Fatalf("expected relaying the batch to succeed, but got %v", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	relay := outbox.NewRelay(nil, nil, nil, nil, outbox.Config{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second})
+	tests := map[int]time.Duration{
+		1: time.Second,
+		2: 2 * time.Second,
+		3: 4 * time.Second,
+		4: 8 * time.Second,
+		5: 10 * time.Second,
+		9: 10 * time.Second,
+	}
+	for attempts, expected := range tests {
+		if d := relay.Backoff(attempts); d != expected {
+			t.Errorf("expected backoff after %d attempts to be %v, but got %v", attempts, expected, d)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
+	"github.com/nawafswe/orders-service/internal/models"
+	"sort"
+)
+
+// Mode
+// how a grand total sent by the client that does not match the computed one is handled
+type Mode int
+
+const (
+	// Reject fails the order with PriceMismatchErr
+	Reject Mode = iota
+	// Overwrite replaces the grand total with the computed one
+	Overwrite
+)
+
+var modeNames = map[Mode]string{
+	Reject:    "reject",
+	Overwrite: "overwrite",
+}
+
+func (m Mode) String() string {
+	return modeNames[m]
+}
+
+// ParseMode
+// maps a mode name such as "reject" back to its Mode value
+func ParseMode(mode string) (Mode, error) {
+	for m, name := range modeNames {
+		if name == mode {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid pricing mode %v, expected reject or overwrite", mode)
+}
+
+// PricerImpl
+// the subtotal is the sum of the price times the quantity of every item, the discounts of the coupon of the order are taken off
+// and shared between the items they apply to, the taxes of the items are computed on what is left of their price, the fees of the fee policy and the tip of the customer are then added.
+// With tax inclusive pricing the subtotal is net of the taxes included in the prices, so the grand total is still the subtotal plus fees, taxes and tip.
+// Orders are priced in the currency of their restaurant, amounts sent without a currency (e.g. through the deprecated double fields) are in it
+type PricerImpl struct {
+	mode       Mode
+	currencies interfaces.RestaurantCurrencies
+	taxes      interfaces.TaxCalculator
+	discounts  interfaces.Discounter
+	fees       interfaces.FeePolicy
+}
+
+func NewPricer(mode Mode, currencies interfaces.RestaurantCurrencies, taxes interfaces.TaxCalculator, discounts interfaces.Discounter, fees interfaces.FeePolicy) interfaces.Pricer {
+	return PricerImpl{mode: mode, currencies: currencies, taxes: taxes, discounts: discounts, fees: fees}
+}
+
+// Price
+// sets the currency, breakdown and grand total of the order. A client that sent no grand total gets the computed one,
+// a mismatching one is rejected or overwritten depending on the mode. An amount in another currency than the restaurant's fails with CurrencyMismatchErr
+func (p PricerImpl) Price(ctx context.Context, order models.Order) (models.Order, error) {
+	currency, err := p.currencies.Currency(ctx, order.RestaurantId)
+	if err != nil {
+		return models.Order{}, fmt.Errorf("failed to get the currency of restaurant %v, err: %w", order.RestaurantId, err)
+	}
+	if order.Currency != "" && order.Currency != currency {
+		return models.Order{}, models.CurrencyMismatchErr{Expected: currency, Given: order.Currency}
+	}
+	breakdown := models.PriceBreakdown{
+		Subtotal:  models.Money{Currency: currency},
+		Fees:      models.Money{Currency: currency},
+		Taxes:     models.Money{Currency: currency},
+		Discounts: models.Money{Currency: currency},
+		Tip:       models.Money{Currency: currency},
+	}
+	// the items are copied before their currency is filled in, they are shared with the caller
+	items := make([]models.OrderedItem, len(order.Items))
+	for idx, i := range order.Items {
+		i.Price, i.PriceMajor = inCurrency(i.Price, i.PriceMajor, currency), 0
+		items[idx] = i
+		if breakdown.Subtotal, err = breakdown.Subtotal.Add(i.Price.Times(int64(i.OrderedQuantity))); err != nil {
+			return models.Order{}, err
+		}
+	}
+	order.Items = items
+	order.Currency = currency
+	if err := p.applyDiscounts(ctx, &order, &breakdown); err != nil {
+		return models.Order{}, err
+	}
+	if err := p.applyTaxes(ctx, &order, &breakdown); err != nil {
+		return models.Order{}, err
+	}
+	if err := p.applyFees(ctx, &order, &breakdown); err != nil {
+		return models.Order{}, err
+	}
+	total, err := breakdown.Total()
+	if err != nil {
+		return models.Order{}, err
+	}
+	given := inCurrency(order.GrandTotal, order.GrandTotalMajor, currency)
+	if given.Currency != currency {
+		return models.Order{}, models.CurrencyMismatchErr{Expected: currency, Given: given.Currency}
+	}
+	if !given.IsZero() && given != total && p.mode == Reject {
+		return models.Order{}, models.PriceMismatchErr{Given: given, Breakdown: breakdown}
+	}
+	order.Pricing = breakdown
+	order.GrandTotal = total
+	order.GrandTotalMajor = 0
+	return order, nil
+}
+
+// inCurrency
+// an amount sent without currency in the currency of the restaurant. Older clients send it as a double in major units,
+// it is only converted here because the decimals depend on the currency (e.g. 1500 JPY is 1500, not 150000, minor units)
+func inCurrency(m models.Money, major float64, currency string) models.Money {
+	if m.Currency != "" {
+		return m
+	}
+	if m.IsZero() && major != 0 {
+		return models.FromMajor(major, currency)
+	}
+	return models.Money{Amount: m.Amount, Currency: currency}
+}
+
+// applyDiscounts
+// keeps the discounts of the coupon on the order, adds them to the breakdown and shares each one between the items it applies to
+func (p PricerImpl) applyDiscounts(ctx context.Context, order *models.Order, breakdown *models.PriceBreakdown) error {
+	discounts, err := p.discounts.Discount(ctx, *order)
+	if err != nil {
+		return err
+	}
+	order.Discounts = discounts
+	for idx := range order.Items {
+		order.Items[idx].Discount = models.Money{Currency: order.Currency}
+	}
+	for _, d := range discounts {
+		if breakdown.Discounts, err = breakdown.Discounts.Add(d.Amount); err != nil {
+			return err
+		}
+		prorate(order.Items, d)
+	}
+	return nil
+}
+
+// prorate
+// shares the discount between the items it applies to in proportion of their amount, the minor units left by rounding down
+// go to the largest amounts so the shares add up to the discount
+func prorate(items []models.OrderedItem, d models.Discount) {
+	var eligible []int
+	var total int64
+	for idx, i := range items {
+		if d.OrderedItemId != 0 && i.OrderedItemId != d.OrderedItemId {
+			continue
+		}
+		eligible = append(eligible, idx)
+		total += i.Price.Times(int64(i.OrderedQuantity)).Amount
+	}
+	if total == 0 {
+		return
+	}
+	left := d.Amount.Amount
+	for _, idx := range eligible {
+		share := d.Amount.Amount * items[idx].Price.Times(int64(items[idx].OrderedQuantity)).Amount / total
+		items[idx].Discount.Amount += share
+		left -= share
+	}
+	sort.SliceStable(eligible, func(a, b int) bool {
+		return items[eligible[a]].Price.Times(int64(items[eligible[a]].OrderedQuantity)).Amount > items[eligible[b]].Price.Times(int64(items[eligible[b]].OrderedQuantity)).Amount
+	})
+	for n := 0; left > 0; n++ {
+		items[eligible[n%len(eligible)]].Discount.Amount++
+		left--
+	}
+}
+
+// applyFees
+// keeps the fees of the fee policy on the order and adds them and the tip of the customer to the breakdown
+func (p PricerImpl) applyFees(ctx context.Context, order *models.Order, breakdown *models.PriceBreakdown) error {
+	fees, err := p.fees.Fees(ctx, *order)
+	if err != nil {
+		return fmt.Errorf("failed to compute the fees of the order, err: %w", err)
+	}
+	order.Fees = fees
+	for _, f := range fees {
+		if breakdown.Fees, err = breakdown.Fees.Add(f.Amount); err != nil {
+			return err
+		}
+	}
+	if order.Tip.Currency == "" {
+		order.Tip.Currency = order.Currency
+	}
+	if order.Tip.Currency != order.Currency {
+		return models.CurrencyMismatchErr{Expected: order.Currency, Given: order.Tip.Currency}
+	}
+	if order.Tip.Amount < 0 {
+		return models.InvalidTipErr{Tip: order.Tip}
+	}
+	breakdown.Tip = order.Tip
+	return nil
+}
+
+// applyTaxes
+// keeps the taxes of every item and tax line on the order and adds their total to the breakdown
+func (p PricerImpl) applyTaxes(ctx context.Context, order *models.Order, breakdown *models.PriceBreakdown) error {
+	assessment, err := p.taxes.Calculate(ctx, *order)
+	if err != nil {
+		return fmt.Errorf("failed to compute the taxes of the order, err: %w", err)
+	}
+	for idx, t := range assessment.Items {
+		order.Items[idx].TaxCategory = t.Category
+		order.Items[idx].TaxRate = t.Rate
+		order.Items[idx].Tax = t.Tax
+	}
+	order.TaxRegion = assessment.Region
+	order.TaxInclusive = assessment.Inclusive
+	order.TaxLines = assessment.Lines
+	breakdown.Taxes = assessment.Total
+	if assessment.Inclusive {
+		breakdown.Subtotal.Amount -= assessment.Total.Amount
+	}
+	return nil
+}
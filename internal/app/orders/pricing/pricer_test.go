@@ -0,0 +1,304 @@
+package pricing_test
+
+import (
+	"context"
+	"errors"
+	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
+	"github.com/nawafswe/orders-service/internal/app/orders/fees"
+	"github.com/nawafswe/orders-service/internal/app/orders/pricing"
+	"github.com/nawafswe/orders-service/internal/app/orders/tax"
+	"github.com/nawafswe/orders-service/internal/models"
+	"reflect"
+	"testing"
+)
+
+// couponOff
+// a toy discounter taking a fixed amount off orders with a coupon
+type couponOff int64
+
+func (c couponOff) Discount(ctx context.Context, order models.Order) ([]models.Discount, error) {
+	if order.CouponCode == "" {
+		return nil, nil
+	}
+	return []models.Discount{{Code: order.CouponCode, Amount: models.Money{Amount: int64(c), Currency: order.Currency}}}, nil
+}
+
+// itemOff
+// a toy discounter taking a fixed amount off one item of orders with a coupon, like a buy_x_get_y promotion
+type itemOff struct {
+	OrderedItemId int64
+	Amount        int64
+}
+
+func (i itemOff) Discount(ctx context.Context, order models.Order) ([]models.Discount, error) {
+	if order.CouponCode == "" {
+		return nil, nil
+	}
+	return []models.Discount{{Code: order.CouponCode, OrderedItemId: i.OrderedItemId, Amount: models.Money{Amount: i.Amount, Currency: order.Currency}}}, nil
+}
+
+func usd(amount int64) models.Money {
+	return models.Money{Amount: amount, Currency: "USD"}
+}
+
+func jpy(amount int64) models.Money {
+	return models.Money{Amount: amount, Currency: "JPY"}
+}
+
+func kwd(amount int64) models.Money {
+	return models.Money{Amount: amount, Currency: "KWD"}
+}
+
+func TestPrice(t *testing.T) {
+	items := []models.OrderedItem{
+		{OrderedItemId: 1, OrderedQuantity: 3, Price: usd(10), Name: "Napkin"},
+		{OrderedItemId: 2, OrderedQuantity: 1, Price: usd(2470), Name: "Pizza"},
+	}
+	// restaurant 1 prices in USD, 3 in JPY, 4 in KWD and the others in the default SAR
+	currencies := pricing.NewStaticRestaurantCurrencies("SAR", map[int64]string{1: "USD", 3: "JPY", 4: "KWD"})
+	untaxed := tax.NewEngine("", nil, nil, nil)
+	// item 3 is in the reduced category
+	taxedIn := func(table tax.RateTable) interfaces.TaxCalculator {
+		return tax.NewEngine("US-NY", nil, map[string]tax.Jurisdiction{"US-NY": table}, map[int64]string{3: "reduced"})
+	}
+	noFees := fees.NewPolicy(nil, nil)
+	delivered := fees.NewPolicy(map[string]fees.Schedule{"USD": {DeliveryBands: []fees.Band{{UpTo: 5000, Fee: 300}}, ServiceRate: 0.02}}, nil)
+	reduced := []models.OrderedItem{items[0], items[1]}
+	reduced[0].OrderedItemId = 3
+	claimed := []models.OrderedItem{items[0], items[1]}
+	claimed[1].TaxCategory = "reduced"
+	tests := map[string]struct {
+		RestaurantId int64
+		Currency     string
+		CouponCode   string
+		Mode         pricing.Mode
+		Taxes        interfaces.TaxCalculator
+		// Discounts defaults to 500 off the basket
+		Discounts          interfaces.Discounter
+		Fees               interfaces.FeePolicy
+		Distance           int64
+		Tip                models.Money
+		Items              []models.OrderedItem
+		GrandTotal         models.Money
+		GrandTotalMajor    float64
+		ExpectedBreakdown  models.PriceBreakdown
+		ExpectedGrandTotal models.Money
+		ExpectedItemTaxes  []models.Money
+		// ExpectedItemDiscount the share of the discounts taken off every item
+		ExpectedItemDiscount []models.Money
+		ExpectedFees         []models.Fee
+		ExpectedErr          error
+	}{
+		"ComputeSubtotalFromItems": {
+			GrandTotal:         usd(2500),
+			ExpectedBreakdown:  models.PriceBreakdown{Subtotal: usd(2500), Fees: usd(0), Taxes: usd(0), Discounts: usd(0), Tip: usd(0)},
+			ExpectedGrandTotal: usd(2500),
+		},
+		"FillInMissingGrandTotal": {
+			ExpectedBreakdown:  models.PriceBreakdown{Subtotal: usd(2500), Fees: usd(0), Taxes: usd(0), Discounts: usd(0), Tip: usd(0)},
+			ExpectedGrandTotal: usd(2500),
+		},
+		"PriceAmountsWithoutCurrencyInTheRestaurantCurrency": {
+			RestaurantId:       2,
+			Items:              []models.OrderedItem{{OrderedItemId: 1, OrderedQuantity: 2, Price: models.Money{Amount: 500}, Name: "Onigiri"}},
+			GrandTotal:         models.Money{Amount: 1000},
+			ExpectedBreakdown:  models.PriceBreakdown{Subtotal: models.Money{Amount: 1000, Currency: "SAR"}, Fees: models.Money{Currency: "SAR"}, Taxes: models.Money{Currency: "SAR"}, Discounts: models.Money{Currency: "SAR"}, Tip: models.Money{Currency: "SAR"}},
+			ExpectedGrandTotal: models.Money{Amount: 1000, Currency: "SAR"},
+		},
+		"ReadDeprecatedAmountsWithTheDecimalsOfTheRestaurantCurrency": {
+			Items:              []models.OrderedItem{{OrderedItemId: 1, OrderedQuantity: 2, PriceMajor: 12.5, Name: "V60"}},
+			GrandTotalMajor:    25,
+			ExpectedBreakdown:  models.PriceBreakdown{Subtotal: usd(2500), Fees: usd(0), Taxes: usd(0), Discounts: usd(0), Tip: usd(0)},
+			ExpectedGrandTotal: usd(2500),
+		},
+		"ReadDeprecatedAmountsInJPYWithoutDecimals": {
+			RestaurantId:       3,
+			Items:              []models.OrderedItem{{OrderedItemId: 1, OrderedQuantity: 2, PriceMajor: 750, Name: "Onigiri"}},
+			GrandTotalMajor:    1500,
+			ExpectedBreakdown:  models.PriceBreakdown{Subtotal: jpy(1500), Fees: jpy(0), Taxes: jpy(0), Discounts: jpy(0), Tip: jpy(0)},
+			ExpectedGrandTotal: jpy(1500),
+		},
+		"ReadDeprecatedAmountsInKWDWithThreeDecimals": {
+			RestaurantId:       4,
+			Items:              []models.OrderedItem{{OrderedItemId: 1, OrderedQuantity: 1, PriceMajor: 1.25, Name: "Karak"}},
+			GrandTotalMajor:    1.25,
+			ExpectedBreakdown:  models.PriceBreakdown{Subtotal: kwd(1250), Fees: kwd(0), Taxes: kwd(0), Discounts: kwd(0), Tip: kwd(0)},
+			ExpectedGrandTotal: kwd(1250),
+		},
+		"RejectMismatchingGrandTotal": {
+			GrandTotal:  usd(3000),
+			ExpectedErr: models.PriceMismatchErr{Given: usd(3000), Breakdown: models.PriceBreakdown{Subtotal: usd(2500), Fees: usd(0), Taxes: usd(0), Discounts: usd(0), Tip: usd(0)}},
+		},
+		"RejectGrandTotalInAnotherCurrency": {
+			Items:       []models.OrderedItem{{OrderedItemId: 1, OrderedQuantity: 1, Price: usd(2500), Name: "Pizza"}},
+			GrandTotal:  models.Money{Amount: 2500, Currency: "EUR"},
+			ExpectedErr: models.CurrencyMismatchErr{Expected: "USD", Given: "EUR"},
+		},
+		"OverwriteMismatchingGrandTotal": {
+			Mode:               pricing.Overwrite,
+			GrandTotal:         usd(3000),
+			ExpectedBreakdown:  models.PriceBreakdown{Subtotal: usd(2500), Fees: usd(0), Taxes: usd(0), Discounts: usd(0), Tip: usd(0)},
+			ExpectedGrandTotal: usd(2500),
+		},
+		"FailForItemsInDifferentCurrencies": {
+			Items:       []models.OrderedItem{{OrderedItemId: 1, OrderedQuantity: 1, Price: usd(100), Name: "Pizza"}, {OrderedItemId: 2, OrderedQuantity: 1, Price: models.Money{Amount: 100, Currency: "EUR"}, Name: "Cola"}},
+			ExpectedErr: models.CurrencyMismatchErr{Expected: "USD", Given: "EUR"},
+		},
+		"FailForItemsInAnotherCurrencyThanTheRestaurant": {
+			RestaurantId: 2,
+			ExpectedErr:  models.CurrencyMismatchErr{Expected: "SAR", Given: "USD"},
+		},
+		"FailForAnOrderInAnotherCurrencyThanTheRestaurant": {
+			Currency:    "EUR",
+			ExpectedErr: models.CurrencyMismatchErr{Expected: "USD", Given: "EUR"},
+		},
+		"AddExclusiveTaxesPerItem": {
+			Taxes:              taxedIn(tax.RateTable{Rates: map[string]float64{"standard": 0.1, "reduced": 0.05}}),
+			Items:              reduced,
+			ExpectedBreakdown:  models.PriceBreakdown{Subtotal: usd(2500), Fees: usd(0), Taxes: usd(249), Discounts: usd(0), Tip: usd(0)},
+			ExpectedGrandTotal: usd(2749),
+			ExpectedItemTaxes:  []models.Money{usd(2), usd(247)},
+		},
+		"IgnoreTheTaxCategorySentWithTheItems": {
+			Taxes:              taxedIn(tax.RateTable{Rates: map[string]float64{"standard": 0.1, "reduced": 0.05}}),
+			Items:              claimed,
+			ExpectedBreakdown:  models.PriceBreakdown{Subtotal: usd(2500), Fees: usd(0), Taxes: usd(250), Discounts: usd(0), Tip: usd(0)},
+			ExpectedGrandTotal: usd(2750),
+			ExpectedItemTaxes:  []models.Money{usd(3), usd(247)},
+		},
+		"KeepInclusiveTaxesInTheGrandTotal": {
+			Taxes:              taxedIn(tax.RateTable{Inclusive: true, Rates: map[string]float64{"standard": 0.15}}),
+			GrandTotal:         usd(2500),
+			ExpectedBreakdown:  models.PriceBreakdown{Subtotal: usd(2174), Fees: usd(0), Taxes: usd(326), Discounts: usd(0), Tip: usd(0)},
+			ExpectedGrandTotal: usd(2500),
+			ExpectedItemTaxes:  []models.Money{usd(4), usd(322)},
+		},
+		"TaxWhatIsLeftOnceDiscountsAreTakenOff": {
+			// 500 off is shared as 6 off the napkins and 494 off the pizza, leaving 24 and 1976 to tax
+			Taxes:                taxedIn(tax.RateTable{Rates: map[string]float64{"standard": 0.1}}),
+			CouponCode:           "WELCOME",
+			GrandTotal:           usd(2200),
+			ExpectedBreakdown:    models.PriceBreakdown{Subtotal: usd(2500), Fees: usd(0), Taxes: usd(200), Discounts: usd(500), Tip: usd(0)},
+			ExpectedGrandTotal:   usd(2200),
+			ExpectedItemTaxes:    []models.Money{usd(2), usd(198)},
+			ExpectedItemDiscount: []models.Money{usd(6), usd(494)},
+		},
+		"TakeItemDiscountsOffThatItemOnly": {
+			Taxes:                taxedIn(tax.RateTable{Rates: map[string]float64{"standard": 0.1}}),
+			Discounts:            itemOff{OrderedItemId: 1, Amount: 10},
+			CouponCode:           "3FOR2",
+			GrandTotal:           usd(2739),
+			ExpectedBreakdown:    models.PriceBreakdown{Subtotal: usd(2500), Fees: usd(0), Taxes: usd(249), Discounts: usd(10), Tip: usd(0)},
+			ExpectedGrandTotal:   usd(2739),
+			ExpectedItemTaxes:    []models.Money{usd(2), usd(247)},
+			ExpectedItemDiscount: []models.Money{usd(10), usd(0)},
+		},
+		"KeepInclusiveTaxesOfWhatIsLeftOnceDiscountsAreTakenOff": {
+			Taxes:                taxedIn(tax.RateTable{Inclusive: true, Rates: map[string]float64{"standard": 0.15}}),
+			CouponCode:           "WELCOME",
+			GrandTotal:           usd(2000),
+			ExpectedBreakdown:    models.PriceBreakdown{Subtotal: usd(2239), Fees: usd(0), Taxes: usd(261), Discounts: usd(500), Tip: usd(0)},
+			ExpectedGrandTotal:   usd(2000),
+			ExpectedItemTaxes:    []models.Money{usd(3), usd(258)},
+			ExpectedItemDiscount: []models.Money{usd(6), usd(494)},
+		},
+		"AddFeesAndTip": {
+			Fees:               delivered,
+			Distance:           1200,
+			Tip:                usd(400),
+			GrandTotal:         usd(3250),
+			ExpectedBreakdown:  models.PriceBreakdown{Subtotal: usd(2500), Fees: usd(350), Taxes: usd(0), Discounts: usd(0), Tip: usd(400)},
+			ExpectedGrandTotal: usd(3250),
+			ExpectedFees:       []models.Fee{{Kind: "delivery", Amount: usd(300)}, {Kind: "service", Amount: usd(50)}},
+		},
+		"FailWhenTheOrderCannotBeDelivered": {
+			Fees:        delivered,
+			Distance:    5001,
+			ExpectedErr: errors.New("failed to compute the fees of the order, err: restaurant 1 delivers up to 5000 meters, the order is 5001 meters away"),
+		},
+		"FailForNegativeTip": {
+			Tip:         usd(-100),
+			ExpectedErr: models.InvalidTipErr{Tip: usd(-100)},
+		},
+		"FailForTipInAnotherCurrency": {
+			Tip:         models.Money{Amount: 100, Currency: "EUR"},
+			ExpectedErr: models.CurrencyMismatchErr{Expected: "USD", Given: "EUR"},
+		},
+		"FailForUnknownTaxCategory": {
+			Taxes:       taxedIn(tax.RateTable{Rates: map[string]float64{"standard": 0.1}}),
+			Items:       reduced,
+			ExpectedErr: errors.New("failed to compute the taxes of the order, err: tax category reduced is unknown in region US-NY"),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if test.Items == nil {
+				test.Items = items
+			}
+			if test.RestaurantId == 0 {
+				test.RestaurantId = 1
+			}
+			if test.Taxes == nil {
+				test.Taxes = untaxed
+			}
+			if test.Fees == nil {
+				test.Fees = noFees
+			}
+			if test.Discounts == nil {
+				test.Discounts = couponOff(500)
+			}
+			order := models.Order{RestaurantId: test.RestaurantId, Currency: test.Currency, CouponCode: test.CouponCode, DeliveryDistance: test.Distance, Tip: test.Tip, GrandTotal: test.GrandTotal, GrandTotalMajor: test.GrandTotalMajor, Items: test.Items}
+			o, err := pricing.NewPricer(test.Mode, currencies, test.Taxes, test.Discounts, test.Fees).Price(context.Background(), order)
+			if test.ExpectedErr != nil {
+				if err == nil || err.Error() != test.ExpectedErr.Error() {
+					t.Fatalf("expected error %v, but got %v", test.ExpectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(o.Pricing, test.ExpectedBreakdown) || o.GrandTotal != test.ExpectedGrandTotal {
+				t.Errorf("expected %v with grand total %v, but got %v with %v", test.ExpectedBreakdown, test.ExpectedGrandTotal, o.Pricing, o.GrandTotal)
+			}
+			if !reflect.DeepEqual(o.Fees, test.ExpectedFees) {
+				t.Errorf("expected fees %+v, but got %+v", test.ExpectedFees, o.Fees)
+			}
+			if o.Currency != o.GrandTotal.Currency {
+				t.Errorf("expected the order to be in %v, but got %v", o.GrandTotal.Currency, o.Currency)
+			}
+			for idx, i := range o.Items {
+				if i.Price.Currency != o.GrandTotal.Currency || i.PriceMajor != 0 {
+					t.Errorf("expected item %v to be priced in %v", i.Name, o.GrandTotal.Currency)
+				}
+				if test.ExpectedItemTaxes != nil && i.Tax != test.ExpectedItemTaxes[idx] {
+					t.Errorf("expected a tax of %v on item %v, but got %v", test.ExpectedItemTaxes[idx], i.Name, i.Tax)
+				}
+				if test.ExpectedItemDiscount != nil && i.Discount != test.ExpectedItemDiscount[idx] {
+					t.Errorf("expected a discount of %v on item %v, but got %v", test.ExpectedItemDiscount[idx], i.Name, i.Discount)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRestaurantCurrencies(t *testing.T) {
+	currencies, err := pricing.ParseRestaurantCurrencies("33=SAR, 41=AED")
+	if err != nil || !reflect.DeepEqual(currencies, map[int64]string{33: "SAR", 41: "AED"}) {
+		t.Errorf("expected the currencies of restaurants 33 and 41, but got %v, err: %v", currencies, err)
+	}
+	for _, invalid := range []string{"33", "x=SAR", "33=sar"} {
+		if _, err := pricing.ParseRestaurantCurrencies(invalid); err == nil {
+			t.Errorf("expected %v to be rejected", invalid)
+		}
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	if m, err := pricing.ParseMode("overwrite"); err != nil || m != pricing.Overwrite {
+		t.Errorf("expected overwrite, but got %v, err: %v", m, err)
+	}
+	if _, err := pricing.ParseMode("round"); err == nil {
+		t.Errorf("expected an error for an unknown mode")
+	}
+}
@@ -0,0 +1,52 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
+	"github.com/nawafswe/orders-service/internal/models"
+	"strconv"
+	"strings"
+)
+
+// StaticRestaurantCurrencies
+// restaurants price in the default currency unless configured otherwise
+type StaticRestaurantCurrencies struct {
+	defaultCurrency string
+	currencies      map[int64]string
+}
+
+func NewStaticRestaurantCurrencies(defaultCurrency string, currencies map[int64]string) interfaces.RestaurantCurrencies {
+	return StaticRestaurantCurrencies{defaultCurrency: defaultCurrency, currencies: currencies}
+}
+
+func (s StaticRestaurantCurrencies) Currency(ctx context.Context, restaurantId int64) (string, error) {
+	if c, ok := s.currencies[restaurantId]; ok {
+		return c, nil
+	}
+	return s.defaultCurrency, nil
+}
+
+// ParseRestaurantCurrencies
+// parses per restaurant currencies such as "33=SAR,41=AED"
+func ParseRestaurantCurrencies(currencies string) (map[int64]string, error) {
+	parsed := make(map[int64]string)
+	for _, entry := range strings.Split(currencies, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		id, currency, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid restaurant currency %v, expected <restaurant id>=<currency>", entry)
+		}
+		restaurantId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil || restaurantId <= 0 {
+			return nil, fmt.Errorf("invalid restaurant id %v", id)
+		}
+		if err := models.ValidateCurrency(currency); err != nil {
+			return nil, err
+		}
+		parsed[restaurantId] = currency
+	}
+	return parsed, nil
+}
@@ -0,0 +1,117 @@
+package promotion
+
+import (
+	"context"
+	"fmt"
+	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
+	"github.com/nawafswe/orders-service/internal/models"
+	"time"
+)
+
+// Evaluator
+// applies the promotion of the coupon of an order to its basket, the price times the quantity of its items.
+// The usage limit of the customer is checked when the order redeems the promotion, so a retried order is not refused for using it already
+type Evaluator struct {
+	repo interfaces.PromotionRepo
+	now  func() time.Time
+}
+
+func NewEvaluator(repo interfaces.PromotionRepo) interfaces.Discounter {
+	return Evaluator{repo: repo, now: time.Now}
+}
+
+func (e Evaluator) Discount(ctx context.Context, order models.Order) ([]models.Discount, error) {
+	if order.CouponCode == "" {
+		return nil, nil
+	}
+	p, err := e.repo.GetByCode(ctx, order.CouponCode)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := Evaluate(p, order, e.now())
+	if err != nil {
+		return nil, err
+	}
+	d := models.Discount{PromotionID: p.ID, Code: p.Code, Kind: p.Kind, Amount: amount}
+	if p.Kind == models.BuyXGetYPromotion.String() {
+		d.OrderedItemId = p.OrderedItemId
+	}
+	return []models.Discount{d}, nil
+}
+
+// Evaluate
+// what the promotion takes off the order at the given time, InvalidCouponErr when the order does not meet its conditions.
+// A promotion stored without being validated fails rather than taking off more than the basket or adding to it
+func Evaluate(p models.Promotion, order models.Order, at time.Time) (models.Money, error) {
+	if err := p.Validate(); err != nil {
+		return models.Money{}, fmt.Errorf("invalid promotion %v, err: %w", p.Code, err)
+	}
+	invalid := func(format string, args ...any) (models.Money, error) {
+		return models.Money{}, models.InvalidCouponErr{Code: p.Code, Reason: fmt.Sprintf(format, args...)}
+	}
+	if p.ValidFrom != nil && at.Before(*p.ValidFrom) {
+		return invalid("it is valid from %v", p.ValidFrom.Format(time.RFC3339))
+	}
+	if p.ValidUntil != nil && !at.Before(*p.ValidUntil) {
+		return invalid("it expired at %v", p.ValidUntil.Format(time.RFC3339))
+	}
+	basket := models.Money{Currency: order.Currency}
+	for _, i := range order.Items {
+		var err error
+		if basket, err = basket.Add(i.Price.Times(int64(i.OrderedQuantity))); err != nil {
+			return models.Money{}, err
+		}
+	}
+	if !p.MinimumBasket.IsZero() {
+		if p.MinimumBasket.Currency != basket.Currency {
+			return invalid("it is only valid for orders in %v", p.MinimumBasket.Currency)
+		}
+		if basket.Amount < p.MinimumBasket.Amount {
+			return invalid("the items should add up to at least %v", p.MinimumBasket)
+		}
+	}
+	kind, _ := models.ParsePromotionKind(p.Kind)
+	var discount models.Money
+	switch kind {
+	case models.PercentOffPromotion:
+		discount = basket.Scale(p.Percent)
+	case models.FixedOffPromotion:
+		if p.AmountOff.Currency != basket.Currency {
+			return invalid("it is only valid for orders in %v", p.AmountOff.Currency)
+		}
+		discount = p.AmountOff
+	case models.BuyXGetYPromotion:
+		discount = freeUnits(p, order)
+		if discount.IsZero() {
+			return invalid("at least %d units of item %v should be ordered", p.BuyQuantity+p.FreeQuantity, p.OrderedItemId)
+		}
+	}
+	if discount.Amount < 0 {
+		return models.Money{}, fmt.Errorf("promotion %v would add %v to the order", p.Code, models.Money{Amount: -discount.Amount, Currency: discount.Currency})
+	}
+	if discount.Amount > basket.Amount {
+		discount.Amount = basket.Amount
+	}
+	return discount, nil
+}
+
+// freeUnits
+// the price of the units of the item given for free, the cheapest price of the item when it is ordered on several lines
+func freeUnits(p models.Promotion, order models.Order) models.Money {
+	var quantity int64
+	var price models.Money
+	for _, i := range order.Items {
+		if i.OrderedItemId != p.OrderedItemId {
+			continue
+		}
+		if quantity == 0 || i.Price.Amount < price.Amount {
+			price = i.Price
+		}
+		quantity += int64(i.OrderedQuantity)
+	}
+	if p.BuyQuantity <= 0 || p.FreeQuantity <= 0 {
+		return models.Money{Currency: order.Currency}
+	}
+	free := quantity / int64(p.BuyQuantity+p.FreeQuantity) * int64(p.FreeQuantity)
+	return models.Money{Amount: price.Amount * free, Currency: order.Currency}
+}
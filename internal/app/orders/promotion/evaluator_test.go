@@ -0,0 +1,139 @@
+package promotion_test
+
+import (
+	"context"
+	"errors"
+	"github.com/nawafswe/orders-service/internal/app/orders/promotion"
+	"github.com/nawafswe/orders-service/internal/models"
+	ordersMock "github.com/nawafswe/orders-service/mocks/github.com/nawafswe/orders-service/pkg/v1"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+func usd(amount int64) models.Money {
+	return models.Money{Amount: amount, Currency: "USD"}
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	yesterday, tomorrow := now.Add(-24*time.Hour), now.Add(24*time.Hour)
+	order := models.Order{Currency: "USD", Items: []models.OrderedItem{
+		{OrderedItemId: 1, OrderedQuantity: 5, Price: usd(300), Name: "Taco"},
+		{OrderedItemId: 2, OrderedQuantity: 1, Price: usd(1000), Name: "Nachos"},
+	}}
+	tests := map[string]struct {
+		Promotion        models.Promotion
+		ExpectedDiscount models.Money
+		ExpectInvalid    bool
+	}{
+		"TakePercentOff": {
+			Promotion:        models.Promotion{Kind: "percent_off", Percent: 0.15},
+			ExpectedDiscount: usd(375),
+		},
+		"TakeFixedAmountOff": {
+			Promotion:        models.Promotion{Kind: "fixed_off", AmountOff: usd(500)},
+			ExpectedDiscount: usd(500),
+		},
+		"TakeAtMostTheBasketOff": {
+			Promotion:        models.Promotion{Kind: "fixed_off", AmountOff: usd(5000)},
+			ExpectedDiscount: usd(2500),
+		},
+		"FailForFixedAmountInAnotherCurrency": {
+			Promotion:     models.Promotion{Kind: "fixed_off", AmountOff: models.Money{Amount: 500, Currency: "EUR"}},
+			ExpectInvalid: true,
+		},
+		"GiveFreeUnits": {
+			// buy 2 get 1, 5 tacos make a single group of 3
+			Promotion:        models.Promotion{Kind: "buy_x_get_y", OrderedItemId: 1, BuyQuantity: 2, FreeQuantity: 1},
+			ExpectedDiscount: usd(300),
+		},
+		"FailWhenTooFewUnitsAreOrdered": {
+			Promotion:     models.Promotion{Kind: "buy_x_get_y", OrderedItemId: 2, BuyQuantity: 1, FreeQuantity: 1},
+			ExpectInvalid: true,
+		},
+		"ApplyAboveTheMinimumBasket": {
+			Promotion:        models.Promotion{Kind: "fixed_off", AmountOff: usd(500), MinimumBasket: usd(2500)},
+			ExpectedDiscount: usd(500),
+		},
+		"FailBelowTheMinimumBasket": {
+			Promotion:     models.Promotion{Kind: "fixed_off", AmountOff: usd(500), MinimumBasket: usd(3000)},
+			ExpectInvalid: true,
+		},
+		"ApplyWithinTheValidityWindow": {
+			Promotion:        models.Promotion{Kind: "percent_off", Percent: 0.1, ValidFrom: &yesterday, ValidUntil: &tomorrow},
+			ExpectedDiscount: usd(250),
+		},
+		"FailBeforeTheValidityWindow": {
+			Promotion:     models.Promotion{Kind: "percent_off", Percent: 0.1, ValidFrom: &tomorrow},
+			ExpectInvalid: true,
+		},
+		"FailOnceExpired": {
+			Promotion:     models.Promotion{Kind: "percent_off", Percent: 0.1, ValidUntil: &yesterday},
+			ExpectInvalid: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.Promotion.Code = "SPRING"
+			discount, err := promotion.Evaluate(test.Promotion, order, now)
+			var invalidErr models.InvalidCouponErr
+			if errors.As(err, &invalidErr) != test.ExpectInvalid {
+				t.Fatalf("expected an invalid coupon %v, but got %v", test.ExpectInvalid, err)
+			}
+			if !test.ExpectInvalid && discount != test.ExpectedDiscount {
+				t.Errorf("expected a discount of %v, but got %v", test.ExpectedDiscount, discount)
+			}
+		})
+	}
+}
+
+func TestEvaluateRejectsInvalidPromotions(t *testing.T) {
+	order := models.Order{Currency: "USD", Items: []models.OrderedItem{{OrderedItemId: 1, OrderedQuantity: 1, Price: usd(1000), Name: "Burrito"}}}
+	for name, p := range map[string]models.Promotion{
+		"PercentAboveOne":              {Code: "DOUBLE", Kind: "percent_off", Percent: 1.5},
+		"NegativePercent":              {Code: "NEGATIVE", Kind: "percent_off", Percent: -0.1},
+		"UnknownKind":                  {Code: "MYSTERY", Kind: "mystery"},
+		"NegativeAmountOff":            {Code: "SURCHARGE", Kind: "fixed_off", AmountOff: usd(-500)},
+		"AmountOffWithoutCurrency":     {Code: "NOCURRENCY", Kind: "fixed_off", AmountOff: models.Money{Amount: 500}},
+		"AmountOffInAnUnknownCurrency": {Code: "UNKNOWN", Kind: "fixed_off", AmountOff: models.Money{Amount: 500, Currency: "XYZ"}},
+		"NoPaidUnits":                  {Code: "FREE", Kind: "buy_x_get_y", OrderedItemId: 1, BuyQuantity: 0, FreeQuantity: 1},
+		"NegativeFreeUnits":            {Code: "TAKEBACK", Kind: "buy_x_get_y", OrderedItemId: 1, BuyQuantity: 1, FreeQuantity: -1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := promotion.Evaluate(p, order, time.Now()); err == nil {
+				t.Errorf("expected promotion %v to be rejected", p.Code)
+			}
+			if err := p.Validate(); err == nil {
+				t.Errorf("expected promotion %v to be invalid", p.Code)
+			}
+		})
+	}
+}
+
+func TestDiscount(t *testing.T) {
+	promotionsMock := ordersMock.NewMockPromotionRepo(t)
+	evaluator := promotion.NewEvaluator(promotionsMock)
+	order := models.Order{Currency: "USD", Items: []models.OrderedItem{{OrderedItemId: 1, OrderedQuantity: 2, Price: usd(1000), Name: "Burrito"}}}
+
+	if discounts, err := evaluator.Discount(context.Background(), order); err != nil || discounts != nil {
+		t.Errorf("expected no discount without coupon, but got %v, err: %v", discounts, err)
+	}
+	promotionsMock.AssertNotCalled(t, "GetByCode", mock.Anything, mock.Anything)
+
+	promotionsMock.On("GetByCode", mock.Anything, "SPRING").Return(models.Promotion{Model: gorm.Model{ID: 4}, Code: "SPRING", Kind: "percent_off", Percent: 0.5}, nil)
+	promotionsMock.On("GetByCode", mock.Anything, "WINTER").Return(models.Promotion{}, models.InvalidCouponErr{Code: "WINTER", Reason: "it does not exist"})
+	order.CouponCode = "SPRING"
+	discounts, err := evaluator.Discount(context.Background(), order)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(discounts) != 1 || discounts[0] != (models.Discount{PromotionID: 4, Code: "SPRING", Kind: "percent_off", Amount: usd(1000)}) {
+		t.Errorf("expected half of the basket off, but got %v", discounts)
+	}
+	order.CouponCode = "WINTER"
+	if _, err := evaluator.Discount(context.Background(), order); !errors.Is(err, models.InvalidCouponErr{Code: "WINTER", Reason: "it does not exist"}) {
+		t.Errorf("expected an unknown coupon to be invalid, but got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
+	"github.com/nawafswe/orders-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// IdempotencyRepoImpl
+// keys older than the retention window are treated as if they were never used
+type IdempotencyRepoImpl struct {
+	db        *gorm.DB
+	retention time.Duration
+}
+
+func NewIdempotencyRepo(d *gorm.DB, retention time.Duration) interfaces.IdempotencyRepo {
+	return IdempotencyRepoImpl{db: d, retention: retention}
+}
+
+func (r IdempotencyRepoImpl) Find(ctx context.Context, key string) (models.IdempotencyKey, bool, error) {
+	var k models.IdempotencyKey
+	tx := conn(ctx, r.db).Where("key = ? AND created_at > ?", key, time.Now().Add(-r.retention)).First(&k)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return models.IdempotencyKey{}, false, nil
+		}
+		return models.IdempotencyKey{}, false, fmt.Errorf("Find: %w", tx.Error)
+	}
+	return k, true, nil
+}
+
+// Save
+// inserts the key, or takes over an expired one. Fails with models.IdempotencyKeyInUseErr while the key is retained
+func (r IdempotencyRepoImpl) Save(ctx context.Context, key models.IdempotencyKey) error {
+	tx := conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"order_id", "request_hash", "created_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Lt{Column: clause.Column{Table: "idempotency_keys", Name: "created_at"}, Value: time.Now().Add(-r.retention)},
+		}},
+	}).Create(&key)
+	if tx.Error != nil {
+		return fmt.Errorf("Save: %w", tx.Error)
+	}
+	if tx.RowsAffected == 0 {
+		return models.IdempotencyKeyInUseErr{Key: key.Key}
+	}
+	return nil
+}
+
+func (r IdempotencyRepoImpl) DeleteExpired(ctx context.Context) (int64, error) {
+	tx := conn(ctx, r.db).Where("created_at <= ?", time.Now().Add(-r.retention)).Delete(&models.IdempotencyKey{})
+	if tx.Error != nil {
+		return 0, fmt.Errorf("DeleteExpired: %w", tx.Error)
+	}
+	return tx.RowsAffected, nil
+}
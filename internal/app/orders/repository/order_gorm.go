@@ -7,6 +7,7 @@ import (
 	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
 	"github.com/nawafswe/orders-service/internal/models"
 	"gorm.io/gorm"
+	"time"
 )
 
 type OrderRepoImpl struct {
@@ -18,7 +19,13 @@ func NewOrderRepo(d *gorm.DB) interfaces.OrderRepo {
 }
 
 func (r OrderRepoImpl) Create(ctx context.Context, order models.Order) (models.Order, error) {
-	tx := r.db.WithContext(ctx).Create(&order)
+	withLegacyAmounts(&order)
+	if order.StatusChangedAt == nil {
+		// the order is in its first status since now, ListStale relies on it being set
+		now := time.Now()
+		order.StatusChangedAt = &now
+	}
+	tx := conn(ctx, r.db).Create(&order)
 
 	if tx.Error != nil {
 		return models.Order{}, fmt.Errorf("error occurred while creating a new order, err: %w", tx.Error)
@@ -28,20 +35,136 @@ func (r OrderRepoImpl) Create(ctx context.Context, order models.Order) (models.O
 	}
 	return order, nil
 }
-func (r OrderRepoImpl) UpdateOrderStatus(ctx context.Context, id int64, status string) (models.Order, error) {
 
-	tx := r.db.WithContext(ctx).Where("id = ? ", id).Updates(&models.Order{Status: status})
+// withLegacyAmounts
+// fills in the deprecated float columns from the Money amounts, they are written until no replica reads them anymore
+func withLegacyAmounts(order *models.Order) {
+	order.LegacyGrandTotal = order.GrandTotal.Major()
+	order.LegacyCancellationFee = order.CancellationFee.Major()
+	order.Pricing.LegacySubtotal = order.Pricing.Subtotal.Major()
+	order.Pricing.LegacyFees = order.Pricing.Fees.Major()
+	order.Pricing.LegacyTaxes = order.Pricing.Taxes.Major()
+	for idx := range order.Items {
+		order.Items[idx].LegacyPrice = order.Items[idx].Price.Major()
+	}
+}
+
+func (r OrderRepoImpl) GetById(ctx context.Context, id int64) (models.Order, error) {
+	var o models.Order
+	tx := conn(ctx, r.db).Preload("Items").Preload("TaxLines").Preload("Discounts").Preload("Fees").First(&o, id)
 	if tx.Error != nil {
 		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
-			return models.Order{}, fmt.Errorf("updating status failed due to invalid order id was given %d", id)
+			return models.Order{}, models.OrderNotFoundErr{Id: id}
 		}
+		return models.Order{}, fmt.Errorf("GetById: %w", tx.Error)
+	}
+	return o, nil
+}
+
+func (r OrderRepoImpl) List(ctx context.Context, filter models.OrderFilter) ([]models.Order, error) {
+	q := conn(ctx, r.db).Preload("Items").Preload("TaxLines").Preload("Discounts").Preload("Fees")
+	if filter.CustomerId != 0 {
+		q = q.Where("customer_id = ?", filter.CustomerId)
+	}
+	if filter.RestaurantId != 0 {
+		q = q.Where("restaurant_id = ?", filter.RestaurantId)
+	}
+	if filter.Status != "" {
+		q = q.Where("status = ?", filter.Status)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		q = q.Where("created_at >= ?", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		q = q.Where("created_at < ?", filter.CreatedTo)
+	}
+	if filter.AfterId != 0 {
+		q = q.Where("id < ?", filter.AfterId)
+	}
+	var orders []models.Order
+	if err := q.Order("id DESC").Limit(filter.Limit).Find(&orders).Error; err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	return orders, nil
+}
+
+// ListStale
+// served by the (status, status_changed_at) index, status_changed_at is set when the order is created
+// and backfilled by db.BackfillStatusChangedAt for orders created before
+func (r OrderRepoImpl) ListStale(ctx context.Context, status string, changedBefore time.Time, limit int) ([]models.Order, error) {
+	var orders []models.Order
+	err := conn(ctx, r.db).
+		Where("status = ? AND status_changed_at < ?", status, changedBefore).
+		Order("status_changed_at, id").
+		Limit(limit).
+		Find(&orders).Error
+	if err != nil {
+		return nil, fmt.Errorf("ListStale: %w", err)
+	}
+	return orders, nil
+}
+
+// UpdateOrderStatus
+// moves the order to the new status only if it is still in the previous status of the change, so concurrent or replayed updates cannot overwrite each other.
+// The reason and actor of the change are kept on the order
+func (r OrderRepoImpl) UpdateOrderStatus(ctx context.Context, change models.StatusChange) (models.Order, error) {
+	tx := conn(ctx, r.db).Model(&models.Order{}).Where("id = ? AND status = ?", change.OrderId, change.PreviousStatus).Updates(map[string]any{
+		"status":             change.Status,
+		"status_reason_code": change.ReasonCode,
+		"status_reason":      change.Reason,
+		"status_changed_by":  change.Actor.String(),
+		"status_changed_at":  change.ChangedAt,
+	})
+	if tx.Error != nil {
 		return models.Order{}, fmt.Errorf("UpdateOrderStatus: %w", tx.Error)
 	}
 	if tx.RowsAffected == 0 {
-		return models.Order{}, fmt.Errorf("order with id %v not found", id)
+		return models.Order{}, models.InvalidStatusChangeErr{Message: fmt.Sprintf("order with id %v is no longer in status '%v'", change.OrderId, change.PreviousStatus)}
 	}
-	var o models.Order
-	tx.Scan(&o)
+	return r.GetById(ctx, change.OrderId)
+}
 
-	return o, nil
+func (r OrderRepoImpl) SetCancellationFee(ctx context.Context, orderId int64, fee models.Money) error {
+	tx := conn(ctx, r.db).Model(&models.Order{}).Where("id = ?", orderId).Updates(map[string]any{
+		"cancellation_fee_amount":   fee.Amount,
+		"cancellation_fee_currency": fee.Currency,
+		"cancellation_fee":          fee.Major(),
+	})
+	if tx.Error != nil {
+		return fmt.Errorf("SetCancellationFee: %w", tx.Error)
+	}
+	if tx.RowsAffected == 0 {
+		return models.OrderNotFoundErr{Id: orderId}
+	}
+	return nil
+}
+
+// SalesByCurrency
+// orders are summed in the currency they were placed in, the conversion is left to the caller
+func (r OrderRepoImpl) SalesByCurrency(ctx context.Context, filter models.SalesReportFilter) ([]models.CurrencyTotal, error) {
+	q := conn(ctx, r.db).Model(&models.Order{}).
+		Select("grand_total_currency AS currency, SUM(grand_total_amount) AS amount, COUNT(*) AS orders").
+		Where("status NOT IN ?", []string{models.Rejected.String(), models.Cancelled.String()})
+	if filter.RestaurantId != 0 {
+		q = q.Where("restaurant_id = ?", filter.RestaurantId)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		q = q.Where("created_at >= ?", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		q = q.Where("created_at < ?", filter.CreatedTo)
+	}
+	var rows []struct {
+		Currency string
+		Amount   int64
+		Orders   int64
+	}
+	if err := q.Group("grand_total_currency").Order("grand_total_currency").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("SalesByCurrency: %w", err)
+	}
+	totals := make([]models.CurrencyTotal, 0, len(rows))
+	for _, row := range rows {
+		totals = append(totals, models.CurrencyTotal{Total: models.Money{Amount: row.Amount, Currency: row.Currency}, Orders: row.Orders})
+	}
+	return totals, nil
 }
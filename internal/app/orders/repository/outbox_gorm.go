@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
+	"github.com/nawafswe/orders-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type OutboxRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepo(d *gorm.DB) interfaces.OutboxRepo {
+	return OutboxRepoImpl{db: d}
+}
+
+func (r OutboxRepoImpl) Add(ctx context.Context, messages []models.OutboxMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	if err := conn(ctx, r.db).Create(&messages).Error; err != nil {
+		return fmt.Errorf("error occurred while adding messages to the outbox, err: %w", err)
+	}
+	return nil
+}
+
+// Claim
+// leases up to limit due messages until leaseUntil, so they can be published outside of a transaction without another relay picking them up.
+// Rows locked by another relay are skipped. A message is held back while an earlier unsent message of its ordering key waits for a retry,
+// so the messages of a key are never published out of order
+func (r OutboxRepoImpl) Claim(ctx context.Context, limit int, leaseUntil time.Time) ([]models.OutboxMessage, error) {
+	now := time.Now()
+	var messages []models.OutboxMessage
+	tx := conn(ctx, r.db).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("sent_at IS NULL AND next_attempt_at <= ?", now).
+		Where(`ordering_key = '' OR NOT EXISTS (SELECT 1 FROM outbox_messages earlier WHERE earlier.ordering_key = outbox_messages.ordering_key
+			AND earlier.id < outbox_messages.id AND earlier.sent_at IS NULL AND earlier.next_attempt_at > ?)`, now).
+		Order("id").
+		Limit(limit).
+		Find(&messages)
+	if tx.Error != nil {
+		return nil, fmt.Errorf("Claim: %w", tx.Error)
+	}
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	ids := make([]uint, 0, len(messages))
+	for _, m := range messages {
+		ids = append(ids, m.ID)
+	}
+	if err := conn(ctx, r.db).Model(&models.OutboxMessage{}).Where("id IN ?", ids).Update("next_attempt_at", leaseUntil).Error; err != nil {
+		return nil, fmt.Errorf("Claim: %w", err)
+	}
+	return messages, nil
+}
+
+// Release
+// gives claimed messages back without counting an attempt, they are due again right away
+func (r OutboxRepoImpl) Release(ctx context.Context, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := conn(ctx, r.db).Model(&models.OutboxMessage{}).Where("id IN ?", ids).Update("next_attempt_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("Release: %w", err)
+	}
+	return nil
+}
+
+func (r OutboxRepoImpl) MarkSent(ctx context.Context, id uint) error {
+	tx := conn(ctx, r.db).Model(&models.OutboxMessage{}).Where("id = ?", id).Updates(map[string]any{
+		"sent_at":    time.Now(),
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": "",
+	})
+	if tx.Error != nil {
+		return fmt.Errorf("MarkSent: %w", tx.Error)
+	}
+	return nil
+}
+
+func (r OutboxRepoImpl) MarkFailed(ctx context.Context, id uint, nextAttemptAt time.Time, reason string) error {
+	tx := conn(ctx, r.db).Model(&models.OutboxMessage{}).Where("id = ?", id).Updates(map[string]any{
+		"next_attempt_at": nextAttemptAt,
+		"attempts":        gorm.Expr("attempts + 1"),
+		"last_error":      reason,
+	})
+	if tx.Error != nil {
+		return fmt.Errorf("MarkFailed: %w", tx.Error)
+	}
+	return nil
+}
+
+func (r OutboxRepoImpl) Stats(ctx context.Context) (models.OutboxStats, error) {
+	var row struct {
+		Pending         int64
+		OldestPendingAt *time.Time
+	}
+	tx := conn(ctx, r.db).Model(&models.OutboxMessage{}).
+		Select("count(*) AS pending, min(created_at) AS oldest_pending_at").
+		Where("sent_at IS NULL").
+		Scan(&row)
+	if tx.Error != nil {
+		return models.OutboxStats{}, fmt.Errorf("Stats: %w", tx.Error)
+	}
+	stats := models.OutboxStats{Pending: row.Pending}
+	if row.OldestPendingAt != nil {
+		stats.OldestPendingAt = *row.OldestPendingAt
+	}
+	return stats, nil
+}
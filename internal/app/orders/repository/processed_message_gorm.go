@@ -0,0 +1,41 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
+	"github.com/nawafswe/orders-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type ProcessedMessageRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewProcessedMessageRepo(d *gorm.DB) interfaces.ProcessedMessageRepo {
+	return ProcessedMessageRepoImpl{db: d}
+}
+
+// MarkProcessed
+// records the message as processed, returns false if it was recorded before
+func (r ProcessedMessageRepoImpl) MarkProcessed(ctx context.Context, subscription string, messageId string) (bool, error) {
+	tx := conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ProcessedMessage{
+		Subscription: subscription,
+		MessageId:    messageId,
+		ProcessedAt:  time.Now(),
+	})
+	if tx.Error != nil {
+		return false, fmt.Errorf("MarkProcessed: %w", tx.Error)
+	}
+	return tx.RowsAffected == 1, nil
+}
+
+func (r ProcessedMessageRepoImpl) DeleteProcessedBefore(ctx context.Context, before time.Time) (int64, error) {
+	tx := conn(ctx, r.db).Where("processed_at < ?", before).Delete(&models.ProcessedMessage{})
+	if tx.Error != nil {
+		return 0, fmt.Errorf("DeleteProcessedBefore: %w", tx.Error)
+	}
+	return tx.RowsAffected, nil
+}
@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
+	"github.com/nawafswe/orders-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type PromotionRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewPromotionRepo(d *gorm.DB) interfaces.PromotionRepo {
+	return PromotionRepoImpl{db: d}
+}
+
+func (r PromotionRepoImpl) GetByCode(ctx context.Context, code string) (models.Promotion, error) {
+	var p models.Promotion
+	tx := conn(ctx, r.db).Where("code = ?", code).First(&p)
+	if tx.Error != nil {
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return models.Promotion{}, models.InvalidCouponErr{Code: code, Reason: "it does not exist"}
+		}
+		return models.Promotion{}, fmt.Errorf("GetByCode: %w", tx.Error)
+	}
+	return p, nil
+}
+
+// Redeem
+// the promotion row is locked until the transaction ends, so concurrent orders of a customer cannot both take its last use
+func (r PromotionRepoImpl) Redeem(ctx context.Context, redemption models.Redemption) error {
+	db := conn(ctx, r.db)
+	var p models.Promotion
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&p, redemption.PromotionID).Error; err != nil {
+		return fmt.Errorf("Redeem: %w", err)
+	}
+	if p.MaxUsesPerCustomer > 0 {
+		var used int64
+		err := db.Model(&models.Redemption{}).
+			Where("promotion_id = ? AND customer_id = ? AND reverted_at IS NULL", redemption.PromotionID, redemption.CustomerId).
+			Count(&used).Error
+		if err != nil {
+			return fmt.Errorf("Redeem: %w", err)
+		}
+		if used >= p.MaxUsesPerCustomer {
+			return models.InvalidCouponErr{Code: p.Code, Reason: fmt.Sprintf("it can only be used %d times", p.MaxUsesPerCustomer)}
+		}
+	}
+	if err := db.Create(&redemption).Error; err != nil {
+		return fmt.Errorf("Redeem: %w", err)
+	}
+	return nil
+}
+
+func (r PromotionRepoImpl) RevertRedemptions(ctx context.Context, orderId int64, revertedAt time.Time) error {
+	tx := conn(ctx, r.db).Model(&models.Redemption{}).
+		Where("order_id = ? AND reverted_at IS NULL", orderId).
+		Update("reverted_at", revertedAt)
+	if tx.Error != nil {
+		return fmt.Errorf("RevertRedemptions: %w", tx.Error)
+	}
+	return nil
+}
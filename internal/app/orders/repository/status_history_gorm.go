@@ -0,0 +1,59 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
+	"github.com/nawafswe/orders-service/internal/models"
+	"gorm.io/gorm"
+)
+
+type StatusHistoryRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewStatusHistoryRepo(d *gorm.DB) interfaces.StatusHistoryRepo {
+	return StatusHistoryRepoImpl{db: d}
+}
+
+func (r StatusHistoryRepoImpl) Add(ctx context.Context, change models.StatusChange) error {
+	tx := conn(ctx, r.db).Create(&models.OrderStatusHistory{
+		OrderId:        change.OrderId,
+		PreviousStatus: change.PreviousStatus,
+		Status:         change.Status,
+		ReasonCode:     change.ReasonCode,
+		Reason:         change.Reason,
+		Actor:          change.Actor.String(),
+		ChangedAt:      change.ChangedAt,
+	})
+	if tx.Error != nil {
+		return fmt.Errorf("error occurred while adding status history of order %v, err: %w", change.OrderId, tx.Error)
+	}
+	return nil
+}
+
+// List
+// the transitions of the order, oldest first
+func (r StatusHistoryRepoImpl) List(ctx context.Context, orderId int64) ([]models.StatusChange, error) {
+	var history []models.OrderStatusHistory
+	if err := conn(ctx, r.db).Where("order_id = ?", orderId).Order("changed_at, id").Find(&history).Error; err != nil {
+		return nil, fmt.Errorf("List: %w", err)
+	}
+	changes := make([]models.StatusChange, 0, len(history))
+	for _, h := range history {
+		actor, err := models.ParseActor(h.Actor)
+		if err != nil {
+			return nil, fmt.Errorf("List: status history %v of order %v, err: %w", h.ID, orderId, err)
+		}
+		changes = append(changes, models.StatusChange{
+			OrderId:        h.OrderId,
+			PreviousStatus: h.PreviousStatus,
+			Status:         h.Status,
+			ReasonCode:     h.ReasonCode,
+			Reason:         h.Reason,
+			Actor:          actor,
+			ChangedAt:      h.ChangedAt,
+		})
+	}
+	return changes, nil
+}
@@ -0,0 +1,37 @@
+package repo
+
+import (
+	"context"
+	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+type TransactorImpl struct {
+	db *gorm.DB
+}
+
+func NewTransactor(d *gorm.DB) interfaces.Transactor {
+	return TransactorImpl{db: d}
+}
+
+// WithinTransaction
+// runs fn in a db transaction carried by the context, repositories called with that context join it.
+// Nested calls reuse the outer transaction
+func (t TransactorImpl) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns the transaction of the context if there is one, the given connection otherwise
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
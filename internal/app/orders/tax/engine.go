@@ -0,0 +1,136 @@
+package tax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
+	"github.com/nawafswe/orders-service/internal/models"
+)
+
+// StandardCategory
+// the category of items the catalog does not list
+const StandardCategory = "standard"
+
+// Jurisdiction
+// computes the taxes of the orders of restaurants in one region, the region is set by the Engine
+type Jurisdiction interface {
+	Assess(ctx context.Context, order models.Order) (models.TaxAssessment, error)
+}
+
+// Engine
+// hands every order to the jurisdiction of the region of its restaurant, restaurants without a region are in the default one.
+// Orders of a restaurant without any region are not taxed. The category of every item is the one of the catalog,
+// keyed by item id, whatever the order carries, items the catalog does not list are standard
+type Engine struct {
+	defaultRegion string
+	restaurants   map[int64]string
+	jurisdictions map[string]Jurisdiction
+	categories    map[int64]string
+}
+
+func NewEngine(defaultRegion string, restaurants map[int64]string, jurisdictions map[string]Jurisdiction, categories map[int64]string) interfaces.TaxCalculator {
+	return Engine{defaultRegion: defaultRegion, restaurants: restaurants, jurisdictions: jurisdictions, categories: categories}
+}
+
+func (e Engine) Calculate(ctx context.Context, order models.Order) (models.TaxAssessment, error) {
+	order.Items = e.categorize(order.Items)
+	region, ok := e.restaurants[order.RestaurantId]
+	if !ok {
+		region = e.defaultRegion
+	}
+	if region == "" {
+		return untaxed(order), nil
+	}
+	j, ok := e.jurisdictions[region]
+	if !ok {
+		return models.TaxAssessment{}, fmt.Errorf("no tax jurisdiction for region %v of restaurant %v", region, order.RestaurantId)
+	}
+	assessment, err := j.Assess(ctx, order)
+	var unknownErr models.UnknownTaxCategoryErr
+	if errors.As(err, &unknownErr) {
+		unknownErr.Region = region
+		return models.TaxAssessment{}, unknownErr
+	}
+	if err != nil {
+		return models.TaxAssessment{}, err
+	}
+	assessment.Region = region
+	return assessment, nil
+}
+
+// categorize
+// copies the items with the category of the catalog, so the items of the order are left untouched
+func (e Engine) categorize(items []models.OrderedItem) []models.OrderedItem {
+	categorized := make([]models.OrderedItem, len(items))
+	for idx, i := range items {
+		i.TaxCategory = e.categories[i.OrderedItemId]
+		categorized[idx] = i
+	}
+	return categorized
+}
+
+// untaxed
+// an assessment without any tax
+func untaxed(order models.Order) models.TaxAssessment {
+	assessment := models.TaxAssessment{Items: make([]models.ItemTax, len(order.Items)), Total: models.Money{Currency: order.Currency}}
+	for idx, i := range order.Items {
+		assessment.Items[idx] = models.ItemTax{Category: category(i), Tax: models.Money{Currency: order.Currency}}
+	}
+	return assessment
+}
+
+func category(i models.OrderedItem) string {
+	if i.TaxCategory == "" {
+		return StandardCategory
+	}
+	return i.TaxCategory
+}
+
+// RateTable
+// taxes every item at the rate of its category, on its price net of its discount. Taxes are rounded per item,
+// with inclusive pricing the tax is the part of the price above its net amount
+type RateTable struct {
+	Inclusive bool               `json:"inclusive"`
+	Rates     map[string]float64 `json:"rates"`
+}
+
+func (r RateTable) Assess(ctx context.Context, order models.Order) (models.TaxAssessment, error) {
+	assessment := models.TaxAssessment{Inclusive: r.Inclusive, Items: make([]models.ItemTax, 0, len(order.Items)), Total: models.Money{Currency: order.Currency}}
+	lines := make(map[models.ItemTax]int)
+	for _, i := range order.Items {
+		c := category(i)
+		rate, ok := r.Rates[c]
+		if !ok {
+			return models.TaxAssessment{}, models.UnknownTaxCategoryErr{Category: c}
+		}
+		amount := i.Price.Times(int64(i.OrderedQuantity))
+		amount.Amount -= i.Discount.Amount
+		taxable, tax := amount, amount.Scale(rate)
+		if r.Inclusive {
+			taxable = amount.Scale(1 / (1 + rate))
+			tax = models.Money{Amount: amount.Amount - taxable.Amount, Currency: amount.Currency}
+		}
+		assessment.Items = append(assessment.Items, models.ItemTax{Category: c, Rate: rate, Tax: tax})
+		// items of the same category and rate share a line
+		key := models.ItemTax{Category: c, Rate: rate}
+		idx, ok := lines[key]
+		if !ok {
+			idx = len(assessment.Lines)
+			lines[key] = idx
+			assessment.Lines = append(assessment.Lines, models.TaxLine{Category: c, Rate: rate, Taxable: models.Money{Currency: amount.Currency}, Tax: models.Money{Currency: amount.Currency}})
+		}
+		var err error
+		line := &assessment.Lines[idx]
+		if line.Taxable, err = line.Taxable.Add(taxable); err != nil {
+			return models.TaxAssessment{}, err
+		}
+		if line.Tax, err = line.Tax.Add(tax); err != nil {
+			return models.TaxAssessment{}, err
+		}
+		if assessment.Total, err = assessment.Total.Add(tax); err != nil {
+			return models.TaxAssessment{}, err
+		}
+	}
+	return assessment, nil
+}
@@ -0,0 +1,132 @@
+package tax_test
+
+import (
+	"context"
+	"errors"
+	"github.com/nawafswe/orders-service/internal/app/orders/tax"
+	"github.com/nawafswe/orders-service/internal/models"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func sar(amount int64) models.Money {
+	return models.Money{Amount: amount, Currency: "SAR"}
+}
+
+func TestEngine(t *testing.T) {
+	items := []models.OrderedItem{
+		{OrderedItemId: 1, Name: "Burger", OrderedQuantity: 2, Price: sar(2000)},
+		{OrderedItemId: 2, Name: "Water", OrderedQuantity: 1, Price: sar(300)},
+		// the category sent with the order is not the one of the catalog
+		{OrderedItemId: 3, Name: "Fries", OrderedQuantity: 1, Price: sar(1000), TaxCategory: "reduced"},
+	}
+	engine := tax.NewEngine("SA", map[int64]string{41: "AE", 50: "NONE"}, map[string]tax.Jurisdiction{
+		"SA": tax.RateTable{Inclusive: true, Rates: map[string]float64{"standard": 0.15, "reduced": 0.05}},
+		"AE": tax.RateTable{Rates: map[string]float64{"standard": 0.05}},
+	}, map[int64]string{2: "reduced", 3: "standard"})
+	tests := map[string]struct {
+		RestaurantId int64
+		Items        []models.OrderedItem
+		Expected     models.TaxAssessment
+		ExpectedErr  error
+	}{
+		"TaxInTheDefaultRegion": {
+			RestaurantId: 33,
+			Items:        items,
+			Expected: models.TaxAssessment{
+				Region:    "SA",
+				Inclusive: true,
+				Items:     []models.ItemTax{{Category: "standard", Rate: 0.15, Tax: sar(522)}, {Category: "reduced", Rate: 0.05, Tax: sar(14)}, {Category: "standard", Rate: 0.15, Tax: sar(130)}},
+				Lines: []models.TaxLine{
+					{Category: "standard", Rate: 0.15, Taxable: sar(4348), Tax: sar(652)},
+					{Category: "reduced", Rate: 0.05, Taxable: sar(286), Tax: sar(14)},
+				},
+				Total: sar(666),
+			},
+		},
+		"TaxInTheRegionOfTheRestaurant": {
+			RestaurantId: 41,
+			Items:        items[:1],
+			Expected: models.TaxAssessment{
+				Region: "AE",
+				Items:  []models.ItemTax{{Category: "standard", Rate: 0.05, Tax: sar(200)}},
+				Lines:  []models.TaxLine{{Category: "standard", Rate: 0.05, Taxable: sar(4000), Tax: sar(200)}},
+				Total:  sar(200),
+			},
+		},
+		"FailForUnknownCategory": {
+			RestaurantId: 41,
+			Items:        items,
+			ExpectedErr:  models.UnknownTaxCategoryErr{Region: "AE", Category: "reduced"},
+		},
+		"FailForRegionWithoutRates": {
+			RestaurantId: 50,
+			Items:        items,
+			ExpectedErr:  errors.New("no tax jurisdiction for region NONE of restaurant 50"),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assessment, err := engine.Calculate(context.Background(), models.Order{RestaurantId: test.RestaurantId, Currency: "SAR", Items: test.Items})
+			if test.ExpectedErr != nil {
+				if err == nil || err.Error() != test.ExpectedErr.Error() {
+					t.Fatalf("expected error %v, but got %v", test.ExpectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(assessment, test.Expected) {
+				t.Errorf("expected %+v, but got %+v", test.Expected, assessment)
+			}
+		})
+	}
+}
+
+func TestEngineWithoutRegion(t *testing.T) {
+	assessment, err := tax.NewEngine("", nil, nil, nil).Calculate(context.Background(), models.Order{Currency: "SAR", Items: []models.OrderedItem{{Price: sar(100), OrderedQuantity: 1}}})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if assessment.Total != sar(0) || len(assessment.Lines) != 0 || assessment.Items[0] != (models.ItemTax{Category: "standard", Tax: sar(0)}) {
+		t.Errorf("expected an untaxed order, but got %+v", assessment)
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write %v, err: %v", path, err)
+		}
+		return path
+	}
+	taxes, err := tax.LoadRules(write("rules.json", `{"default_region": "SA", "restaurants": {"41": "AE"},
+		"regions": {"SA": {"inclusive": true, "rates": {"standard": 0.15}}, "AE": {"rates": {"standard": 0.05, "reduced": 0.01}}}, "items": {"12": "reduced"}}`))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	assessment, err := taxes.Calculate(context.Background(), models.Order{RestaurantId: 41, Currency: "AED", Items: []models.OrderedItem{{Price: models.Money{Amount: 1000, Currency: "AED"}, OrderedQuantity: 1}}})
+	if err != nil || assessment.Region != "AE" || assessment.Inclusive || assessment.Total.Amount != 50 {
+		t.Errorf("expected 0.50 AED of exclusive taxes in AE, but got %+v, err: %v", assessment, err)
+	}
+	assessment, err = taxes.Calculate(context.Background(), models.Order{RestaurantId: 41, Currency: "AED", Items: []models.OrderedItem{{OrderedItemId: 12, Price: models.Money{Amount: 1000, Currency: "AED"}, OrderedQuantity: 1}}})
+	if err != nil || assessment.Items[0].Category != "reduced" || assessment.Total.Amount != 10 {
+		t.Errorf("expected 0.10 AED of reduced taxes for item 12, but got %+v, err: %v", assessment, err)
+	}
+	for name, content := range map[string]string{
+		"invalid.json":            `{"regions": `,
+		"unknown-default.json":    `{"default_region": "SA", "regions": {}}`,
+		"unknown-restaurant.json": `{"restaurants": {"41": "AE"}, "regions": {}}`,
+		"negative-rate.json":      `{"regions": {"SA": {"rates": {"standard": -0.15}}}}`,
+		"empty-category.json":     `{"regions": {}, "items": {"12": ""}}`,
+	} {
+		if _, err := tax.LoadRules(write(name, content)); err == nil {
+			t.Errorf("expected %v to be rejected", name)
+		}
+	}
+}
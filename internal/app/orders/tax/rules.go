@@ -0,0 +1,54 @@
+package tax
+
+import (
+	"encoding/json"
+	"fmt"
+	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
+	"os"
+)
+
+// rulesFile
+// e.g. {"default_region": "SA", "restaurants": {"41": "AE"}, "regions": {"SA": {"inclusive": true, "rates": {"standard": 0.15, "reduced": 0.05}}},
+// "items": {"12": "reduced"}}
+type rulesFile struct {
+	DefaultRegion string               `json:"default_region"`
+	Restaurants   map[int64]string     `json:"restaurants"`
+	Regions       map[string]RateTable `json:"regions"`
+	Items         map[int64]string     `json:"items"`
+}
+
+// LoadRules
+// reads the rate tables of every region, the region of every restaurant and the tax category of every item from a json file
+func LoadRules(path string) (interfaces.TaxCalculator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tax rules, err: %w", err)
+	}
+	var f rulesFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse tax rules of %v, err: %w", path, err)
+	}
+	jurisdictions := make(map[string]Jurisdiction, len(f.Regions))
+	for region, table := range f.Regions {
+		for c, rate := range table.Rates {
+			if rate < 0 || rate >= 1 {
+				return nil, fmt.Errorf("invalid tax rate %v of category %v in region %v, expected a rate between 0 and 1", rate, c, region)
+			}
+		}
+		jurisdictions[region] = table
+	}
+	if _, ok := jurisdictions[f.DefaultRegion]; f.DefaultRegion != "" && !ok {
+		return nil, fmt.Errorf("default tax region %v has no rates", f.DefaultRegion)
+	}
+	for restaurantId, region := range f.Restaurants {
+		if _, ok := jurisdictions[region]; !ok {
+			return nil, fmt.Errorf("tax region %v of restaurant %v has no rates", region, restaurantId)
+		}
+	}
+	for itemId, c := range f.Items {
+		if c == "" {
+			return nil, fmt.Errorf("item %v has an empty tax category", itemId)
+		}
+	}
+	return NewEngine(f.DefaultRegion, f.Restaurants, jurisdictions, f.Items), nil
+}
@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
@@ -10,8 +11,11 @@ import (
 	pb "github.com/nawafswe/orders-service/proto"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"strconv"
 	"time"
 )
 
@@ -38,8 +42,37 @@ func (o *OrdersServer) Create(ctx context.Context, in *pb.Order) (*pb.Order, err
 	if err := validateOrderCreationRequest(in); err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, err.Error())
 	}
-	newOrder, err := o.UseCase.PlaceOrder(ctx, ToDomain(in))
+	idempotencyKey, err := getIdempotencyKey(ctx)
 	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+	newOrder, err := o.UseCase.PlaceOrder(ctx, ToDomain(in), idempotencyKey)
+	if err != nil {
+		var conflictErr models.IdempotencyConflictErr
+		if errors.As(err, &conflictErr) {
+			return nil, status.Errorf(codes.AlreadyExists, err.Error())
+		}
+		var mismatchErr models.PriceMismatchErr
+		if errors.As(err, &mismatchErr) {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		var currencyErr models.CurrencyMismatchErr
+		if errors.As(err, &currencyErr) {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		var taxCategoryErr models.UnknownTaxCategoryErr
+		if errors.As(err, &taxCategoryErr) {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		var tipErr models.InvalidTipErr
+		if errors.As(err, &tipErr) {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		var couponErr models.InvalidCouponErr
+		var rangeErr models.DeliveryOutOfRangeErr
+		if errors.As(err, &couponErr) || errors.As(err, &rangeErr) {
+			return nil, status.Errorf(codes.FailedPrecondition, err.Error())
+		}
 		return nil, status.Errorf(codes.Internal, "failed to place a new order, err: %v", err)
 	}
 	processInfo["createdOrderId"] = newOrder.ID
@@ -49,12 +82,254 @@ func (o *OrdersServer) Create(ctx context.Context, in *pb.Order) (*pb.Order, err
 }
 
 func (s *OrdersServer) ChangeOrderStatus(ctx context.Context, in *pb.OrderStatus) (*emptypb.Empty, error) {
-	_, err := s.UseCase.UpdateOrderStatus(ctx, in.OrderId, in.Status)
+	// the system actor is reserved to the changes the service makes itself, such as expiring orders
+	actor := models.RestaurantActor
+	if in.Actor != "" {
+		a, err := models.ParseActor(in.Actor)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		if a == models.SystemActor {
+			return nil, status.Errorf(codes.InvalidArgument, "the %v actor is reserved to the service", a)
+		}
+		actor = a
+	}
+	_, err := s.UseCase.UpdateOrderStatus(ctx, models.StatusChange{
+		OrderId:    in.OrderId,
+		Status:     in.Status,
+		ReasonCode: in.ReasonCode,
+		Reason:     in.Reason,
+		Actor:      actor,
+	})
 	if err != nil {
+		var invalidStatusErr models.InvalidStatusChangeErr
+		if errors.As(err, &invalidStatusErr) {
+			return nil, status.Errorf(codes.FailedPrecondition, "cannot change order status, err: %v", err)
+		}
+		var notFoundErr models.OrderNotFoundErr
+		if errors.As(err, &notFoundErr) {
+			return nil, status.Errorf(codes.NotFound, err.Error())
+		}
 		return nil, status.Errorf(codes.Internal, "error occurred while changing order status, err: %v", err)
 	}
 	return &emptypb.Empty{}, nil
 }
+
+func (s *OrdersServer) CancelOrder(ctx context.Context, in *pb.CancelOrderRequest) (*pb.CancelOrderResponse, error) {
+	if in.OrderId <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "order id should be valid, given %d", in.OrderId)
+	}
+	if in.CustomerId <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "customer id should be valid, given %d", in.CustomerId)
+	}
+	o, err := s.UseCase.CancelOrder(ctx, models.Cancellation{
+		OrderId:    in.OrderId,
+		CustomerId: in.CustomerId,
+		ReasonCode: in.ReasonCode,
+		Reason:     in.Reason,
+	})
+	if err != nil {
+		var deniedErr models.CancellationDeniedErr
+		var invalidStatusErr models.InvalidStatusChangeErr
+		if errors.As(err, &deniedErr) || errors.As(err, &invalidStatusErr) {
+			return nil, status.Errorf(codes.FailedPrecondition, "cannot cancel order, err: %v", err)
+		}
+		var notOwnerErr models.NotOrderOwnerErr
+		if errors.As(err, &notOwnerErr) {
+			return nil, status.Errorf(codes.PermissionDenied, err.Error())
+		}
+		var notFoundErr models.OrderNotFoundErr
+		if errors.As(err, &notFoundErr) {
+			return nil, status.Errorf(codes.NotFound, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "error occurred while cancelling order, err: %v", err)
+	}
+	return &pb.CancelOrderResponse{Order: FromDomain(o), CancellationFee: o.CancellationFee.Major(), CancellationFeeMoney: fromMoney(o.CancellationFee)}, nil
+}
+
+func (s *OrdersServer) GetOrder(ctx context.Context, in *pb.GetOrderRequest) (*pb.Order, error) {
+	if in.OrderId <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "order id should be valid, given %d", in.OrderId)
+	}
+	o, err := s.UseCase.GetOrder(ctx, in.OrderId)
+	if err != nil {
+		var notFoundErr models.OrderNotFoundErr
+		if errors.As(err, &notFoundErr) {
+			return nil, status.Errorf(codes.NotFound, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "error occurred while getting order, err: %v", err)
+	}
+	return FromDomain(o), nil
+}
+
+func (s *OrdersServer) GetOrderTimeline(ctx context.Context, in *pb.GetOrderTimelineRequest) (*pb.OrderTimeline, error) {
+	if in.OrderId <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "order id should be valid, given %d", in.OrderId)
+	}
+	changes, err := s.UseCase.GetOrderTimeline(ctx, in.OrderId)
+	if err != nil {
+		var notFoundErr models.OrderNotFoundErr
+		if errors.As(err, &notFoundErr) {
+			return nil, status.Errorf(codes.NotFound, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "error occurred while getting order timeline, err: %v", err)
+	}
+	timeline := &pb.OrderTimeline{OrderId: in.OrderId}
+	for _, c := range changes {
+		timeline.Transitions = append(timeline.Transitions, &pb.StatusTransition{
+			PreviousStatus: c.PreviousStatus,
+			Status:         c.Status,
+			ReasonCode:     c.ReasonCode,
+			Reason:         c.Reason,
+			Actor:          c.Actor.String(),
+			ChangedAt:      timestamppb.New(c.ChangedAt),
+		})
+	}
+	return timeline, nil
+}
+
+func (s *OrdersServer) ListOrders(ctx context.Context, in *pb.ListOrdersRequest) (*pb.ListOrdersResponse, error) {
+	filter, err := toOrderFilter(in)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+	page, err := s.UseCase.ListOrders(ctx, filter)
+	if err != nil {
+		var invalidStatusErr models.InvalidStatusChangeErr
+		if errors.As(err, &invalidStatusErr) {
+			return nil, status.Errorf(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "error occurred while listing orders, err: %v", err)
+	}
+	res := &pb.ListOrdersResponse{}
+	for _, o := range page.Orders {
+		res.Orders = append(res.Orders, FromDomain(o))
+	}
+	if page.NextCursor != 0 {
+		res.NextPageToken = encodePageToken(page.NextCursor)
+	}
+	return res, nil
+}
+
+func (s *OrdersServer) GetSalesReport(ctx context.Context, in *pb.SalesReportRequest) (*pb.SalesReport, error) {
+	if err := models.ValidateCurrency(in.Currency); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+	filter := models.SalesReportFilter{RestaurantId: in.RestaurantId, Currency: in.Currency}
+	if in.CreatedFrom != nil {
+		filter.CreatedFrom = in.CreatedFrom.AsTime()
+	}
+	if in.CreatedTo != nil {
+		filter.CreatedTo = in.CreatedTo.AsTime()
+	}
+	report, err := s.UseCase.GetSalesReport(ctx, filter)
+	if err != nil {
+		var rateErr models.ExchangeRateNotFoundErr
+		if errors.As(err, &rateErr) {
+			return nil, status.Errorf(codes.FailedPrecondition, err.Error())
+		}
+		return nil, status.Errorf(codes.Internal, "error occurred while reporting sales, err: %v", err)
+	}
+	res := &pb.SalesReport{Total: fromMoney(report.Total), Orders: report.Orders}
+	for _, t := range report.ByCurrency {
+		res.ByCurrency = append(res.ByCurrency, &pb.CurrencySales{Total: fromMoney(t.Total), Orders: t.Orders})
+	}
+	return res, nil
+}
+
+func (s *OrdersServer) WatchOrder(in *pb.WatchOrderRequest, stream pb.OrderService_WatchOrderServer) error {
+	if in.OrderId <= 0 {
+		return status.Errorf(codes.InvalidArgument, "order id should be valid, given %d", in.OrderId)
+	}
+	feed, err := s.UseCase.WatchOrder(stream.Context(), in.OrderId)
+	if err != nil {
+		var notFoundErr models.OrderNotFoundErr
+		if errors.As(err, &notFoundErr) {
+			return status.Errorf(codes.NotFound, err.Error())
+		}
+		return status.Errorf(codes.Internal, "error occurred while watching order, err: %v", err)
+	}
+	return streamOrderFeed(stream.Context(), feed, stream.Send)
+}
+
+func (s *OrdersServer) WatchRestaurantOrders(in *pb.WatchRestaurantOrdersRequest, stream pb.OrderService_WatchRestaurantOrdersServer) error {
+	if in.RestaurantId <= 0 {
+		return status.Errorf(codes.InvalidArgument, "restaurant id should be valid, given %d", in.RestaurantId)
+	}
+	feed, err := s.UseCase.WatchRestaurantOrders(stream.Context(), in.RestaurantId)
+	if err != nil {
+		return status.Errorf(codes.Internal, "error occurred while watching restaurant orders, err: %v", err)
+	}
+	return streamOrderFeed(stream.Context(), feed, stream.Send)
+}
+
+// streamOrderFeed
+// sends the feed until the client goes away, a closed updates channel means the client could not keep up with the changes
+func streamOrderFeed(ctx context.Context, feed interfaces.OrderFeed, send func(*pb.Order) error) error {
+	defer feed.Close()
+	for _, o := range feed.Current {
+		if err := send(FromDomain(o)); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case o, ok := <-feed.Updates:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "client is too slow to consume order updates, reconnect to resume watching")
+			}
+			if err := send(FromDomain(o)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toOrderFilter(in *pb.ListOrdersRequest) (models.OrderFilter, error) {
+	if in.PageSize < 0 {
+		return models.OrderFilter{}, fmt.Errorf("page size should not be negative, given %d", in.PageSize)
+	}
+	filter := models.OrderFilter{
+		CustomerId:   in.CustomerId,
+		RestaurantId: in.RestaurantId,
+		Status:       in.Status,
+		Limit:        int(in.PageSize),
+	}
+	if in.CreatedFrom != nil {
+		filter.CreatedFrom = in.CreatedFrom.AsTime()
+	}
+	if in.CreatedTo != nil {
+		filter.CreatedTo = in.CreatedTo.AsTime()
+	}
+	if in.PageToken != "" {
+		cursor, err := decodePageToken(in.PageToken)
+		if err != nil {
+			return models.OrderFilter{}, err
+		}
+		filter.AfterId = cursor
+	}
+	return filter, nil
+}
+
+// page tokens are opaque to clients, they wrap the id of the last order on the previous page
+func encodePageToken(cursor int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(cursor, 10)))
+}
+
+func decodePageToken(token string) (int64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, fmt.Errorf("invalid page token %v", token)
+	}
+	cursor, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil || cursor <= 0 {
+		return 0, fmt.Errorf("invalid page token %v", token)
+	}
+	return cursor, nil
+}
+
 func ToDomain(o *pb.Order) models.Order {
 	var items []models.OrderedItem
 	for _, i := range o.Items {
@@ -62,15 +337,21 @@ func ToDomain(o *pb.Order) models.Order {
 			OrderedItemId:   i.OrderedItemId,
 			OrderedQuantity: i.OrderedQuantity,
 			Name:            i.Name,
-			Price:           i.Price,
+			Price:           toMoney(i.PriceMoney),
+			PriceMajor:      deprecatedAmount(i.PriceMoney, i.Price),
 		})
 	}
 	return models.Order{
-		CustomerId:   o.CustomerId,
-		RestaurantId: o.RestaurantId,
-		Status:       o.Status,
-		GrandTotal:   o.GrandTotal,
-		Items:        items,
+		CustomerId:       o.CustomerId,
+		RestaurantId:     o.RestaurantId,
+		Status:           o.Status,
+		Currency:         o.Currency,
+		GrandTotal:       toMoney(o.GrandTotalMoney),
+		GrandTotalMajor:  deprecatedAmount(o.GrandTotalMoney, o.GrandTotal),
+		Items:            items,
+		CouponCode:       o.CouponCode,
+		DeliveryDistance: o.DeliveryDistanceMeters,
+		Tip:              toMoney(o.Tip),
 	}
 }
 
@@ -82,17 +363,119 @@ func FromDomain(o models.Order) *pb.Order {
 			OrderedItemId:   i.OrderedItemId,
 			OrderedQuantity: i.OrderedQuantity,
 			Name:            i.Name,
-			Price:           i.Price,
+			Price:           major(i.Price, i.PriceMajor),
+			PriceMoney:      fromMoney(i.Price),
+			TaxCategory:     i.TaxCategory,
+			TaxRate:         i.TaxRate,
+			Tax:             fromMoney(i.Tax),
 		})
 	}
-	return &pb.Order{
-		OrderId:      int64(o.ID),
-		CustomerId:   o.CustomerId,
-		RestaurantId: o.RestaurantId,
-		Status:       o.Status,
-		GrandTotal:   o.GrandTotal,
-		Items:        items,
+	lines := make([]*pb.TaxLine, 0, len(o.TaxLines))
+	for _, l := range o.TaxLines {
+		lines = append(lines, &pb.TaxLine{Category: l.Category, Rate: l.Rate, Taxable: fromMoney(l.Taxable), Tax: fromMoney(l.Tax)})
+	}
+	discounts := make([]*pb.Discount, 0, len(o.Discounts))
+	for _, d := range o.Discounts {
+		discounts = append(discounts, &pb.Discount{Code: d.Code, Kind: d.Kind, Amount: fromMoney(d.Amount)})
+	}
+	fees := make([]*pb.Fee, 0, len(o.Fees))
+	for _, f := range o.Fees {
+		fees = append(fees, &pb.Fee{Kind: f.Kind, Amount: fromMoney(f.Amount)})
+	}
+	order := &pb.Order{
+		OrderId:         int64(o.ID),
+		CustomerId:      o.CustomerId,
+		RestaurantId:    o.RestaurantId,
+		Status:          o.Status,
+		Currency:        o.Currency,
+		GrandTotal:      major(o.GrandTotal, o.GrandTotalMajor),
+		GrandTotalMoney: fromMoney(o.GrandTotal),
+		Items:           items,
+		Pricing: &pb.PriceBreakdown{
+			Subtotal:      o.Pricing.Subtotal.Major(),
+			Fees:          o.Pricing.Fees.Major(),
+			Taxes:         o.Pricing.Taxes.Major(),
+			SubtotalMoney: fromMoney(o.Pricing.Subtotal),
+			FeesMoney:     fromMoney(o.Pricing.Fees),
+			TaxesMoney:    fromMoney(o.Pricing.Taxes),
+			Discounts:     fromMoney(o.Pricing.Discounts),
+			Tip:           fromMoney(o.Pricing.Tip),
+		},
+		CouponCode:             o.CouponCode,
+		Discounts:              discounts,
+		DeliveryDistanceMeters: o.DeliveryDistance,
+		Tip:                    fromMoney(o.Tip),
+		Fees:                   fees,
+		Tax:                    &pb.TaxBreakdown{Region: o.TaxRegion, Inclusive: o.TaxInclusive, Lines: lines},
 	}
+	if !o.CreatedAt.IsZero() {
+		order.CreatedAt = timestamppb.New(o.CreatedAt)
+	}
+	return order
+}
+
+func toMoney(m *pb.Money) models.Money {
+	if m == nil {
+		return models.Money{}
+	}
+	return models.Money{Amount: m.AmountMinor, Currency: m.Currency}
+}
+
+// deprecatedAmount
+// the deprecated double of an amount when the client sent no Money field. It is in major units of a currency only known once the order is priced,
+// so the pricer converts it with the decimals of the currency of the restaurant
+func deprecatedAmount(m *pb.Money, deprecated float64) float64 {
+	if m != nil {
+		return 0
+	}
+	return deprecated
+}
+
+// major
+// the deprecated double of an amount, the one sent by an older client as long as the order is not priced
+func major(m models.Money, sent float64) float64 {
+	if m.Currency == "" && m.IsZero() {
+		return sent
+	}
+	return m.Major()
+}
+
+func fromMoney(m models.Money) *pb.Money {
+	return &pb.Money{AmountMinor: m.Amount, Currency: m.Currency}
+}
+
+// validateMoney
+// a Money field must carry a valid currency and, like prices, cannot be negative
+func validateMoney(field string, m *pb.Money, deprecated float64) error {
+	if m == nil {
+		if deprecated < 0 {
+			return fmt.Errorf("the %s should not be negative", field)
+		}
+		return nil
+	}
+	if m.AmountMinor < 0 {
+		return fmt.Errorf("the %s should not be negative", field)
+	}
+	if err := models.ValidateCurrency(m.Currency); err != nil {
+		return fmt.Errorf("the %s has an %w", field, err)
+	}
+	return nil
+}
+
+const maxIdempotencyKeyLength = 255
+
+// getIdempotencyKey
+// reads the optional idempotency-key metadata clients send to safely retry order creation
+func getIdempotencyKey(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md["idempotency-key"]) == 0 {
+		return "", nil
+	}
+	key := md["idempotency-key"][0]
+	if len(key) > maxIdempotencyKeyLength {
+		return "", fmt.Errorf("idempotency key should not exceed %d characters, given %d", maxIdempotencyKeyLength, len(key))
+	}
+	return key, nil
 }
 
 type InvalidCreateOrderRequest struct {
@@ -127,6 +510,23 @@ func validateOrderCreationRequest(o *pb.Order) error {
 		if i.OrderedQuantity <= 0 {
 			errs = append(errs, fmt.Errorf("the quantity for item with sku %s should be greater than zero", i.Name))
 		}
+		if err := validateMoney(fmt.Sprintf("price for item with sku %s", i.Name), i.PriceMoney, i.Price); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := validateMoney("grand total", o.GrandTotalMoney, o.GrandTotal); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateMoney("tip", o.Tip, 0); err != nil {
+		errs = append(errs, err)
+	}
+	if o.DeliveryDistanceMeters < 0 {
+		errs = append(errs, fmt.Errorf("the delivery distance should not be negative, given %d", o.DeliveryDistanceMeters))
+	}
+	if o.Currency != "" {
+		if err := models.ValidateCurrency(o.Currency); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
 	if o.CustomerId <= 0 {
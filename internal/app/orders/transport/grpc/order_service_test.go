@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
 	odGrpc "github.com/nawafswe/orders-service/internal/app/orders/transport/grpc"
 	"github.com/nawafswe/orders-service/internal/models"
 	ordersMocks "github.com/nawafswe/orders-service/mocks/github.com/nawafswe/orders-service/pkg/v1"
@@ -11,9 +12,14 @@ import (
 	pb "github.com/nawafswe/orders-service/proto"
 	"github.com/stretchr/testify/mock"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
 	"net"
 	"testing"
+	"time"
 )
 
 func TestPlaceOrderService(t *testing.T) {
@@ -90,7 +96,7 @@ func TestPlaceOrderService(t *testing.T) {
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
 			t.Logf("=== running %s ===", name)
-			orderUseCase.On("PlaceOrder", mock.Anything, odGrpc.ToDomain(test.Input)).Return(odGrpc.ToDomain(test.Input), nil)
+			orderUseCase.On("PlaceOrder", mock.Anything, odGrpc.ToDomain(test.Input), "").Return(odGrpc.ToDomain(test.Input), nil)
 			res, err := c.Create(ctx, test.Input)
 			// I need to handle this and return custom err
 			if err == nil && test.ExpectedErr != nil {
@@ -101,12 +107,123 @@ func TestPlaceOrderService(t *testing.T) {
 			}
 
 			orderUseCase.AssertNumberOfCalls(t, "PlaceOrder", 1)
-			orderUseCase.AssertCalled(t, "PlaceOrder", mock.Anything, odGrpc.ToDomain(test.Input))
+			orderUseCase.AssertCalled(t, "PlaceOrder", mock.Anything, odGrpc.ToDomain(test.Input), "")
 			orderUseCase.AssertExpectations(t)
 		})
 	}
 }
 
+func TestFailPlaceOrderServiceDueGrandTotalMismatch(t *testing.T) {
+	orderUseCase := ordersMocks.NewMockOrderUseCase(t)
+	port := 9013
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		t.Errorf("failed to connect to port %d", port)
+	}
+	srv := grpc.NewServer()
+	defer srv.Stop()
+	odGrpc.NewOrderService(srv, orderUseCase, logger.NewLogger())
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			t.Errorf("failed to start a grpc server on port %d", port)
+		}
+	}()
+	conn, err := grpc.Dial("localhost:9013", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Error("could not establish a connection to the grpc server")
+	}
+	defer conn.Close()
+
+	c := pb.NewOrderServiceClient(conn)
+	in := &pb.Order{
+		CustomerId:      1,
+		RestaurantId:    1,
+		GrandTotalMoney: &pb.Money{AmountMinor: 3000, Currency: "USD"},
+		Items:           []*pb.OrderedItem{{OrderedItemId: 1, PriceMoney: &pb.Money{AmountMinor: 2500, Currency: "USD"}, Name: "V60", OrderedQuantity: 1}},
+	}
+	usd := func(amount int64) models.Money {
+		return models.Money{Amount: amount, Currency: "USD"}
+	}
+	orderUseCase.On("PlaceOrder", mock.Anything, odGrpc.ToDomain(in), "").Return(models.Order{}, models.PriceMismatchErr{Given: usd(3000), Breakdown: models.PriceBreakdown{Subtotal: usd(2500), Fees: usd(0), Taxes: usd(0), Discounts: usd(0), Tip: usd(0)}})
+	_, err = c.Create(context.Background(), in)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a mismatching grand total, but got %v", err)
+	}
+	if st, _ := status.FromError(err); st.Message() != "grand total 30.00 USD does not match the computed total 25.00 USD (subtotal 25.00 USD, fees 0.00 USD, taxes 0.00 USD, tip 0.00 USD, discounts 0.00 USD)" {
+		t.Errorf("expected the breakdown in the error, but got %v", st.Message())
+	}
+
+	in.Items[0].PriceMoney = &pb.Money{AmountMinor: -2500, Currency: "USD"}
+	if _, err = c.Create(context.Background(), in); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a negative price, but got %v", err)
+	}
+	in.Items[0].PriceMoney = &pb.Money{AmountMinor: 2500, Currency: "usd"}
+	if _, err = c.Create(context.Background(), in); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for an invalid currency, but got %v", err)
+	}
+	in.Items[0].PriceMoney = &pb.Money{AmountMinor: 2500, Currency: "XYZ"}
+	if _, err = c.Create(context.Background(), in); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a currency that is not in ISO 4217, but got %v", err)
+	}
+	in.Items[0].PriceMoney = &pb.Money{AmountMinor: 2500, Currency: "USD"}
+	in.Tip = &pb.Money{AmountMinor: -100, Currency: "USD"}
+	if _, err = c.Create(context.Background(), in); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a negative tip, but got %v", err)
+	}
+	orderUseCase.AssertNumberOfCalls(t, "PlaceOrder", 1)
+}
+
+func TestToDomainReadsDeprecatedAmounts(t *testing.T) {
+	in := &pb.Order{
+		GrandTotal: 25.5,
+		Items: []*pb.OrderedItem{
+			{OrderedItemId: 1, Price: 0.29, Name: "Napkin", OrderedQuantity: 1},
+			{OrderedItemId: 2, Price: 12.5, PriceMoney: &pb.Money{AmountMinor: 1250, Currency: "JPY"}, Name: "Onigiri", OrderedQuantity: 1},
+		},
+	}
+	o := odGrpc.ToDomain(in)
+	if !o.GrandTotal.IsZero() || o.GrandTotalMajor != 25.5 {
+		t.Errorf("expected the grand total to be kept in major units until it is priced, but got %v and %v", o.GrandTotal, o.GrandTotalMajor)
+	}
+	if !o.Items[0].Price.IsZero() || o.Items[0].PriceMajor != 0.29 {
+		t.Errorf("expected the price to be kept in major units until it is priced, but got %v and %v", o.Items[0].Price, o.Items[0].PriceMajor)
+	}
+	if o.Items[1].Price != (models.Money{Amount: 1250, Currency: "JPY"}) || o.Items[1].PriceMajor != 0 {
+		t.Errorf("expected the Money price to win over the deprecated one, but got %v", o.Items[1].Price)
+	}
+	// an unpriced order, such as a replayed idempotent request, still returns the amounts it was sent with
+	out := odGrpc.FromDomain(o)
+	if out.GrandTotal != 25.5 || out.Items[0].Price != 0.29 {
+		t.Errorf("expected the deprecated amounts to be mapped back, but got %v and %v", out.GrandTotal, out.Items[0].Price)
+	}
+}
+
+func TestToDomainIgnoresTheTaxOfTheItems(t *testing.T) {
+	in := &pb.Order{Items: []*pb.OrderedItem{{OrderedItemId: 1, PriceMoney: &pb.Money{AmountMinor: 2500, Currency: "USD"}, Name: "V60", OrderedQuantity: 1, TaxCategory: "exempt", TaxRate: 0.5}}}
+	if i := odGrpc.ToDomain(in).Items[0]; i.TaxCategory != "" || i.TaxRate != 0 {
+		t.Errorf("expected the tax category and rate sent by the client to be ignored, but got %v and %v", i.TaxCategory, i.TaxRate)
+	}
+}
+
+func TestFeesAndTipRoundTrip(t *testing.T) {
+	usd := func(amount int64) models.Money {
+		return models.Money{Amount: amount, Currency: "USD"}
+	}
+	o := odGrpc.ToDomain(&pb.Order{DeliveryDistanceMeters: 2400, Tip: &pb.Money{AmountMinor: 300, Currency: "USD"}})
+	if o.DeliveryDistance != 2400 || o.Tip != usd(300) {
+		t.Errorf("expected a delivery of 2400 meters with a tip of 3.00 USD, but got %v meters and %v", o.DeliveryDistance, o.Tip)
+	}
+	o.Fees = []models.Fee{{Kind: "delivery", Amount: usd(500)}, {Kind: "small_basket", Amount: usd(200)}}
+	o.Pricing = models.PriceBreakdown{Fees: usd(700), Tip: usd(300)}
+	out := odGrpc.FromDomain(o)
+	if len(out.Fees) != 2 || out.Fees[1].Kind != "small_basket" || out.Fees[1].GetAmount().GetAmountMinor() != 200 {
+		t.Errorf("expected the delivery and small basket fees, but got %v", out.Fees)
+	}
+	if out.GetTip().GetAmountMinor() != 300 || out.GetPricing().GetTip().GetAmountMinor() != 300 || out.DeliveryDistanceMeters != 2400 {
+		t.Errorf("expected the tip and distance to be returned, but got %v", out)
+	}
+}
+
 func TestSuccessfullyChangeOrderStatusService(t *testing.T) {
 	orderUseCase := ordersMocks.NewMockOrderUseCase(t)
 	port := 9009
@@ -134,8 +251,8 @@ func TestSuccessfullyChangeOrderStatusService(t *testing.T) {
 	}(conn)
 
 	c := pb.NewOrderServiceClient(conn)
-	in := &pb.OrderStatus{OrderId: 1, Status: "Delivered"}
-	orderUseCase.On("UpdateOrderStatus", mock.Anything, in.OrderId, in.Status).Return(models.Order{}, nil)
+	in := &pb.OrderStatus{OrderId: 1, Status: "Delivered", ReasonCode: "COURIER", Reason: "picked up by the courier", Actor: "restaurant"}
+	orderUseCase.On("UpdateOrderStatus", mock.Anything, models.StatusChange{OrderId: 1, Status: "Delivered", ReasonCode: "COURIER", Reason: "picked up by the courier", Actor: models.RestaurantActor}).Return(models.Order{}, nil)
 	_, err = c.ChangeOrderStatus(context.Background(), in)
 	if err != nil {
 		t.Errorf("status update field with err: %v", err)
@@ -172,7 +289,7 @@ func TestFailChangeOrderStatusServiceDueInvalidOrderId(t *testing.T) {
 
 	c := pb.NewOrderServiceClient(conn)
 	in := &pb.OrderStatus{OrderId: -300, Status: "Delivered"}
-	orderUseCase.On("UpdateOrderStatus", mock.Anything, in.OrderId, in.Status).Return(models.Order{}, errors.New("order not found"))
+	orderUseCase.On("UpdateOrderStatus", mock.Anything, models.StatusChange{OrderId: in.OrderId, Status: in.Status, Actor: models.RestaurantActor}).Return(models.Order{}, errors.New("order not found"))
 	_, err = c.ChangeOrderStatus(context.Background(), in)
 	if err == nil {
 		t.Errorf("it should fail update order status due to invalid id is passed but it did not")
@@ -180,3 +297,415 @@ func TestFailChangeOrderStatusServiceDueInvalidOrderId(t *testing.T) {
 	orderUseCase.AssertNumberOfCalls(t, "UpdateOrderStatus", 1)
 
 }
+
+func TestFailChangeOrderStatusServiceDueInvalidActor(t *testing.T) {
+	orderUseCase := ordersMocks.NewMockOrderUseCase(t)
+	port := 9010
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		t.Errorf("failed to connect to port %d", port)
+	}
+	srv := grpc.NewServer()
+	defer srv.Stop()
+	odGrpc.NewOrderService(srv, orderUseCase, logger.NewLogger())
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			t.Errorf("failed to start a grpc server on port %d", port)
+		}
+	}()
+	conn, err := grpc.Dial("localhost:9010", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Error("could not establish a connection to the grpc server")
+	}
+	defer conn.Close()
+
+	c := pb.NewOrderServiceClient(conn)
+	_, err = c.ChangeOrderStatus(context.Background(), &pb.OrderStatus{OrderId: 1, Status: "Cancelled", Actor: "courier"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for an unknown actor, but got %v", err)
+	}
+	_, err = c.ChangeOrderStatus(context.Background(), &pb.OrderStatus{OrderId: 1, Status: "Cancelled", Actor: "system"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a caller claiming the system actor, but got %v", err)
+	}
+	orderUseCase.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+}
+
+func TestGetOrderTimelineService(t *testing.T) {
+	orderUseCase := ordersMocks.NewMockOrderUseCase(t)
+	port := 9011
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		t.Errorf("failed to connect to port %d", port)
+	}
+	srv := grpc.NewServer()
+	defer srv.Stop()
+	odGrpc.NewOrderService(srv, orderUseCase, logger.NewLogger())
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			t.Errorf("failed to start a grpc server on port %d", port)
+		}
+	}()
+	conn, err := grpc.Dial("localhost:9011", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Error("could not establish a connection to the grpc server")
+	}
+	defer conn.Close()
+	c := pb.NewOrderServiceClient(conn)
+
+	approvedAt := time.Date(2024, 1, 2, 10, 1, 0, 0, time.UTC)
+	orderUseCase.On("GetOrderTimeline", mock.Anything, int64(1)).Return([]models.StatusChange{
+		{OrderId: 1, Status: "New", Actor: models.CustomerActor, ChangedAt: approvedAt.Add(-time.Minute)},
+		{OrderId: 1, PreviousStatus: "New", Status: "Approved", ReasonCode: "ACCEPTED", Reason: "on its way", Actor: models.RestaurantActor, ChangedAt: approvedAt},
+	}, nil)
+	timeline, err := c.GetOrderTimeline(context.Background(), &pb.GetOrderTimelineRequest{OrderId: 1})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if timeline.OrderId != 1 || len(timeline.Transitions) != 2 {
+		t.Fatalf("expected 2 transitions of order 1, but got %v", timeline)
+	}
+	approved := timeline.Transitions[1]
+	if approved.PreviousStatus != "New" || approved.Status != "Approved" || approved.ReasonCode != "ACCEPTED" || approved.Reason != "on its way" ||
+		approved.Actor != "restaurant" || !approved.ChangedAt.AsTime().Equal(approvedAt) {
+		t.Errorf("unexpected transition %v", approved)
+	}
+
+	orderUseCase.On("GetOrderTimeline", mock.Anything, int64(2)).Return(nil, models.OrderNotFoundErr{Id: 2})
+	if _, err := c.GetOrderTimeline(context.Background(), &pb.GetOrderTimelineRequest{OrderId: 2}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for an unknown order, but got %v", err)
+	}
+	if _, err := c.GetOrderTimeline(context.Background(), &pb.GetOrderTimelineRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a missing order id, but got %v", err)
+	}
+}
+
+func TestCancelOrderService(t *testing.T) {
+	orderUseCase := ordersMocks.NewMockOrderUseCase(t)
+	port := 9012
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		t.Errorf("failed to connect to port %d", port)
+	}
+	srv := grpc.NewServer()
+	defer srv.Stop()
+	odGrpc.NewOrderService(srv, orderUseCase, logger.NewLogger())
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			t.Errorf("failed to start a grpc server on port %d", port)
+		}
+	}()
+	conn, err := grpc.Dial("localhost:9012", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Error("could not establish a connection to the grpc server")
+	}
+	defer conn.Close()
+	c := pb.NewOrderServiceClient(conn)
+
+	cancellation := func(orderId int64) models.Cancellation {
+		return models.Cancellation{OrderId: orderId, CustomerId: 7, ReasonCode: "CHANGED_MIND", Reason: "ordered by mistake"}
+	}
+	orderUseCase.On("CancelOrder", mock.Anything, cancellation(1)).Return(models.Order{Model: gorm.Model{ID: 1}, CustomerId: 7, Status: "Cancelled", CancellationFee: models.Money{Amount: 250, Currency: "USD"}}, nil)
+	orderUseCase.On("CancelOrder", mock.Anything, cancellation(2)).Return(models.Order{}, models.CancellationDeniedErr{Message: "order with id 2 is being prepared and can no longer be cancelled"})
+	orderUseCase.On("CancelOrder", mock.Anything, cancellation(3)).Return(models.Order{}, models.NotOrderOwnerErr{OrderId: 3, CustomerId: 7})
+	orderUseCase.On("CancelOrder", mock.Anything, cancellation(4)).Return(models.Order{}, models.OrderNotFoundErr{Id: 4})
+	orderUseCase.On("CancelOrder", mock.Anything, cancellation(5)).Return(models.Order{}, models.InvalidStatusChangeErr{Message: "order with id 5 is no longer in status 'New'"})
+
+	res, err := c.CancelOrder(context.Background(), &pb.CancelOrderRequest{OrderId: 1, CustomerId: 7, ReasonCode: "CHANGED_MIND", Reason: "ordered by mistake"})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if res.GetOrder().GetStatus() != "Cancelled" || res.GetCancellationFeeMoney().GetAmountMinor() != 250 || res.GetCancellationFee() != 2.5 {
+		t.Errorf("expected a cancelled order with a fee of 2.5, but got %v", res)
+	}
+	tests := map[string]struct {
+		Request      *pb.CancelOrderRequest
+		ExpectedCode codes.Code
+	}{
+		"FailWhenThePolicyDeniesIt": {Request: &pb.CancelOrderRequest{OrderId: 2, CustomerId: 7}, ExpectedCode: codes.FailedPrecondition},
+		"FailForAnotherCustomer":    {Request: &pb.CancelOrderRequest{OrderId: 3, CustomerId: 7}, ExpectedCode: codes.PermissionDenied},
+		"FailForUnknownOrder":       {Request: &pb.CancelOrderRequest{OrderId: 4, CustomerId: 7}, ExpectedCode: codes.NotFound},
+		"FailWhenTheStatusChanged":  {Request: &pb.CancelOrderRequest{OrderId: 5, CustomerId: 7}, ExpectedCode: codes.FailedPrecondition},
+		"FailForMissingOrderId":     {Request: &pb.CancelOrderRequest{CustomerId: 7}, ExpectedCode: codes.InvalidArgument},
+		"FailForMissingCustomerId":  {Request: &pb.CancelOrderRequest{OrderId: 1}, ExpectedCode: codes.InvalidArgument},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.Request.ReasonCode, test.Request.Reason = "CHANGED_MIND", "ordered by mistake"
+			if _, err := c.CancelOrder(context.Background(), test.Request); status.Code(err) != test.ExpectedCode {
+				t.Errorf("expected %v, but got %v", test.ExpectedCode, err)
+			}
+		})
+	}
+}
+
+func TestGetSalesReportService(t *testing.T) {
+	orderUseCase := ordersMocks.NewMockOrderUseCase(t)
+	port := 9014
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		t.Errorf("failed to connect to port %d", port)
+	}
+	srv := grpc.NewServer()
+	defer srv.Stop()
+	odGrpc.NewOrderService(srv, orderUseCase, logger.NewLogger())
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			t.Errorf("failed to start a grpc server on port %d", port)
+		}
+	}()
+	conn, err := grpc.Dial("localhost:9014", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Error("could not establish a connection to the grpc server")
+	}
+	defer conn.Close()
+	c := pb.NewOrderServiceClient(conn)
+
+	orderUseCase.On("GetSalesReport", mock.Anything, models.SalesReportFilter{RestaurantId: 3, Currency: "USD"}).Return(models.SalesReport{
+		ByCurrency: []models.CurrencyTotal{
+			{Total: models.Money{Amount: 10000, Currency: "USD"}, Orders: 4},
+			{Total: models.Money{Amount: 37500, Currency: "SAR"}, Orders: 3},
+		},
+		Total:  models.Money{Amount: 20000, Currency: "USD"},
+		Orders: 7,
+	}, nil)
+	orderUseCase.On("GetSalesReport", mock.Anything, models.SalesReportFilter{RestaurantId: 4, Currency: "USD"}).Return(models.SalesReport{}, fmt.Errorf("failed to convert the sales in SAR, err: %w", models.ExchangeRateNotFoundErr{From: "SAR", To: "USD"}))
+
+	res, err := c.GetSalesReport(context.Background(), &pb.SalesReportRequest{RestaurantId: 3, Currency: "USD"})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if res.GetTotal().GetAmountMinor() != 20000 || res.GetTotal().GetCurrency() != "USD" || res.GetOrders() != 7 || len(res.GetByCurrency()) != 2 ||
+		res.GetByCurrency()[1].GetTotal().GetCurrency() != "SAR" || res.GetByCurrency()[1].GetOrders() != 3 {
+		t.Errorf("unexpected sales report %v", res)
+	}
+	tests := map[string]struct {
+		Request      *pb.SalesReportRequest
+		ExpectedCode codes.Code
+	}{
+		"FailForACurrencyWithoutRate": {Request: &pb.SalesReportRequest{RestaurantId: 4, Currency: "USD"}, ExpectedCode: codes.FailedPrecondition},
+		"FailForAMissingCurrency":     {Request: &pb.SalesReportRequest{RestaurantId: 3}, ExpectedCode: codes.InvalidArgument},
+		"FailForAnInvalidCurrency":    {Request: &pb.SalesReportRequest{RestaurantId: 3, Currency: "dollar"}, ExpectedCode: codes.InvalidArgument},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := c.GetSalesReport(context.Background(), test.Request); status.Code(err) != test.ExpectedCode {
+				t.Errorf("expected %v, but got %v", test.ExpectedCode, err)
+			}
+		})
+	}
+}
+
+func TestGetOrderService(t *testing.T) {
+	orderUseCase := ordersMocks.NewMockOrderUseCase(t)
+	port := 9005
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		t.Errorf("failed to connect to port %d", port)
+	}
+	srv := grpc.NewServer()
+	defer srv.Stop()
+	odGrpc.NewOrderService(srv, orderUseCase, logger.NewLogger())
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			t.Errorf("failed to start a grpc server on port %d", port)
+		}
+	}()
+	conn, err := grpc.Dial("localhost:9005", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Error("could not establish a connection to the grpc server")
+	}
+	defer func(conn *grpc.ClientConn) {
+		if err := conn.Close(); err != nil {
+			t.Errorf("failed to kill client connection")
+		}
+	}(conn)
+	c := pb.NewOrderServiceClient(conn)
+
+	orderUseCase.On("GetOrder", mock.Anything, int64(1)).Return(models.Order{Model: gorm.Model{ID: 1}, CustomerId: 1, RestaurantId: 1, Status: "New", TaxRegion: "SA", TaxInclusive: true,
+		Items:    []models.OrderedItem{{Name: "Burger", OrderedQuantity: 1, Price: models.Money{Amount: 1150, Currency: "SAR"}, TaxCategory: "standard", TaxRate: 0.15, Tax: models.Money{Amount: 150, Currency: "SAR"}}},
+		TaxLines: []models.TaxLine{{Category: "standard", Rate: 0.15, Taxable: models.Money{Amount: 1000, Currency: "SAR"}, Tax: models.Money{Amount: 150, Currency: "SAR"}}},
+	}, nil)
+	orderUseCase.On("GetOrder", mock.Anything, int64(2)).Return(models.Order{}, models.OrderNotFoundErr{Id: 2})
+
+	res, err := c.GetOrder(context.Background(), &pb.GetOrderRequest{OrderId: 1})
+	if err != nil {
+		t.Errorf("get order failed with err: %v", err)
+	}
+	if res.GetOrderId() != 1 || res.GetStatus() != "New" {
+		t.Errorf("expected order 1 with status New, but got %v", res)
+	}
+	if res.GetTax().GetRegion() != "SA" || !res.GetTax().GetInclusive() || len(res.GetTax().GetLines()) != 1 || res.GetTax().GetLines()[0].GetTax().GetAmountMinor() != 150 ||
+		res.GetItems()[0].GetTaxCategory() != "standard" || res.GetItems()[0].GetTaxRate() != 0.15 || res.GetItems()[0].GetTax().GetAmountMinor() != 150 {
+		t.Errorf("expected the taxes of the order, but got %v", res)
+	}
+
+	_, err = c.GetOrder(context.Background(), &pb.GetOrderRequest{OrderId: 2})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected not found error, but got %v", err)
+	}
+
+	_, err = c.GetOrder(context.Background(), &pb.GetOrderRequest{OrderId: -1})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected invalid argument error, but got %v", err)
+	}
+	orderUseCase.AssertNumberOfCalls(t, "GetOrder", 2)
+}
+
+func TestListOrdersService(t *testing.T) {
+	orderUseCase := ordersMocks.NewMockOrderUseCase(t)
+	port := 9006
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		t.Errorf("failed to connect to port %d", port)
+	}
+	srv := grpc.NewServer()
+	defer srv.Stop()
+	odGrpc.NewOrderService(srv, orderUseCase, logger.NewLogger())
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			t.Errorf("failed to start a grpc server on port %d", port)
+		}
+	}()
+	conn, err := grpc.Dial("localhost:9006", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Error("could not establish a connection to the grpc server")
+	}
+	defer func(conn *grpc.ClientConn) {
+		if err := conn.Close(); err != nil {
+			t.Errorf("failed to kill client connection")
+		}
+	}(conn)
+	c := pb.NewOrderServiceClient(conn)
+
+	firstPage := models.OrderFilter{CustomerId: 1, Limit: 2}
+	orderUseCase.On("ListOrders", mock.Anything, firstPage).Return(models.OrderPage{
+		Orders:     []models.Order{{Model: gorm.Model{ID: 9}}, {Model: gorm.Model{ID: 7}}},
+		NextCursor: 7,
+	}, nil)
+	secondPage := models.OrderFilter{CustomerId: 1, Limit: 2, AfterId: 7}
+	orderUseCase.On("ListOrders", mock.Anything, secondPage).Return(models.OrderPage{
+		Orders: []models.Order{{Model: gorm.Model{ID: 4}}},
+	}, nil)
+
+	res, err := c.ListOrders(context.Background(), &pb.ListOrdersRequest{CustomerId: 1, PageSize: 2})
+	if err != nil {
+		t.Fatalf("listing orders failed with err: %v", err)
+	}
+	if len(res.Orders) != 2 || res.NextPageToken == "" {
+		t.Errorf("expected two orders and a next page token, but got %v", res)
+	}
+	res, err = c.ListOrders(context.Background(), &pb.ListOrdersRequest{CustomerId: 1, PageSize: 2, PageToken: res.NextPageToken})
+	if err != nil {
+		t.Fatalf("listing orders failed with err: %v", err)
+	}
+	if len(res.Orders) != 1 || res.NextPageToken != "" {
+		t.Errorf("expected the last order without a next page token, but got %v", res)
+	}
+
+	_, err = c.ListOrders(context.Background(), &pb.ListOrdersRequest{PageToken: "not-a-token"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected invalid argument error for a malformed page token, but got %v", err)
+	}
+	orderUseCase.AssertNumberOfCalls(t, "ListOrders", 2)
+}
+
+func TestWatchOrderService(t *testing.T) {
+	orderUseCase := ordersMocks.NewMockOrderUseCase(t)
+	port := 9007
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		t.Errorf("failed to connect to port %d", port)
+	}
+	srv := grpc.NewServer()
+	defer srv.Stop()
+	odGrpc.NewOrderService(srv, orderUseCase, logger.NewLogger())
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			t.Errorf("failed to start a grpc server on port %d", port)
+		}
+	}()
+	conn, err := grpc.Dial("localhost:9007", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Error("could not establish a connection to the grpc server")
+	}
+	defer func(conn *grpc.ClientConn) {
+		if err := conn.Close(); err != nil {
+			t.Errorf("failed to kill client connection")
+		}
+	}(conn)
+	c := pb.NewOrderServiceClient(conn)
+
+	updates := make(chan models.Order, 1)
+	closed := make(chan struct{})
+	orderUseCase.On("WatchOrder", mock.Anything, int64(1)).Return(interfaces.OrderFeed{
+		Current: []models.Order{{Model: gorm.Model{ID: 1}, Status: "New"}},
+		Updates: updates,
+		Close:   func() { close(closed) },
+	}, nil)
+
+	stream, err := c.WatchOrder(context.Background(), &pb.WatchOrderRequest{OrderId: 1})
+	if err != nil {
+		t.Fatalf("failed to watch order, err: %v", err)
+	}
+	if o, err := stream.Recv(); err != nil || o.Status != "New" {
+		t.Errorf("expected the current order state first, but got %v, err: %v", o, err)
+	}
+	updates <- models.Order{Model: gorm.Model{ID: 1}, Status: "Approved"}
+	if o, err := stream.Recv(); err != nil || o.Status != "Approved" {
+		t.Errorf("expected the order update, but got %v, err: %v", o, err)
+	}
+	// the hub closes the updates of a subscriber that fell behind
+	close(updates)
+	if _, err := stream.Recv(); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("expected resource exhausted error for a slow client, but got %v", err)
+	}
+	<-closed
+}
+
+func TestPlaceOrderWithIdempotencyKeyService(t *testing.T) {
+	orderUseCase := ordersMocks.NewMockOrderUseCase(t)
+	port := 9008
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		t.Errorf("failed to connect to port %d", port)
+	}
+	srv := grpc.NewServer()
+	defer srv.Stop()
+	odGrpc.NewOrderService(srv, orderUseCase, logger.NewLogger())
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			t.Errorf("failed to start a grpc server on port %d", port)
+		}
+	}()
+	conn, err := grpc.Dial("localhost:9008", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Error("could not establish a connection to the grpc server")
+	}
+	defer func(conn *grpc.ClientConn) {
+		if err := conn.Close(); err != nil {
+			t.Errorf("failed to kill client connection")
+		}
+	}(conn)
+	c := pb.NewOrderServiceClient(conn)
+
+	in := &pb.Order{
+		CustomerId:   1,
+		RestaurantId: 1,
+		GrandTotal:   10,
+		Items:        []*pb.OrderedItem{{OrderedItemId: 1, Price: 10, Name: "Pepsi", OrderedQuantity: 1}},
+	}
+	orderUseCase.On("PlaceOrder", mock.Anything, odGrpc.ToDomain(in), "key-1").Return(models.Order{Model: gorm.Model{ID: 7}}, nil)
+	orderUseCase.On("PlaceOrder", mock.Anything, odGrpc.ToDomain(in), "key-2").Return(models.Order{}, models.IdempotencyConflictErr{Key: "key-2"})
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "idempotency-key", "key-1")
+	res, err := c.Create(ctx, in)
+	if err != nil || res.OrderId != 7 {
+		t.Errorf("expected order 7 to be returned, but got %v, err: %v", res, err)
+	}
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "idempotency-key", "key-2")
+	if _, err := c.Create(ctx, in); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("expected already exists error for a reused key, but got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/nawafswe/orders-service/internal/models"
+	"google.golang.org/protobuf/proto"
+)
+
+// CancellationPolicy
+// what cancelling an order costs its customer depending on its status: a new order is cancelled for free,
+// an approved one for a fee or not at all, and once the restaurant started preparing it the order can no longer be cancelled
+type CancellationPolicy struct {
+	// AllowApproved lets customers cancel approved orders, they are charged ApprovedFeeRate of the grand total
+	AllowApproved   bool
+	ApprovedFeeRate float64
+}
+
+// DefaultCancellationPolicy
+// approved orders are cancelled for 10% of their grand total
+func DefaultCancellationPolicy() CancellationPolicy {
+	return CancellationPolicy{AllowApproved: true, ApprovedFeeRate: 0.1}
+}
+
+// Fee
+// the fee of cancelling the order in its current status, CancellationDeniedErr when the policy does not allow it
+func (p CancellationPolicy) Fee(o models.Order) (models.Money, error) {
+	free := models.Money{Currency: o.GrandTotal.Currency}
+	status, err := models.ParseOrderStatus(o.Status)
+	if err != nil {
+		return free, err
+	}
+	switch status {
+	case models.New:
+		return free, nil
+	case models.Approved:
+		if !p.AllowApproved {
+			return free, models.CancellationDeniedErr{Message: fmt.Sprintf("order with id %v was approved by the restaurant and can no longer be cancelled", o.ID)}
+		}
+		return o.GrandTotal.Scale(p.ApprovedFeeRate), nil
+	case models.Preparing:
+		return free, models.CancellationDeniedErr{Message: fmt.Sprintf("order with id %v is being prepared and can no longer be cancelled", o.ID)}
+	default:
+		return free, models.CancellationDeniedErr{Message: fmt.Sprintf("order with id %v is already %v", o.ID, o.Status)}
+	}
+}
+
+// CancelOrder
+// the policy is applied to the status the order is in when it is read, the order is only cancelled while it is still in it,
+// so a restaurant starting the preparation in between makes the cancellation fail with InvalidStatusChangeErr
+func (u OrderUseCaseImpl) CancelOrder(ctx context.Context, cancellation models.Cancellation) (models.Order, error) {
+	current, err := u.repo.GetById(ctx, cancellation.OrderId)
+	if err != nil {
+		return models.Order{}, err
+	}
+	if current.CustomerId != cancellation.CustomerId {
+		return models.Order{}, models.NotOrderOwnerErr{OrderId: cancellation.OrderId, CustomerId: cancellation.CustomerId}
+	}
+	fee, err := u.policy.Fee(current)
+	if err != nil {
+		return models.Order{}, err
+	}
+	change := models.StatusChange{
+		OrderId:        cancellation.OrderId,
+		PreviousStatus: current.Status,
+		Status:         models.Cancelled.String(),
+		ReasonCode:     cancellation.ReasonCode,
+		Reason:         cancellation.Reason,
+		Actor:          models.CustomerActor,
+	}
+	return u.changeStatus(ctx, change, func(ctx context.Context, o *models.Order, change models.StatusChange) ([]models.OutboxMessage, error) {
+		if err := u.repo.SetCancellationFee(ctx, change.OrderId, fee); err != nil {
+			return nil, err
+		}
+		o.CancellationFee = fee
+		cancelled, err := u.orderCancelledMessage(ctx, *o, change)
+		if err != nil {
+			return nil, err
+		}
+		return []models.OutboxMessage{cancelled}, nil
+	})
+}
+
+func (u OrderUseCaseImpl) orderCancelledMessage(ctx context.Context, order models.Order, change models.StatusChange) (models.OutboxMessage, error) {
+	data, err := proto.Marshal(orderCancelledEvent(order, change))
+	if err != nil {
+		return models.OutboxMessage{}, fmt.Errorf("failed to marshal message, err: %w", err)
+	}
+	return newOutboxMessage(ctx, orderCancelledSchema, order, data), nil
+}
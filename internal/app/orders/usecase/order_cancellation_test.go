@@ -0,0 +1,127 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"github.com/nawafswe/orders-service/internal/app/orders/exchange"
+	"github.com/nawafswe/orders-service/internal/app/orders/usecase"
+	"github.com/nawafswe/orders-service/internal/models"
+	ordersMock "github.com/nawafswe/orders-service/mocks/github.com/nawafswe/orders-service/pkg/v1"
+	"github.com/nawafswe/orders-service/pkg/logger"
+	pb "github.com/nawafswe/orders-service/proto"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func usd(amount int64) models.Money {
+	return models.Money{Amount: amount, Currency: "USD"}
+}
+
+func TestCancellationPolicyFee(t *testing.T) {
+	denyApproved := usecase.CancellationPolicy{AllowApproved: false}
+	tests := map[string]struct {
+		Policy      usecase.CancellationPolicy
+		Status      string
+		ExpectedFee models.Money
+		ExpectDeny  bool
+	}{
+		"NewOrderIsCancelledForFree":         {Policy: usecase.DefaultCancellationPolicy(), Status: "New", ExpectedFee: usd(0)},
+		"ApprovedOrderIsCharged":             {Policy: usecase.DefaultCancellationPolicy(), Status: "Approved", ExpectedFee: usd(235)},
+		"ApprovedOrderIsDenied":              {Policy: denyApproved, Status: "Approved", ExpectedFee: usd(0), ExpectDeny: true},
+		"OrderBeingPreparedIsDenied":         {Policy: usecase.DefaultCancellationPolicy(), Status: "Preparing", ExpectedFee: usd(0), ExpectDeny: true},
+		"DeliveredOrderIsDenied":             {Policy: usecase.DefaultCancellationPolicy(), Status: "Delivered", ExpectedFee: usd(0), ExpectDeny: true},
+		"CancelledOrderIsDenied":             {Policy: usecase.DefaultCancellationPolicy(), Status: "Cancelled", ExpectedFee: usd(0), ExpectDeny: true},
+		"NewOrderIsFreeEvenIfApprovedDenied": {Policy: denyApproved, Status: "New", ExpectedFee: usd(0)},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fee, err := test.Policy.Fee(models.Order{Model: gorm.Model{ID: 1}, Status: test.Status, GrandTotal: usd(2345)})
+			var deniedErr models.CancellationDeniedErr
+			if errors.As(err, &deniedErr) != test.ExpectDeny {
+				t.Fatalf("expected denial %v, but got %v", test.ExpectDeny, err)
+			}
+			if fee != test.ExpectedFee {
+				t.Errorf("expected fee %v, but got %v", test.ExpectedFee, fee)
+			}
+		})
+	}
+}
+
+func TestCancelOrderUseCase(t *testing.T) {
+	tests := map[string]struct {
+		CurrentStatus string
+		CustomerId    int64
+		ExpectedFee   models.Money
+		ExpectedErr   error
+	}{
+		"CancelNewOrderForFree": {
+			CurrentStatus: "New",
+			CustomerId:    7,
+			ExpectedFee:   usd(0),
+		},
+		"CancelApprovedOrderForAFee": {
+			CurrentStatus: "Approved",
+			CustomerId:    7,
+			ExpectedFee:   usd(200),
+		},
+		"FailForAnotherCustomer": {
+			CurrentStatus: "New",
+			CustomerId:    8,
+			ExpectedErr:   models.NotOrderOwnerErr{OrderId: 1, CustomerId: 8},
+		},
+		"FailOnceThePreparationStarted": {
+			CurrentStatus: "Preparing",
+			CustomerId:    7,
+			ExpectedErr:   models.CancellationDeniedErr{Message: "order with id 1 is being prepared and can no longer be cancelled"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ordersRepoMock := ordersMock.NewMockOrderRepo(t)
+			historyMock := ordersMock.NewMockStatusHistoryRepo(t)
+			outboxMock := ordersMock.NewMockOutboxRepo(t)
+			txMock := ordersMock.NewMockTransactor(t)
+			notifierMock := ordersMock.NewMockOrderNotifier(t)
+			ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, historyMock, outboxMock, ordersMock.NewMockIdempotencyRepo(t), ordersMock.NewMockProcessedMessageRepo(t), ordersMock.NewMockPromotionRepo(t), txMock, notifierMock, usdPricer(ordersMock.NewMockPromotionRepo(t)), exchange.NewStaticRates("USD", nil), usecase.DefaultCancellationPolicy(), logger.NewLogger())
+
+			ordersRepoMock.On("GetById", mock.Anything, int64(1)).Return(models.Order{Model: gorm.Model{ID: 1}, CustomerId: 7, RestaurantId: 3, Status: test.CurrentStatus, GrandTotal: usd(2000)}, nil)
+			if test.ExpectedErr == nil {
+				isCancellation := func(change models.StatusChange) bool {
+					return change.OrderId == 1 && change.PreviousStatus == test.CurrentStatus && change.Status == "Cancelled" &&
+						change.ReasonCode == "CHANGED_MIND" && change.Actor == models.CustomerActor && !change.ChangedAt.IsZero()
+				}
+				txMock.EXPECT().WithinTransaction(mock.Anything, mock.Anything).RunAndReturn(runInTransaction)
+				ordersRepoMock.On("UpdateOrderStatus", mock.Anything, mock.MatchedBy(isCancellation)).Return(models.Order{Model: gorm.Model{ID: 1}, CustomerId: 7, RestaurantId: 3, Status: "Cancelled", GrandTotal: usd(2000)}, nil)
+				ordersRepoMock.On("SetCancellationFee", mock.Anything, int64(1), test.ExpectedFee).Return(nil)
+				historyMock.On("Add", mock.Anything, mock.MatchedBy(isCancellation)).Return(nil)
+				outboxMock.On("Add", mock.Anything, mock.MatchedBy(func(messages []models.OutboxMessage) bool {
+					var event pb.OrderCancelledV1
+					return len(messages) == 2 && messages[0].Topic == "orderStatusChanged" && messages[1].Topic == "orderCancelled" &&
+						messages[1].Attributes["ce-type"] == usecase.OrderCancelledEventType && proto.Unmarshal(messages[1].Data, &event) == nil &&
+						event.OrderId == 1 && event.RestaurantId == 3 && event.CustomerId == 7 && event.PreviousStatus == test.CurrentStatus &&
+						event.ReasonCode == "CHANGED_MIND" && event.CancellationFeeMoney.GetAmountMinor() == test.ExpectedFee.Amount && event.CancellationFeeMoney.GetCurrency() == "USD" && event.CancelledAt != nil
+				})).Return(nil)
+				notifierMock.On("Notify", mock.MatchedBy(func(o models.Order) bool {
+					return o.Status == "Cancelled" && o.CancellationFee == test.ExpectedFee
+				})).Return()
+			}
+
+			o, err := ordersUseCase.CancelOrder(context.Background(), models.Cancellation{OrderId: 1, CustomerId: test.CustomerId, ReasonCode: "CHANGED_MIND", Reason: "ordered by mistake"})
+			if test.ExpectedErr != nil {
+				if !errors.Is(err, test.ExpectedErr) {
+					t.Fatalf("expected error %v, but got %v", test.ExpectedErr, err)
+				}
+				ordersRepoMock.AssertNotCalled(t, "UpdateOrderStatus", mock.Anything, mock.Anything)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if o.Status != "Cancelled" || o.CancellationFee != test.ExpectedFee {
+				t.Errorf("expected a cancelled order with a fee of %v, but got %+v", test.ExpectedFee, o)
+			}
+		})
+	}
+}
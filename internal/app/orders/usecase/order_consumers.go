@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
+	"github.com/nawafswe/orders-service/internal/models"
+	"github.com/nawafswe/orders-service/pkg/messaging"
+	pb "github.com/nawafswe/orders-service/proto"
+)
+
+const (
+	ApproveOrderSubscription = "approveOrder"
+	RejectOrderSubscription  = "rejectOrder"
+)
+
+// RegisterConsumers
+// registers the handlers of every subscription the orders service consumes
+func RegisterConsumers(c *messaging.Consumer, u interfaces.OrderUseCase) {
+	upcasters := CommandUpcasters()
+	c.Register(ApproveOrderSubscription, orderStatusHandler(upcasters, u.HandleOrderApproval))
+	c.Register(RejectOrderSubscription, orderStatusHandler(upcasters, u.HandleOrderRejection))
+}
+
+// orderStatusHandler
+// decodes an OrderStatus command sent as a cloudevent by the restaurant service, upcast to its latest version.
+// Producers that predate cloudevents send the bare protobuf, identified by their event-id attribute or the broker message id.
+// An illegal status transition is skipped, the order moved on and redelivering the command will never succeed
+func orderStatusHandler(upcasters *messaging.UpcasterRegistry, fn func(ctx context.Context, eventId string, change models.StatusChange) error) messaging.Handler {
+	apply := func(ctx context.Context, eventId string, cmd *pb.OrderStatusCommandV2) error {
+		err := fn(ctx, eventId, models.StatusChange{
+			OrderId:    cmd.OrderId,
+			Status:     cmd.Status,
+			ReasonCode: cmd.ReasonCode,
+			Reason:     cmd.Reason,
+			Actor:      models.RestaurantActor,
+		})
+		var invalidStatusErr models.InvalidStatusChangeErr
+		if errors.As(err, &invalidStatusErr) {
+			return messaging.Skip(err)
+		}
+		return err
+	}
+	cloudEvent := messaging.CloudEventHandler(func(ctx context.Context, event messaging.CloudEvent, cmd *pb.OrderStatusCommandV2) error {
+		return apply(ctx, event.ID, cmd)
+	})
+	legacy := messaging.ProtoHandler(func(ctx context.Context, msg *messaging.Message, cmd *pb.OrderStatusCommandV2) error {
+		return apply(ctx, messaging.EventId(msg), cmd)
+	})
+	return messaging.Upcast(upcasters, OrderStatusCommandSchema, func(ctx context.Context, msg *messaging.Message) error {
+		if messaging.IsCloudEvent(msg) {
+			return cloudEvent(ctx, msg)
+		}
+		return legacy(ctx, msg)
+	})
+}
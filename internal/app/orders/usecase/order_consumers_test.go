@@ -0,0 +1,195 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"github.com/nawafswe/orders-service/internal/app/orders/exchange"
+	"github.com/nawafswe/orders-service/internal/app/orders/usecase"
+	"github.com/nawafswe/orders-service/internal/models"
+	messagesMock "github.com/nawafswe/orders-service/mocks/github.com/nawafswe/orders-service/pkg/messaging"
+	ordersMock "github.com/nawafswe/orders-service/mocks/github.com/nawafswe/orders-service/pkg/v1"
+	"github.com/nawafswe/orders-service/pkg/logger"
+	"github.com/nawafswe/orders-service/pkg/messaging"
+	pb "github.com/nawafswe/orders-service/proto"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/protobuf/proto"
+	"gorm.io/gorm"
+	"testing"
+)
+
+// statusCommand
+// the binary mode attributes of an approval/rejection command of the given schema version
+func statusCommand(id string, version string) map[string]string {
+	return messaging.CloudEvent{
+		ID:              id,
+		Source:          "/restaurants-service",
+		Type:            "com.nawafswe.restaurants.order.status",
+		SpecVersion:     messaging.CloudEventsSpecVersion,
+		DataContentType: messaging.ProtobufContentType,
+		Subject:         "1",
+		Extensions:      map[string]string{messaging.SchemaVersionExtension: version},
+	}.Attributes(nil)
+}
+
+func TestOrderStatusConsumers(t *testing.T) {
+	tests := map[string]struct {
+		Subscription string
+		Attributes   map[string]string
+		Command      proto.Message
+		UseCaseErr   error
+		SkipsUseCase bool
+		// EventId the command is recorded by, defaults to evt-1
+		EventId string
+		// ExpectedChange defaults to an approval by the restaurant without a reason
+		ExpectedChange models.StatusChange
+		ExpectedErr    bool
+		ExpectedDrop   bool
+		ExpectedSkip   bool
+	}{
+		"approval is applied": {
+			Subscription: usecase.ApproveOrderSubscription,
+			Attributes:   statusCommand("evt-1", "1"),
+		},
+		"rejection is applied": {
+			Subscription: usecase.RejectOrderSubscription,
+			Attributes:   statusCommand("evt-1", "1"),
+		},
+		"illegal transition is skipped": {
+			Subscription: usecase.ApproveOrderSubscription,
+			Attributes:   statusCommand("evt-1", "1"),
+			UseCaseErr:   models.InvalidStatusChangeErr{Message: "cannot change order status from 'Delivered' to 'Approved'"},
+			ExpectedErr:  true,
+			ExpectedSkip: true,
+		},
+		"transient failure is redelivered": {
+			Subscription: usecase.RejectOrderSubscription,
+			Attributes:   statusCommand("evt-1", "1"),
+			UseCaseErr:   errors.New("connection reset"),
+			ExpectedErr:  true,
+		},
+		"latest command version is applied": {
+			Subscription:   usecase.ApproveOrderSubscription,
+			Attributes:     statusCommand("evt-1", "2"),
+			Command:        &pb.OrderStatusCommandV2{OrderId: 1, Status: "Approved", ReasonCode: "ACCEPTED", Reason: "accepted by the restaurant"},
+			ExpectedChange: models.StatusChange{OrderId: 1, Status: "Approved", ReasonCode: "ACCEPTED", Reason: "accepted by the restaurant", Actor: models.RestaurantActor},
+		},
+		"unknown command version is dropped": {
+			Subscription: usecase.ApproveOrderSubscription,
+			Attributes:   statusCommand("evt-1", "3"),
+			SkipsUseCase: true,
+			ExpectedErr:  true,
+			ExpectedDrop: true,
+		},
+		"legacy command without a cloudevents envelope is applied": {
+			Subscription: usecase.ApproveOrderSubscription,
+			Attributes:   map[string]string{"event-id": "evt-1"},
+		},
+		"legacy rejection is identified by the message id": {
+			Subscription: usecase.RejectOrderSubscription,
+			Attributes:   map[string]string{},
+			EventId:      "msg-1",
+		},
+		"command with an incomplete cloudevents envelope is dropped": {
+			Subscription: usecase.ApproveOrderSubscription,
+			Attributes:   map[string]string{"ce-id": "evt-1"},
+			SkipsUseCase: true,
+			ExpectedErr:  true,
+			ExpectedDrop: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			useCaseMock := ordersMock.NewMockOrderUseCase(t)
+			if test.ExpectedChange == (models.StatusChange{}) {
+				test.ExpectedChange = models.StatusChange{OrderId: 1, Status: "Approved", Actor: models.RestaurantActor}
+			}
+			if test.EventId == "" {
+				test.EventId = "evt-1"
+			}
+			switch {
+			case test.SkipsUseCase:
+			case test.Subscription == usecase.RejectOrderSubscription:
+				useCaseMock.EXPECT().HandleOrderRejection(mock.Anything, test.EventId, test.ExpectedChange).Return(test.UseCaseErr)
+			default:
+				useCaseMock.EXPECT().HandleOrderApproval(mock.Anything, test.EventId, test.ExpectedChange).Return(test.UseCaseErr)
+			}
+
+			c := messaging.NewConsumer(messagesMock.NewMockMessageService(t))
+			usecase.RegisterConsumers(c, useCaseMock)
+			if test.Command == nil {
+				// producers that predate versioned commands send the OrderStatus API message
+				test.Command = &pb.OrderStatus{OrderId: 1, Status: "Approved"}
+			}
+			data, _ := proto.Marshal(test.Command)
+			err := c.Dispatch(context.Background(), test.Subscription, &messaging.Message{ID: "msg-1", Data: data, Attributes: test.Attributes})
+			if (err != nil) != test.ExpectedErr {
+				t.Errorf("expected error %v, but got %v", test.ExpectedErr, err)
+			}
+			var dropErr messaging.DropErr
+			if errors.As(err, &dropErr) != test.ExpectedDrop {
+				t.Errorf("expected drop %v, but got %v", test.ExpectedDrop, err)
+			}
+			var skipErr messaging.SkipErr
+			if errors.As(err, &skipErr) != test.ExpectedSkip {
+				t.Errorf("expected skip %v, but got %v", test.ExpectedSkip, err)
+			}
+		})
+	}
+}
+
+func TestHandleOrderApprovalAppliesOnce(t *testing.T) {
+	ordersRepoMock := ordersMock.NewMockOrderRepo(t)
+	processedMock := ordersMock.NewMockProcessedMessageRepo(t)
+	txMock := ordersMock.NewMockTransactor(t)
+	txMock.EXPECT().WithinTransaction(mock.Anything, mock.Anything).RunAndReturn(runInTransaction)
+	processedMock.EXPECT().MarkProcessed(mock.Anything, usecase.ApproveOrderSubscription, "evt-1").Return(false, nil)
+	ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, ordersMock.NewMockStatusHistoryRepo(t), ordersMock.NewMockOutboxRepo(t), ordersMock.NewMockIdempotencyRepo(t), processedMock, ordersMock.NewMockPromotionRepo(t), txMock, ordersMock.NewMockOrderNotifier(t), usdPricer(ordersMock.NewMockPromotionRepo(t)), exchange.NewStaticRates("USD", nil), usecase.DefaultCancellationPolicy(), logger.NewLogger())
+
+	if err := ordersUseCase.HandleOrderApproval(context.Background(), "evt-1", models.StatusChange{OrderId: 1, Status: "Approved", Actor: models.RestaurantActor}); err != nil {
+		t.Errorf("expected a redelivered event to be acknowledged, but got %v", err)
+	}
+	ordersRepoMock.AssertNotCalled(t, "GetById", mock.Anything, mock.Anything)
+}
+
+func TestRedeliveredApprovalIsAppliedAndNotifiedOnce(t *testing.T) {
+	ordersRepoMock := ordersMock.NewMockOrderRepo(t)
+	historyMock := ordersMock.NewMockStatusHistoryRepo(t)
+	outboxMock := ordersMock.NewMockOutboxRepo(t)
+	processedMock := ordersMock.NewMockProcessedMessageRepo(t)
+	notifierMock := ordersMock.NewMockOrderNotifier(t)
+	txMock := ordersMock.NewMockTransactor(t)
+	// nested transactions join the outer one, the change is committed once the outermost returns
+	depth, committed := 0, false
+	txMock.EXPECT().WithinTransaction(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, fn func(ctx context.Context) error) error {
+		depth++
+		err := fn(ctx)
+		depth--
+		if depth == 0 {
+			committed = err == nil
+		}
+		return err
+	})
+	processedMock.EXPECT().MarkProcessed(mock.Anything, usecase.ApproveOrderSubscription, "evt-1").Return(true, nil).Once()
+	processedMock.EXPECT().MarkProcessed(mock.Anything, usecase.ApproveOrderSubscription, "evt-1").Return(false, nil).Once()
+	ordersRepoMock.EXPECT().GetById(mock.Anything, int64(1)).Return(models.Order{Model: gorm.Model{ID: 1}, Status: "New"}, nil).Once()
+	ordersRepoMock.EXPECT().UpdateOrderStatus(mock.Anything, mock.Anything).Return(models.Order{Model: gorm.Model{ID: 1}, Status: "Approved"}, nil).Once()
+	historyMock.EXPECT().Add(mock.Anything, mock.Anything).Return(nil).Once()
+	outboxMock.EXPECT().Add(mock.Anything, mock.Anything).Return(nil).Once()
+	notifierMock.EXPECT().Notify(mock.Anything).Run(func(o models.Order) {
+		if !committed {
+			t.Errorf("expected watchers to be notified once the change is committed")
+		}
+	}).Return().Once()
+	ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, historyMock, outboxMock, ordersMock.NewMockIdempotencyRepo(t), processedMock, ordersMock.NewMockPromotionRepo(t), txMock, notifierMock, usdPricer(ordersMock.NewMockPromotionRepo(t)), exchange.NewStaticRates("USD", nil), usecase.DefaultCancellationPolicy(), logger.NewLogger())
+
+	c := messaging.NewConsumer(messagesMock.NewMockMessageService(t))
+	usecase.RegisterConsumers(c, ordersUseCase)
+	data, _ := proto.Marshal(&pb.OrderStatus{OrderId: 1, Status: "Approved"})
+	// the broker redelivers the same event, e.g. after the ack of the first delivery was lost
+	for _, messageId := range []string{"msg-1", "msg-2"} {
+		msg := &messaging.Message{ID: messageId, Data: data, Attributes: statusCommand("evt-1", "1")}
+		if err := c.Dispatch(context.Background(), usecase.ApproveOrderSubscription, msg); err != nil {
+			t.Errorf("expected delivery %v to be acknowledged, but got %v", messageId, err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"fmt"
+	"github.com/nawafswe/orders-service/internal/models"
+	"github.com/nawafswe/orders-service/pkg/messaging"
+	pb "github.com/nawafswe/orders-service/proto"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CloudEvents source and types of the events published by the service
+const (
+	EventSource                 = "/orders-service"
+	OrderCreatedEventType       = "com.nawafswe.orders.order.created"
+	OrderStatusChangedEventType = "com.nawafswe.orders.order.status_changed"
+	OrderCancelledEventType     = "com.nawafswe.orders.order.cancelled"
+)
+
+// OrderStatusCommandSchema
+// the schema of the approval and rejection commands, whose versions are upcast by CommandUpcasters
+const OrderStatusCommandSchema = "orders.OrderStatusCommand"
+
+// eventSchema
+// where an event is published, its cloudevents type and the version of its data
+type eventSchema struct {
+	Topic   string
+	Type    string
+	Version int
+}
+
+var (
+	orderCreatedSchema       = eventSchema{Topic: "orderCreated", Type: OrderCreatedEventType, Version: 1}
+	orderStatusChangedSchema = eventSchema{Topic: "orderStatusChanged", Type: OrderStatusChangedEventType, Version: 1}
+	orderCancelledSchema     = eventSchema{Topic: "orderCancelled", Type: OrderCancelledEventType, Version: 1}
+)
+
+// orderCreatedEvent
+// the OrderCreatedV1 payload of the order
+func orderCreatedEvent(o models.Order) *pb.OrderCreatedV1 {
+	items := make([]*pb.OrderedItemV1, 0, len(o.Items))
+	for _, i := range o.Items {
+		items = append(items, &pb.OrderedItemV1{
+			ItemId:          int64(i.ID),
+			OrderedItemId:   i.OrderedItemId,
+			OrderedQuantity: i.OrderedQuantity,
+			Name:            i.Name,
+			Price:           i.Price.Major(),
+			PriceMoney:      toMoneyEvent(i.Price),
+			TaxCategory:     i.TaxCategory,
+			TaxRate:         i.TaxRate,
+			Tax:             toMoneyEvent(i.Tax),
+		})
+	}
+	lines := make([]*pb.TaxLineV1, 0, len(o.TaxLines))
+	for _, l := range o.TaxLines {
+		lines = append(lines, &pb.TaxLineV1{Category: l.Category, Rate: l.Rate, Taxable: toMoneyEvent(l.Taxable), Tax: toMoneyEvent(l.Tax)})
+	}
+	discounts := make([]*pb.DiscountV1, 0, len(o.Discounts))
+	for _, d := range o.Discounts {
+		discounts = append(discounts, &pb.DiscountV1{Code: d.Code, Kind: d.Kind, Amount: toMoneyEvent(d.Amount)})
+	}
+	fees := make([]*pb.FeeV1, 0, len(o.Fees))
+	for _, f := range o.Fees {
+		fees = append(fees, &pb.FeeV1{Kind: f.Kind, Amount: toMoneyEvent(f.Amount)})
+	}
+	event := &pb.OrderCreatedV1{
+		OrderId:                int64(o.ID),
+		CustomerId:             o.CustomerId,
+		RestaurantId:           o.RestaurantId,
+		Status:                 o.Status,
+		GrandTotal:             o.GrandTotal.Major(),
+		GrandTotalMoney:        toMoneyEvent(o.GrandTotal),
+		Currency:               o.Currency,
+		Items:                  items,
+		Tax:                    &pb.TaxBreakdownV1{Region: o.TaxRegion, Inclusive: o.TaxInclusive, Lines: lines, Total: toMoneyEvent(o.Pricing.Taxes)},
+		CouponCode:             o.CouponCode,
+		Discounts:              discounts,
+		Fees:                   fees,
+		Tip:                    toMoneyEvent(o.Tip),
+		DeliveryDistanceMeters: o.DeliveryDistance,
+	}
+	if !o.CreatedAt.IsZero() {
+		event.CreatedAt = timestamppb.New(o.CreatedAt)
+	}
+	return event
+}
+
+// orderStatusChangedEvent
+// the OrderStatusChangedV1 payload of the change of the order, the previous status is empty for a new order
+func orderStatusChangedEvent(o models.Order, change models.StatusChange) *pb.OrderStatusChangedV1 {
+	event := &pb.OrderStatusChangedV1{
+		OrderId:         change.OrderId,
+		Status:          change.Status,
+		PreviousStatus:  change.PreviousStatus,
+		ReasonCode:      change.ReasonCode,
+		Reason:          change.Reason,
+		Actor:           change.Actor.String(),
+		Currency:        o.Currency,
+		GrandTotalMoney: toMoneyEvent(o.GrandTotal),
+	}
+	if !change.ChangedAt.IsZero() {
+		event.ChangedAt = timestamppb.New(change.ChangedAt)
+	}
+	return event
+}
+
+// orderCancelledEvent
+// the OrderCancelledV1 payload of the order cancelled by the change
+func orderCancelledEvent(o models.Order, change models.StatusChange) *pb.OrderCancelledV1 {
+	event := &pb.OrderCancelledV1{
+		OrderId:              int64(o.ID),
+		RestaurantId:         o.RestaurantId,
+		CustomerId:           o.CustomerId,
+		PreviousStatus:       change.PreviousStatus,
+		ReasonCode:           change.ReasonCode,
+		Reason:               change.Reason,
+		CancellationFee:      o.CancellationFee.Major(),
+		CancellationFeeMoney: toMoneyEvent(o.CancellationFee),
+	}
+	if !change.ChangedAt.IsZero() {
+		event.CancelledAt = timestamppb.New(change.ChangedAt)
+	}
+	return event
+}
+
+func toMoneyEvent(m models.Money) *pb.Money {
+	return &pb.Money{AmountMinor: m.Amount, Currency: m.Currency}
+}
+
+// CommandUpcasters
+// the upcasters of the commands consumed by the service
+func CommandUpcasters() *messaging.UpcasterRegistry {
+	r := messaging.NewUpcasterRegistry()
+	r.Register(OrderStatusCommandSchema, 1, upcastOrderStatusCommandV1)
+	return r
+}
+
+// upcastOrderStatusCommandV1
+// V2 adds the reason of the status change, V1 commands have none
+func upcastOrderStatusCommandV1(data []byte) ([]byte, error) {
+	var v1 pb.OrderStatusCommandV1
+	if err := proto.Unmarshal(data, &v1); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %T, err: %w", &v1, err)
+	}
+	return proto.Marshal(&pb.OrderStatusCommandV2{OrderId: v1.OrderId, Status: v1.Status})
+}
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/nawafswe/orders-service/internal/models"
+)
+
+// findIdempotentOrder
+// returns the order previously created with the key, or models.IdempotencyConflictErr if the key was used for a different payload
+func (u OrderUseCaseImpl) findIdempotentOrder(ctx context.Context, key string, requestHash string) (models.Order, bool, error) {
+	k, found, err := u.idempotency.Find(ctx, key)
+	if err != nil || !found {
+		return models.Order{}, false, err
+	}
+	if k.RequestHash != requestHash {
+		return models.Order{}, true, models.IdempotencyConflictErr{Key: key}
+	}
+	o, err := u.repo.GetById(ctx, int64(k.OrderID))
+	if err != nil {
+		return models.Order{}, true, err
+	}
+	return o, true, nil
+}
+
+// orderRequest
+// what the client sent to place an order, the fields are listed explicitly so adding a field to the order or to its
+// api response does not change the fingerprint of a retry
+type orderRequest struct {
+	CustomerId       int64              `json:"customer_id"`
+	RestaurantId     int64              `json:"restaurant_id"`
+	Currency         string             `json:"currency"`
+	GrandTotal       requestAmount      `json:"grand_total"`
+	Items            []orderRequestItem `json:"items"`
+	CouponCode       string             `json:"coupon_code"`
+	Tip              requestAmount      `json:"tip"`
+	DeliveryDistance int64              `json:"delivery_distance"`
+}
+
+type orderRequestItem struct {
+	OrderedItemId   int64         `json:"ordered_item_id"`
+	Name            string        `json:"name"`
+	OrderedQuantity int32         `json:"ordered_quantity"`
+	Price           requestAmount `json:"price"`
+}
+
+// requestAmount
+// an amount as sent, Major is the deprecated double amount
+type requestAmount struct {
+	Amount   int64   `json:"amount"`
+	Currency string  `json:"currency"`
+	Major    float64 `json:"major"`
+}
+
+// fingerprint
+// a stable hash of the order request, used to tell a retry apart from a different order reusing the same key
+func fingerprint(order models.Order) (string, error) {
+	request := orderRequest{
+		CustomerId:       order.CustomerId,
+		RestaurantId:     order.RestaurantId,
+		Currency:         order.Currency,
+		GrandTotal:       requestAmount{Amount: order.GrandTotal.Amount, Currency: order.GrandTotal.Currency, Major: order.GrandTotalMajor},
+		Items:            make([]orderRequestItem, 0, len(order.Items)),
+		CouponCode:       order.CouponCode,
+		Tip:              requestAmount{Amount: order.Tip.Amount, Currency: order.Tip.Currency},
+		DeliveryDistance: order.DeliveryDistance,
+	}
+	for _, i := range order.Items {
+		request.Items = append(request.Items, orderRequestItem{
+			OrderedItemId:   i.OrderedItemId,
+			Name:            i.Name,
+			OrderedQuantity: i.OrderedQuantity,
+			Price:           requestAmount{Amount: i.Price.Amount, Currency: i.Price.Currency, Major: i.PriceMajor},
+		})
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to fingerprint order, err: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,41 @@
+package usecase
+
+import (
+	"fmt"
+	"github.com/nawafswe/orders-service/internal/models"
+)
+
+// orderLifecycle
+// the allowed transitions for each order status, a status that maps to nothing is terminal
+var orderLifecycle = map[models.OrderStatus][]models.OrderStatus{
+	models.New:       {models.Approved, models.Rejected, models.Cancelled},
+	models.Approved:  {models.Preparing, models.Cancelled},
+	models.Preparing: {models.Delivered, models.Cancelled},
+	models.Rejected:  {},
+	models.Cancelled: {},
+	models.Delivered: {},
+}
+
+// validateStatusTransition
+// checks that an order currently in status `from` is allowed to move to status `to`
+func validateStatusTransition(from string, to models.OrderStatus) error {
+	current, err := models.ParseOrderStatus(from)
+	if err != nil {
+		return models.InvalidStatusChangeErr{Message: fmt.Sprintf("order has unknown current status '%v'", from)}
+	}
+	if CanChangeStatus(current, to) {
+		return nil
+	}
+	return models.InvalidStatusChangeErr{Message: fmt.Sprintf("cannot change order status from '%v' to '%v'", current, to)}
+}
+
+// CanChangeStatus
+// whether the lifecycle lets an order in status `from` move to status `to`
+func CanChangeStatus(from models.OrderStatus, to models.OrderStatus) bool {
+	for _, s := range orderLifecycle[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
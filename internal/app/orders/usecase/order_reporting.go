@@ -0,0 +1,32 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"github.com/nawafswe/orders-service/internal/models"
+)
+
+// GetSalesReport
+// the sales of every currency are kept as placed and converted only to be summed into the total of the report,
+// a currency without a rate to the one of the report fails the whole report rather than being left out of it
+func (u OrderUseCaseImpl) GetSalesReport(ctx context.Context, filter models.SalesReportFilter) (models.SalesReport, error) {
+	if err := models.ValidateCurrency(filter.Currency); err != nil {
+		return models.SalesReport{}, err
+	}
+	totals, err := u.repo.SalesByCurrency(ctx, filter)
+	if err != nil {
+		return models.SalesReport{}, err
+	}
+	report := models.SalesReport{ByCurrency: totals, Total: models.Money{Currency: filter.Currency}}
+	for _, t := range totals {
+		rate, err := u.rates.Rate(ctx, t.Total.Currency, filter.Currency)
+		if err != nil {
+			return models.SalesReport{}, fmt.Errorf("failed to convert the sales in %v, err: %w", t.Total.Currency, err)
+		}
+		if report.Total, err = report.Total.Add(t.Total.Convert(filter.Currency, rate)); err != nil {
+			return models.SalesReport{}, err
+		}
+		report.Orders += t.Orders
+	}
+	return report, nil
+}
@@ -0,0 +1,87 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"github.com/nawafswe/orders-service/internal/app/orders/exchange"
+	"github.com/nawafswe/orders-service/internal/app/orders/usecase"
+	"github.com/nawafswe/orders-service/internal/models"
+	ordersMock "github.com/nawafswe/orders-service/mocks/github.com/nawafswe/orders-service/pkg/v1"
+	"github.com/nawafswe/orders-service/pkg/logger"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+func TestGetSalesReport(t *testing.T) {
+	sales := []models.CurrencyTotal{
+		{Total: usd(10000), Orders: 4},
+		{Total: models.Money{Amount: 37500, Currency: "SAR"}, Orders: 3},
+		{Total: models.Money{Amount: 1500, Currency: "JPY"}, Orders: 1},
+	}
+	tests := map[string]struct {
+		Currency      string
+		Sales         []models.CurrencyTotal
+		ExpectedTotal models.Money
+		ExpectedErr   error
+	}{
+		"ConvertEveryCurrencyToTheOneOfTheReport": {
+			Currency:      "USD",
+			Sales:         sales,
+			ExpectedTotal: usd(21000),
+		},
+		"ConvertToACurrencyWithoutDecimals": {
+			Currency:      "JPY",
+			Sales:         sales[:1],
+			ExpectedTotal: models.Money{Amount: 15000, Currency: "JPY"},
+		},
+		"ReportNoSalesAsZero": {
+			Currency:      "SAR",
+			ExpectedTotal: models.Money{Currency: "SAR"},
+		},
+		"FailForACurrencyWithoutRate": {
+			Currency:    "USD",
+			Sales:       append(sales, models.CurrencyTotal{Total: models.Money{Amount: 100, Currency: "BHD"}, Orders: 1}),
+			ExpectedErr: models.ExchangeRateNotFoundErr{From: "BHD", To: "USD"},
+		},
+	}
+	rates := exchange.NewStaticRates("USD", map[string]float64{"SAR": 3.75, "JPY": 150})
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ordersRepoMock := ordersMock.NewMockOrderRepo(t)
+			ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, ordersMock.NewMockStatusHistoryRepo(t), ordersMock.NewMockOutboxRepo(t), ordersMock.NewMockIdempotencyRepo(t), ordersMock.NewMockProcessedMessageRepo(t), ordersMock.NewMockPromotionRepo(t), ordersMock.NewMockTransactor(t), ordersMock.NewMockOrderNotifier(t), usdPricer(ordersMock.NewMockPromotionRepo(t)), rates, usecase.DefaultCancellationPolicy(), logger.NewLogger())
+			filter := models.SalesReportFilter{RestaurantId: 3, Currency: test.Currency}
+			ordersRepoMock.On("SalesByCurrency", mock.Anything, filter).Return(test.Sales, nil)
+
+			report, err := ordersUseCase.GetSalesReport(context.Background(), filter)
+			if test.ExpectedErr != nil {
+				if !errors.Is(err, test.ExpectedErr) {
+					t.Fatalf("expected error %v, but got %v", test.ExpectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if report.Total != test.ExpectedTotal {
+				t.Errorf("expected a total of %v, but got %v", test.ExpectedTotal, report.Total)
+			}
+			orders := int64(0)
+			for _, s := range test.Sales {
+				orders += s.Orders
+			}
+			if report.Orders != orders || len(report.ByCurrency) != len(test.Sales) {
+				t.Errorf("expected %d orders in %d currencies, but got %+v", orders, len(test.Sales), report)
+			}
+		})
+	}
+}
+
+func TestGetSalesReportFailsForInvalidCurrency(t *testing.T) {
+	ordersRepoMock := ordersMock.NewMockOrderRepo(t)
+	ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, ordersMock.NewMockStatusHistoryRepo(t), ordersMock.NewMockOutboxRepo(t), ordersMock.NewMockIdempotencyRepo(t), ordersMock.NewMockProcessedMessageRepo(t), ordersMock.NewMockPromotionRepo(t), ordersMock.NewMockTransactor(t), ordersMock.NewMockOrderNotifier(t), usdPricer(ordersMock.NewMockPromotionRepo(t)), exchange.NewStaticRates("USD", nil), usecase.DefaultCancellationPolicy(), logger.NewLogger())
+
+	if _, err := ordersUseCase.GetSalesReport(context.Background(), models.SalesReportFilter{Currency: "usd"}); err == nil {
+		t.Errorf("expected an error")
+	}
+	ordersRepoMock.AssertNotCalled(t, "SalesByCurrency", mock.Anything, mock.Anything)
+}
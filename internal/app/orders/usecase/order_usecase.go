@@ -1,192 +1,386 @@
 package usecase
 
 import (
-	"cloud.google.com/go/pubsub"
 	"context"
+	"errors"
 	"fmt"
+	"github.com/google/uuid"
 	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
-	ordersService "github.com/nawafswe/orders-service/internal/app/orders/transport/grpc"
 	"github.com/nawafswe/orders-service/internal/models"
 	"github.com/nawafswe/orders-service/pkg/contextWrapper"
 	"github.com/nawafswe/orders-service/pkg/logger"
 	"github.com/nawafswe/orders-service/pkg/messaging"
-	pb "github.com/nawafswe/orders-service/proto"
 	"google.golang.org/protobuf/proto"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
-	"log"
 	"os"
 	"strconv"
 	"time"
 )
 
 type OrderUseCaseImpl struct {
-	repo interfaces.OrderRepo
-	// define an interface for messaging once you segregate business logic for publishing events and handling events from there
-	pubSubClient messaging.MessageService
-	l            logger.Logger
+	repo        interfaces.OrderRepo
+	history     interfaces.StatusHistoryRepo
+	outbox      interfaces.OutboxRepo
+	idempotency interfaces.IdempotencyRepo
+	processed   interfaces.ProcessedMessageRepo
+	promotions  interfaces.PromotionRepo
+	tx          interfaces.Transactor
+	notifier    interfaces.OrderNotifier
+	pricer      interfaces.Pricer
+	rates       interfaces.ExchangeRateProvider
+	policy      CancellationPolicy
+	l           logger.Logger
 }
 
-func NewOrderUseCase(repo interfaces.OrderRepo, ps messaging.MessageService, l logger.Logger) interfaces.OrderUseCase {
-	return OrderUseCaseImpl{repo: repo, pubSubClient: ps, l: l}
+func NewOrderUseCase(repo interfaces.OrderRepo, history interfaces.StatusHistoryRepo, outbox interfaces.OutboxRepo, idempotency interfaces.IdempotencyRepo, processed interfaces.ProcessedMessageRepo, promotions interfaces.PromotionRepo, tx interfaces.Transactor, notifier interfaces.OrderNotifier, pricer interfaces.Pricer, rates interfaces.ExchangeRateProvider, policy CancellationPolicy, l logger.Logger) interfaces.OrderUseCase {
+	return OrderUseCaseImpl{repo: repo, history: history, outbox: outbox, idempotency: idempotency, processed: processed, promotions: promotions, tx: tx, notifier: notifier, pricer: pricer, rates: rates, policy: policy, l: l}
 }
 
-func (u OrderUseCaseImpl) PlaceOrder(ctx context.Context, order models.Order) (models.Order, error) {
+func (u OrderUseCaseImpl) PlaceOrder(ctx context.Context, order models.Order, idempotencyKey string) (models.Order, error) {
 	for _, i := range order.Items {
 		if i.OrderedQuantity <= 0 {
 			return models.Order{}, fmt.Errorf("supplied quantity for item with name %v, should be greater than zero, received is %v", i.Name, i.OrderedQuantity)
 
 		}
 	}
-	o, err := u.repo.Create(ctx, order)
+	// every order starts its lifecycle as new regardless of what the client sent
+	order.Status = models.New.String()
+	// the request is fingerprinted before it is priced, a retry gets the order as it was placed even if prices or promotions changed since
+	requestHash, err := fingerprint(order)
 	if err != nil {
 		return models.Order{}, err
 	}
+	if idempotencyKey != "" {
+		if o, found, err := u.findIdempotentOrder(ctx, idempotencyKey, requestHash); err != nil || found {
+			return o, err
+		}
+	}
+	// the total is computed from the items rather than trusted from the client
+	if order, err = u.pricer.Price(ctx, order); err != nil {
+		return models.Order{}, err
+	}
 	ctx = contextWrapper.CorrelationId(ctx)
-	u.PublishOrderCreatedEvent(ctx, o)
-	u.PublishOrderStatusChanged(ctx, order)
+	var o models.Order
+	err = u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if o, err = u.repo.Create(ctx, order); err != nil {
+			return err
+		}
+		for _, d := range o.Discounts {
+			if err := u.promotions.Redeem(ctx, models.Redemption{PromotionID: d.PromotionID, OrderID: o.ID, CustomerId: o.CustomerId, RedeemedAt: o.CreatedAt}); err != nil {
+				return err
+			}
+		}
+		if idempotencyKey != "" {
+			if err := u.idempotency.Save(ctx, models.IdempotencyKey{Key: idempotencyKey, OrderID: o.ID, RequestHash: requestHash}); err != nil {
+				return err
+			}
+		}
+		created, err := u.orderCreatedMessage(ctx, o)
+		if err != nil {
+			return err
+		}
+		placed := models.StatusChange{
+			OrderId:   int64(o.ID),
+			Status:    o.Status,
+			Actor:     models.CustomerActor,
+			ChangedAt: o.CreatedAt,
+		}
+		if err := u.history.Add(ctx, placed); err != nil {
+			return err
+		}
+		statusChanged, err := u.orderStatusChangedMessage(ctx, o, placed)
+		if err != nil {
+			return err
+		}
+		return u.outbox.Add(ctx, []models.OutboxMessage{created, statusChanged})
+	})
+	var inUseErr models.IdempotencyKeyInUseErr
+	if errors.As(err, &inUseErr) {
+		// a concurrent retry with the same key won the race, answer with the order it created
+		o, found, err := u.findIdempotentOrder(ctx, idempotencyKey, requestHash)
+		if err == nil && !found {
+			err = inUseErr
+		}
+		return o, err
+	}
+	if err != nil {
+		return models.Order{}, err
+	}
+	u.notifier.Notify(o)
 	return o, nil
 }
 
-func (u OrderUseCaseImpl) UpdateOrderStatus(ctx context.Context, orderId int64, status string) (models.Order, error) {
-	if status == "" {
-		return models.Order{}, models.InvalidStatusChangeErr{Message: fmt.Sprintf("given status '%v' is invalid", status)}
+// UpdateOrderStatus
+// customers cancel their orders through CancelOrder, so the cancellation policy and its fee always apply
+func (u OrderUseCaseImpl) UpdateOrderStatus(ctx context.Context, change models.StatusChange) (models.Order, error) {
+	if change.Actor == models.CustomerActor && change.Status == models.Cancelled.String() {
+		return models.Order{}, models.InvalidStatusChangeErr{Message: fmt.Sprintf("order with id %v can only be cancelled by its customer through CancelOrder", change.OrderId)}
 	}
-	o, err := u.repo.UpdateOrderStatus(ctx, orderId, status)
+	return u.changeStatus(ctx, change, nil)
+}
+
+// changeStatus
+// applies the change and notifies the watchers of the order once it is committed
+func (u OrderUseCaseImpl) changeStatus(ctx context.Context, change models.StatusChange, within func(ctx context.Context, o *models.Order, change models.StatusChange) ([]models.OutboxMessage, error)) (models.Order, error) {
+	o, err := u.applyStatusChange(ctx, change, within)
 	if err != nil {
 		return models.Order{}, err
 	}
-	u.PublishOrderStatusChanged(ctx, o)
+	u.notifier.Notify(o)
+	return o, nil
+}
 
+// applyStatusChange
+// validates and applies the change, records it and publishes it. within, when set, runs in the same transaction
+// once the order is updated, and returns the events to publish alongside the status change.
+// Watchers are not notified, the change may be part of a transaction of the caller that is not committed yet
+func (u OrderUseCaseImpl) applyStatusChange(ctx context.Context, change models.StatusChange, within func(ctx context.Context, o *models.Order, change models.StatusChange) ([]models.OutboxMessage, error)) (models.Order, error) {
+	next, err := models.ParseOrderStatus(change.Status)
+	if err != nil {
+		return models.Order{}, err
+	}
+	current, err := u.repo.GetById(ctx, change.OrderId)
+	if err != nil {
+		return models.Order{}, err
+	}
+	if change.PreviousStatus != "" && change.PreviousStatus != current.Status {
+		return models.Order{}, models.InvalidStatusChangeErr{Message: fmt.Sprintf("order with id %v is no longer in status '%v'", change.OrderId, change.PreviousStatus)}
+	}
+	if err := validateStatusTransition(current.Status, next); err != nil {
+		return models.Order{}, err
+	}
+	change.PreviousStatus = current.Status
+	change.ChangedAt = time.Now()
+	var o models.Order
+	err = u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if o, err = u.repo.UpdateOrderStatus(ctx, change); err != nil {
+			return err
+		}
+		if err := u.history.Add(ctx, change); err != nil {
+			return err
+		}
+		// a rejected or cancelled order gives its coupon back to the customer
+		if len(o.Discounts) > 0 && (next == models.Rejected || next == models.Cancelled) {
+			if err := u.promotions.RevertRedemptions(ctx, change.OrderId, change.ChangedAt); err != nil {
+				return err
+			}
+		}
+		statusChanged, err := u.orderStatusChangedMessage(ctx, o, change)
+		if err != nil {
+			return err
+		}
+		messages := []models.OutboxMessage{statusChanged}
+		if within != nil {
+			extra, err := within(ctx, &o, change)
+			if err != nil {
+				return err
+			}
+			messages = append(messages, extra...)
+		}
+		return u.outbox.Add(ctx, messages)
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
 	return o, nil
 }
 
-// Maybe Moving this logic into saga?, probably I need to do research about it
+func (u OrderUseCaseImpl) GetOrder(ctx context.Context, orderId int64) (models.Order, error) {
+	return u.repo.GetById(ctx, orderId)
+}
+
+// GetOrderTimeline
+// fails with OrderNotFoundErr for an unknown order rather than returning an empty timeline
+func (u OrderUseCaseImpl) GetOrderTimeline(ctx context.Context, orderId int64) ([]models.StatusChange, error) {
+	if _, err := u.repo.GetById(ctx, orderId); err != nil {
+		return nil, err
+	}
+	return u.history.List(ctx, orderId)
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
 
-func (u OrderUseCaseImpl) PublishOrderCreatedEvent(ctx context.Context, order models.Order) {
-	data, err := proto.Marshal(ordersService.FromDomain(order))
+func (u OrderUseCaseImpl) ListOrders(ctx context.Context, filter models.OrderFilter) (models.OrderPage, error) {
+	if filter.Status != "" {
+		if _, err := models.ParseOrderStatus(filter.Status); err != nil {
+			return models.OrderPage{}, err
+		}
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	} else if filter.Limit > maxPageSize {
+		filter.Limit = maxPageSize
+	}
+	pageSize := filter.Limit
+	// fetch one extra row to know whether another page exists
+	filter.Limit++
+	orders, err := u.repo.List(ctx, filter)
 	if err != nil {
-		log.Printf("error occured while marshling order data, err: %v\n", err)
+		return models.OrderPage{}, err
 	}
-	msgId, ok := ctx.Value("correlation-id").(string)
-	if !ok {
-		ctx = contextWrapper.CorrelationId(ctx)
-		msgId = ctx.Value("correlation-id").(string)
+	page := models.OrderPage{Orders: orders}
+	if len(orders) > pageSize {
+		page.Orders = orders[:pageSize]
+		page.NextCursor = int64(page.Orders[pageSize-1].ID)
 	}
-	span, _ := tracer.SpanFromContext(ctx)
-	u.l.Info(map[string]any{
-		"process": fmt.Sprintf("Publish order created event with spanId %v", span.Context().SpanID()),
-	}, "Publishing message to google pub sub")
-	u.pubSubClient.PublishAsync(ctx, "orderCreated", &pubsub.Message{Data: data, Attributes: map[string]string{"correlation-id": msgId, "service": "orders", "host": "localhost", "spanId": strconv.FormatUint(span.Context().SpanID(), 10)}})
-
+	return page, nil
 }
-func (u OrderUseCaseImpl) HandleOrderApproval(ctx context.Context) {
-	subId := "approveOrder"
-	processName := "HandleOrderApproval"
-	approveOrder, err := u.pubSubClient.GetSubscription(ctx, subId)
 
+// WatchOrder
+// subscribes before reading the order, so no transition happening in between is missed
+func (u OrderUseCaseImpl) WatchOrder(ctx context.Context, orderId int64) (interfaces.OrderFeed, error) {
+	updates, unsubscribe := u.notifier.Subscribe(func(o models.Order) bool {
+		return int64(o.ID) == orderId
+	})
+	o, err := u.repo.GetById(ctx, orderId)
 	if err != nil {
-		log.Printf("cannot handle order approval at the moment, err: %v\n", err)
-		return
+		unsubscribe()
+		return interfaces.OrderFeed{}, err
 	}
-	u.l.Info(map[string]any{
-		"process": processName,
-		"subId":   subId,
-		"time":    time.Now(),
-	}, "starting to handling order approvals requests")
-
-	err = approveOrder.Receive(ctx, func(_ context.Context, msg *pubsub.Message) {
-		msgId := msg.Attributes["correlation-id"]
-		start := time.Now()
-		span, _ := tracer.StartSpanFromContext(ctx, processName)
-		defer span.Finish()
-
-		var orderStatus pb.OrderStatus
-		if err := proto.Unmarshal(msg.Data, &orderStatus); err != nil {
-			log.Printf("failed to unmarshal message of order status, err: %v\n", err)
-			// configure nack
-			msg.Nack()
-			return
-		}
-		if correlationId, ok := msg.Attributes["correlation-id"]; ok {
-			ctx = contextWrapper.WithCorrelationId(ctx, correlationId)
+	return interfaces.OrderFeed{Current: []models.Order{o}, Updates: updates, Close: unsubscribe}, nil
+}
+
+// WatchRestaurantOrders
+// the current state of a restaurant is all of its orders that are still in progress, read page by page
+func (u OrderUseCaseImpl) WatchRestaurantOrders(ctx context.Context, restaurantId int64) (interfaces.OrderFeed, error) {
+	updates, unsubscribe := u.notifier.Subscribe(func(o models.Order) bool {
+		return o.RestaurantId == restaurantId
+	})
+	var current []models.Order
+	for s := models.New; s <= models.Delivered; s++ {
+		if len(orderLifecycle[s]) == 0 {
+			continue
 		}
-		u.l.Info(map[string]any{
-			"process":        processName,
-			"context":        fmt.Sprintf("Handling HandleOrderApproval for orderId: %v, corrleation-id: %v", orderStatus.OrderId, msgId),
-			"correlation-id": msgId,
-			"service":        os.Getenv("SERVICE_NAME"),
-			"time":           time.Since(start),
-		}, "Processing incoming order")
-		// update order status
-		processedOrder, err := u.UpdateOrderStatus(ctx, orderStatus.OrderId, orderStatus.Status)
-		if err != nil {
-			log.Printf("could not handle order approval for order: %v, error: %v\n", orderStatus.OrderId, err)
-			msg.Nack()
-			return
+		filter := models.OrderFilter{RestaurantId: restaurantId, Status: s.String(), Limit: maxPageSize}
+		for {
+			orders, err := u.repo.List(ctx, filter)
+			if err != nil {
+				unsubscribe()
+				return interfaces.OrderFeed{}, err
+			}
+			current = append(current, orders...)
+			if len(orders) < maxPageSize {
+				break
+			}
+			filter.AfterId = int64(orders[len(orders)-1].ID)
 		}
-		u.l.Info(map[string]any{
-			"process":        processName,
-			"context":        fmt.Sprintf("restaurantId: %v approved the incoming order %v succesfully", processedOrder.RestaurantId, processedOrder.ID),
-			"correlation-id": msgId,
-			"spanId":         strconv.FormatUint(span.Context().SpanID(), 10),
-			"service":        os.Getenv("SERVICE_NAME"),
-			"time":           time.Since(start),
-		}, "Order processed")
-		msg.Ack()
-	})
-	if err != nil {
-		log.Printf("Cannot receive messages for order approval at the moment, err: %v\n", err)
 	}
+	return interfaces.OrderFeed{Current: current, Updates: updates, Close: unsubscribe}, nil
 }
 
-func (u OrderUseCaseImpl) HandleOrderRejection(ctx context.Context) {
-	subId := "rejectOrder"
-	s, err := u.pubSubClient.GetSubscription(ctx, subId)
+// Maybe Moving this logic into saga?, probably I need to do research about it
+
+// orderCreatedMessage
+// the orderCreated event for the outbox, the relay publishes it once the order is committed
+func (u OrderUseCaseImpl) orderCreatedMessage(ctx context.Context, order models.Order) (models.OutboxMessage, error) {
+	data, err := proto.Marshal(orderCreatedEvent(order))
 	if err != nil {
-		log.Printf("failed to get subscription resource, err: %v", err)
-		return
-	}
-	log.Println("===== starting to handle order rejection events =====")
-	err = s.Receive(ctx, func(_ context.Context, msg *pubsub.Message) {
-		var order pb.OrderStatus
-		if err := proto.Unmarshal(msg.Data, &order); err != nil {
-			log.Printf("failed to unmarshal order status for msgId: %v, err: %v \n", msg.ID, err)
-			msg.Nack()
-			return
-		}
-		if correlationId, ok := msg.Attributes["correlation-id"]; ok {
-			ctx = contextWrapper.WithCorrelationId(ctx, correlationId)
-		}
-		if _, err := u.UpdateOrderStatus(ctx, order.OrderId, order.Status); err != nil {
-			log.Printf("failed to update order status, err: %v\n", err)
-			msg.Nack()
-			return
-		}
-		msg.Ack()
-	})
+		return models.OutboxMessage{}, fmt.Errorf("error occured while marshling order data, err: %w", err)
+	}
+	span, _ := tracer.SpanFromContext(ctx)
+	u.l.Info(map[string]any{
+		"process": fmt.Sprintf("Publish order created event with spanId %v", span.Context().SpanID()),
+	}, "Adding order created event to the outbox")
+	return newOutboxMessage(ctx, orderCreatedSchema, order, data), nil
+}
 
+// HandleOrderApproval
+// applies an order status change requested on the approveOrder subscription, a redelivered event is applied only once
+func (u OrderUseCaseImpl) HandleOrderApproval(ctx context.Context, eventId string, change models.StatusChange) error {
+	return u.handleOrderStatusCommand(ctx, ApproveOrderSubscription, eventId, change)
+}
+
+// HandleOrderRejection
+// applies an order status change requested on the rejectOrder subscription, a redelivered event is applied only once
+func (u OrderUseCaseImpl) HandleOrderRejection(ctx context.Context, eventId string, change models.StatusChange) error {
+	return u.handleOrderStatusCommand(ctx, RejectOrderSubscription, eventId, change)
+}
+
+func (u OrderUseCaseImpl) handleOrderStatusCommand(ctx context.Context, subscription string, eventId string, change models.StatusChange) error {
+	var processedOrder models.Order
+	applied, err := u.applyOnce(ctx, subscription, eventId, func(ctx context.Context) error {
+		var err error
+		processedOrder, err = u.applyStatusChange(ctx, change, nil)
+		return err
+	})
 	if err != nil {
-		log.Printf("failed to receive messages for sub: %v\n", subId)
+		return fmt.Errorf("failed to change status of order %v to %v, err: %w", change.OrderId, change.Status, err)
+	}
+	if !applied {
+		u.l.Info(map[string]any{
+			"process": subscription,
+			"context": fmt.Sprintf("event %v for order %v was already processed", eventId, change.OrderId),
+		}, "Skipping duplicate event")
+		return nil
 	}
+	// the change is committed along with the processed event only now
+	u.notifier.Notify(processedOrder)
+	u.l.Info(map[string]any{
+		"process": subscription,
+		"context": fmt.Sprintf("restaurantId: %v changed the status of order %v to %v", processedOrder.RestaurantId, processedOrder.ID, processedOrder.Status),
+		"service": os.Getenv("SERVICE_NAME"),
+	}, "Order processed")
+	return nil
+}
 
+// applyOnce
+// runs fn in the same transaction that records the event as processed by the subscription.
+// Returns false without running fn when the event was already applied, e.g. on a redelivery
+func (u OrderUseCaseImpl) applyOnce(ctx context.Context, subscription string, eventId string, fn func(ctx context.Context) error) (bool, error) {
+	applied := false
+	err := u.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		fresh, err := u.processed.MarkProcessed(ctx, subscription, eventId)
+		if err != nil || !fresh {
+			return err
+		}
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		applied = true
+		return nil
+	})
+	return applied, err
 }
 
-func (u OrderUseCaseImpl) PublishOrderStatusChanged(ctx context.Context, order models.Order) {
-	orderPb := pb.OrderStatus{OrderId: int64(order.ID), Status: order.Status}
-	data, err := proto.Marshal(&orderPb)
+func (u OrderUseCaseImpl) orderStatusChangedMessage(ctx context.Context, order models.Order, change models.StatusChange) (models.OutboxMessage, error) {
+	data, err := proto.Marshal(orderStatusChangedEvent(order, change))
 	if err != nil {
-		log.Printf("failed to marshal message, err: %v\n", err)
-		return
+		return models.OutboxMessage{}, fmt.Errorf("failed to marshal message, err: %w", err)
 	}
+	return newOutboxMessage(ctx, orderStatusChangedSchema, order, data), nil
+}
+
+// newOutboxMessage
+// wraps the event data in a CloudEvents envelope (binary mode) whose subject is the order id and which carries the schema version,
+// correlation-id and spanId stay plain attributes for the consumers that rely on them
+func newOutboxMessage(ctx context.Context, schema eventSchema, order models.Order, data []byte) models.OutboxMessage {
 	msgId, ok := ctx.Value("correlation-id").(string)
 	if !ok {
 		ctx = contextWrapper.CorrelationId(ctx)
 		msgId = ctx.Value("correlation-id").(string)
 	}
 	span, _ := tracer.SpanFromContext(ctx)
-	u.pubSubClient.PublishAsync(ctx, "orderStatusChanged", &pubsub.Message{
-		Data:       data,
-		Attributes: map[string]string{"correlation-id": msgId, "service": "orders", "host": "localhost", "spanId": strconv.FormatUint(span.Context().SpanID(), 10)},
-	})
-
+	orderId := strconv.FormatUint(uint64(order.ID), 10)
+	event := messaging.CloudEvent{
+		ID:              uuid.New().String(),
+		Source:          EventSource,
+		Type:            schema.Type,
+		SpecVersion:     messaging.CloudEventsSpecVersion,
+		Time:            time.Now(),
+		DataContentType: messaging.ProtobufContentType,
+		Subject:         orderId,
+		Extensions:      map[string]string{messaging.SchemaVersionExtension: strconv.Itoa(schema.Version)},
+	}
+	return models.OutboxMessage{
+		Topic:         schema.Topic,
+		OrderingKey:   orderId,
+		Data:          data,
+		Attributes:    event.Attributes(map[string]string{"correlation-id": msgId, "spanId": strconv.FormatUint(span.Context().SpanID(), 10)}),
+		NextAttemptAt: time.Now(),
+	}
 }
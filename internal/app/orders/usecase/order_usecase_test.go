@@ -3,17 +3,27 @@ package usecase_test
 import (
 	"context"
 	"errors"
+	interfaces "github.com/nawafswe/orders-service/internal/app/orders"
+	"github.com/nawafswe/orders-service/internal/app/orders/exchange"
+	"github.com/nawafswe/orders-service/internal/app/orders/fees"
+	"github.com/nawafswe/orders-service/internal/app/orders/hub"
+	"github.com/nawafswe/orders-service/internal/app/orders/pricing"
+	"github.com/nawafswe/orders-service/internal/app/orders/promotion"
+	"github.com/nawafswe/orders-service/internal/app/orders/tax"
 	"github.com/nawafswe/orders-service/internal/app/orders/usecase"
 	"github.com/nawafswe/orders-service/internal/models"
 	loggerMock "github.com/nawafswe/orders-service/mocks/github.com/nawafswe/orders-service/pkg/logger"
-	messagesMock "github.com/nawafswe/orders-service/mocks/github.com/nawafswe/orders-service/pkg/messaging"
 	ordersMock "github.com/nawafswe/orders-service/mocks/github.com/nawafswe/orders-service/pkg/v1"
 	"github.com/nawafswe/orders-service/pkg/logger"
+	"github.com/nawafswe/orders-service/pkg/messaging"
+	pb "github.com/nawafswe/orders-service/proto"
 	"github.com/stretchr/testify/mock"
+	"google.golang.org/protobuf/proto"
 	"gorm.io/gorm"
 	"reflect"
 	"slices"
 	"testing"
+	"time"
 )
 
 func TestPlaceOrderUseCase(t *testing.T) {
@@ -31,13 +41,16 @@ func TestPlaceOrderUseCase(t *testing.T) {
 				CustomerId:   1,
 				RestaurantId: 1,
 				Status:       "New",
-				GrandTotal:   10,
+				GrandTotal:   models.Money{Amount: 1000, Currency: "USD"},
 				Items: []models.OrderedItem{
 					{
 						OrderedItemId:   1,
 						OrderedQuantity: 10,
-						Price:           1,
+						Price:           models.Money{Amount: 100, Currency: "USD"},
 						Name:            "Pepsi",
+						TaxCategory:     "standard",
+						Discount:        models.Money{Currency: "USD"},
+						Tax:             models.Money{Currency: "USD"},
 					},
 				},
 			},
@@ -45,29 +58,47 @@ func TestPlaceOrderUseCase(t *testing.T) {
 				CustomerId:   1,
 				RestaurantId: 1,
 				Status:       "New",
-				GrandTotal:   10,
+				GrandTotal:   models.Money{Amount: 1000, Currency: "USD"},
 				Items: []models.OrderedItem{
 					{
 						OrderedItemId:   1,
 						OrderedQuantity: 10,
-						Price:           1,
+						Price:           models.Money{Amount: 100, Currency: "USD"},
 						Name:            "Pepsi",
 					},
 				},
 			},
 		},
+		"FailPlaceOrderDueToGrandTotalMismatch": {
+			Description: "Should fail place order due to a grand total not matching its items",
+			Input: models.Order{
+				CustomerId:   1,
+				RestaurantId: 1,
+				GrandTotal:   models.Money{Amount: 3000, Currency: "USD"},
+				Items: []models.OrderedItem{
+					{
+						OrderedItemId:   1,
+						OrderedQuantity: 1,
+						Price:           models.Money{Amount: 2500, Currency: "USD"},
+						Name:            "Pizza",
+					},
+				},
+			},
+			ExpectedResult: models.Order{},
+			ExpectedErr:    models.PriceMismatchErr{Given: models.Money{Amount: 3000, Currency: "USD"}, Breakdown: models.PriceBreakdown{Subtotal: models.Money{Amount: 2500, Currency: "USD"}, Fees: models.Money{Currency: "USD"}, Taxes: models.Money{Currency: "USD"}, Discounts: models.Money{Currency: "USD"}, Tip: models.Money{Currency: "USD"}}},
+		},
 		"FailPlaceOrderDueToInvalidItemQuantity": {
 			Description: "Should fail place order due to invalid item quantities",
 			Input: models.Order{
 				CustomerId:   1,
 				RestaurantId: 1,
 				Status:       "New",
-				GrandTotal:   10,
+				GrandTotal:   models.Money{Amount: 1000, Currency: "USD"},
 				Items: []models.OrderedItem{
 					{
 						OrderedItemId:   1,
 						OrderedQuantity: -10,
-						Price:           1,
+						Price:           models.Money{Amount: 100, Currency: "USD"},
 						Name:            "Pepise",
 					},
 				},
@@ -82,18 +113,35 @@ func TestPlaceOrderUseCase(t *testing.T) {
 			t.Logf("running %s", name)
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
-			pubSubMock := messagesMock.NewMockMessageService(t)
 			ordersRepoMock := ordersMock.NewMockOrderRepo(t)
-			ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, pubSubMock, logger.NewLogger())
+			outboxMock := ordersMock.NewMockOutboxRepo(t)
+			txMock := ordersMock.NewMockTransactor(t)
+			notifierMock := ordersMock.NewMockOrderNotifier(t)
+			historyMock := ordersMock.NewMockStatusHistoryRepo(t)
+			ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, historyMock, outboxMock, ordersMock.NewMockIdempotencyRepo(t), ordersMock.NewMockProcessedMessageRepo(t), ordersMock.NewMockPromotionRepo(t), txMock, notifierMock, usdPricer(ordersMock.NewMockPromotionRepo(t)), exchange.NewStaticRates("USD", nil), usecase.DefaultCancellationPolicy(), logger.NewLogger())
 			// setting up mocks
 			if test.ExpectedErr == nil {
-				newOrder := test.Input
+				priced := test.Input
+				priced.Currency = "USD"
+				priced.Tip = models.Money{Currency: "USD"}
+				priced.Items = untaxed(test.Input.Items)
+				priced.Pricing = models.PriceBreakdown{Subtotal: test.Input.GrandTotal, Fees: models.Money{Currency: "USD"}, Taxes: models.Money{Currency: "USD"}, Discounts: models.Money{Currency: "USD"}, Tip: models.Money{Currency: "USD"}}
+				newOrder := priced
 				newOrder.ID = 1
-				ordersRepoMock.On("Create", mock.Anything, test.Input).Return(newOrder, nil)
-				pubSubMock.On("PublishAsync", mock.Anything, "orderCreated", mock.Anything).Return(nil)
-				pubSubMock.On("PublishAsync", mock.Anything, "orderStatusChanged", mock.Anything).Return(nil)
+				ordersRepoMock.On("Create", mock.Anything, priced).Return(newOrder, nil)
+				historyMock.On("Add", mock.Anything, models.StatusChange{OrderId: 1, Status: "New", Actor: models.CustomerActor}).Return(nil)
+				notifierMock.On("Notify", newOrder).Return()
+				txMock.EXPECT().WithinTransaction(mock.Anything, mock.Anything).RunAndReturn(runInTransaction)
+				outboxMock.On("Add", mock.Anything, mock.MatchedBy(func(messages []models.OutboxMessage) bool {
+					var created pb.OrderCreatedV1
+					var changed pb.OrderStatusChangedV1
+					return len(messages) == 2 && messages[0].Topic == "orderCreated" && messages[1].Topic == "orderStatusChanged" &&
+						isOrderEvent(messages[0], usecase.OrderCreatedEventType, "1") && isOrderEvent(messages[1], usecase.OrderStatusChangedEventType, "1") &&
+						proto.Unmarshal(messages[0].Data, &created) == nil && created.OrderId == 1 && len(created.Items) == len(test.Input.Items) && created.Currency == "USD" && created.GetTax().GetTotal().GetCurrency() == "USD" && created.GetTip().GetCurrency() == "USD" &&
+						proto.Unmarshal(messages[1].Data, &changed) == nil && changed.Currency == "USD" && changed.GrandTotalMoney.GetAmountMinor() == test.Input.GrandTotal.Amount
+				})).Return(nil)
 			}
-			result, err := ordersUseCase.PlaceOrder(ctx, test.Input)
+			result, err := ordersUseCase.PlaceOrder(ctx, test.Input, "")
 			if err == nil && test.ExpectedErr != nil {
 				t.Errorf("expected error from %s is %v but got %v", name, test.ExpectedErr, err)
 			}
@@ -105,12 +153,37 @@ func TestPlaceOrderUseCase(t *testing.T) {
 			}
 			if test.ExpectedErr == nil {
 				ordersRepoMock.AssertExpectations(t)
-				pubSubMock.AssertExpectations(t)
+				outboxMock.AssertExpectations(t)
+			} else {
+				outboxMock.AssertNumberOfCalls(t, "Add", 0)
 			}
 		})
 	}
 }
 
+// usdPricer
+// prices orders of every restaurant in USD without taxes or fees, discounting them with the promotions of the repo
+func usdPricer(promotions interfaces.PromotionRepo) interfaces.Pricer {
+	return pricing.NewPricer(pricing.Reject, pricing.NewStaticRestaurantCurrencies("USD", nil), tax.NewEngine("", nil, nil, nil), promotion.NewEvaluator(promotions), fees.NewPolicy(nil, nil))
+}
+
+// untaxed
+// the items as priced for a restaurant without taxes
+func untaxed(items []models.OrderedItem) []models.OrderedItem {
+	priced := make([]models.OrderedItem, 0, len(items))
+	for _, i := range items {
+		i.TaxCategory = "standard"
+		i.Discount = models.Money{Currency: "USD"}
+		i.Tax = models.Money{Currency: "USD"}
+		priced = append(priced, i)
+	}
+	return priced
+}
+
+func runInTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
 func TestUpdateOrderStatusUseCase(t *testing.T) {
 	tests := map[string]struct {
 		Description string
@@ -118,6 +191,8 @@ func TestUpdateOrderStatusUseCase(t *testing.T) {
 			OrderId int64
 			Status  string
 		}
+		CurrentStatus  string
+		GetByIdErr     error
 		ExpectedResult models.Order
 		ExpectedErr    error
 	}{
@@ -130,10 +205,39 @@ func TestUpdateOrderStatusUseCase(t *testing.T) {
 				OrderId: 1,
 				Status:  "Approved",
 			},
+			CurrentStatus:  "New",
 			ExpectedResult: models.Order{Model: gorm.Model{ID: 1}, Status: "Approved"},
 			ExpectedErr:    nil,
 		},
 
+		"SuccessfullyUpdateOrderStatusFromPreparingToDelivered": {
+			Description: "Should successfully update order status from preparing to delivered",
+			Input: struct {
+				OrderId int64
+				Status  string
+			}{
+				OrderId: 1,
+				Status:  "Delivered",
+			},
+			CurrentStatus:  "Preparing",
+			ExpectedResult: models.Order{Model: gorm.Model{ID: 1}, Status: "Delivered"},
+			ExpectedErr:    nil,
+		},
+
+		"FailedToUpdateOrderStatusFromApprovedToDelivered": {
+			Description: "Should fail update order status, an order is delivered only once it was prepared",
+			Input: struct {
+				OrderId int64
+				Status  string
+			}{
+				OrderId: 1,
+				Status:  "Delivered",
+			},
+			CurrentStatus:  "Approved",
+			ExpectedResult: models.Order{},
+			ExpectedErr:    models.InvalidStatusChangeErr{Message: "cannot change order status from 'Approved' to 'Delivered'"},
+		},
+
 		"FailedToUpdateOrderStatusDueInvalidIdPassed": {
 			Description: "Should fail update order status due invalid order id passed",
 			Input: struct {
@@ -143,6 +247,7 @@ func TestUpdateOrderStatusUseCase(t *testing.T) {
 				OrderId: -100,
 				Status:  "Approved",
 			},
+			GetByIdErr:     errors.New("invalid order id"),
 			ExpectedResult: models.Order{},
 			ExpectedErr:    errors.New("invalid order id"),
 		},
@@ -158,47 +263,115 @@ func TestUpdateOrderStatusUseCase(t *testing.T) {
 			ExpectedResult: models.Order{},
 			ExpectedErr:    models.InvalidStatusChangeErr{Message: "given status '' is invalid"},
 		},
+
+		"FailedToUpdateOrderStatusFromDeliveredToNew": {
+			Description: "Should fail update order status, due delivered order cannot go back to new",
+			Input: struct {
+				OrderId int64
+				Status  string
+			}{
+				OrderId: 1,
+				Status:  "New",
+			},
+			CurrentStatus:  "Delivered",
+			ExpectedResult: models.Order{},
+			ExpectedErr:    models.InvalidStatusChangeErr{Message: "cannot change order status from 'Delivered' to 'New'"},
+		},
+
+		"FailedToUpdateOrderStatusFromRejectedToDelivered": {
+			Description: "Should fail update order status, due rejected order can never be delivered",
+			Input: struct {
+				OrderId int64
+				Status  string
+			}{
+				OrderId: 1,
+				Status:  "Delivered",
+			},
+			CurrentStatus:  "Rejected",
+			ExpectedResult: models.Order{},
+			ExpectedErr:    models.InvalidStatusChangeErr{Message: "cannot change order status from 'Rejected' to 'Delivered'"},
+		},
+
+		"FailedToUpdateOrderStatusToTheSameStatus": {
+			Description: "Should fail update order status, due a replayed approval on an approved order",
+			Input: struct {
+				OrderId int64
+				Status  string
+			}{
+				OrderId: 1,
+				Status:  "Approved",
+			},
+			CurrentStatus:  "Approved",
+			ExpectedResult: models.Order{},
+			ExpectedErr:    models.InvalidStatusChangeErr{Message: "cannot change order status from 'Approved' to 'Approved'"},
+		},
 	}
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
 			t.Logf("running %v", name)
-			pubSubMock := messagesMock.NewMockMessageService(t)
 			ordersRepoMock := ordersMock.NewMockOrderRepo(t)
 			loggerMocks := loggerMock.NewMockLogger(t)
-			ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, pubSubMock, loggerMocks)
+			outboxMock := ordersMock.NewMockOutboxRepo(t)
+			txMock := ordersMock.NewMockTransactor(t)
+			notifierMock := ordersMock.NewMockOrderNotifier(t)
+			historyMock := ordersMock.NewMockStatusHistoryRepo(t)
+			ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, historyMock, outboxMock, ordersMock.NewMockIdempotencyRepo(t), ordersMock.NewMockProcessedMessageRepo(t), ordersMock.NewMockPromotionRepo(t), txMock, notifierMock, usdPricer(ordersMock.NewMockPromotionRepo(t)), exchange.NewStaticRates("USD", nil), usecase.DefaultCancellationPolicy(), loggerMocks)
+			if test.CurrentStatus != "" || test.GetByIdErr != nil {
+				ordersRepoMock.On("GetById", mock.Anything, test.Input.OrderId).Return(
+					models.Order{
+						Model:  gorm.Model{ID: uint(test.Input.OrderId)},
+						Status: test.CurrentStatus,
+					}, test.GetByIdErr)
+			}
 			if test.ExpectedErr == nil {
-
-				ordersRepoMock.On("UpdateOrderStatus", mock.Anything, test.Input.OrderId, test.Input.Status).Return(
+				ordersRepoMock.On("UpdateOrderStatus", mock.Anything, mock.MatchedBy(func(change models.StatusChange) bool {
+					return change.OrderId == test.Input.OrderId && change.PreviousStatus == test.CurrentStatus && change.Status == test.Input.Status &&
+						change.Reason == "picked up by the courier" && change.Actor == models.RestaurantActor && !change.ChangedAt.IsZero()
+				})).Return(
 					models.Order{
 						Model:  gorm.Model{ID: uint(test.Input.OrderId)},
-						Status: "Approved",
+						Status: test.Input.Status,
 					}, nil)
-				pubSubMock.On("PublishAsync", mock.Anything, "orderStatusChanged", mock.Anything).Return(nil)
-			} else {
-				var expectedErr models.InvalidStatusChangeErr
-				if !errors.As(test.ExpectedErr, &expectedErr) {
-					ordersRepoMock.On("UpdateOrderStatus", mock.Anything, test.Input.OrderId, test.Input.Status).Return(
-						models.Order{
-							Model:  gorm.Model{ID: uint(test.Input.OrderId)},
-							Status: test.Input.Status,
-						}, test.ExpectedErr)
-				}
+				historyMock.On("Add", mock.Anything, mock.MatchedBy(func(change models.StatusChange) bool {
+					return change.OrderId == test.Input.OrderId && change.PreviousStatus == test.CurrentStatus && change.Status == test.Input.Status &&
+						change.ReasonCode == "COURIER" && change.Actor == models.RestaurantActor && !change.ChangedAt.IsZero()
+				})).Return(nil)
+				txMock.EXPECT().WithinTransaction(mock.Anything, mock.Anything).RunAndReturn(runInTransaction)
+				outboxMock.On("Add", mock.Anything, mock.MatchedBy(func(messages []models.OutboxMessage) bool {
+					var event pb.OrderStatusChangedV1
+					return len(messages) == 1 && messages[0].Topic == "orderStatusChanged" && proto.Unmarshal(messages[0].Data, &event) == nil &&
+						event.PreviousStatus == test.CurrentStatus && event.Status == test.Input.Status && event.ReasonCode == "COURIER" &&
+						event.Reason == "picked up by the courier" && event.Actor == "restaurant" && event.ChangedAt != nil
+				})).Return(nil)
+				notifierMock.On("Notify", mock.Anything).Return()
 			}
 
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
-			result, err := ordersUseCase.UpdateOrderStatus(ctx, test.Input.OrderId, test.Input.Status)
+			result, err := ordersUseCase.UpdateOrderStatus(ctx, models.StatusChange{
+				OrderId:    test.Input.OrderId,
+				Status:     test.Input.Status,
+				ReasonCode: "COURIER",
+				Reason:     "picked up by the courier",
+				Actor:      models.RestaurantActor,
+			})
 			if test.ExpectedErr == nil {
 				ordersRepoMock.AssertExpectations(t)
-				pubSubMock.AssertExpectations(t)
+				outboxMock.AssertExpectations(t)
 
 			} else {
-				pubSubMock.AssertNumberOfCalls(t, "PublishAsync", 0)
+				outboxMock.AssertNumberOfCalls(t, "Add", 0)
+				ordersRepoMock.AssertNumberOfCalls(t, "UpdateOrderStatus", 0)
+				notifierMock.AssertNumberOfCalls(t, "Notify", 0)
 			}
 			if err == nil && test.ExpectedErr != nil {
 				t.Errorf("expected error to be %v, but got %v", test.ExpectedErr, err)
 			}
+			var expectedStatusErr models.InvalidStatusChangeErr
+			if errors.As(test.ExpectedErr, &expectedStatusErr) && !reflect.DeepEqual(err, test.ExpectedErr) {
+				t.Errorf("expected error to be %v, but got %v", test.ExpectedErr, err)
+			}
 
 			if !reflect.DeepEqual(result, test.ExpectedResult) {
 				t.Errorf("expected result to be %v, but got %v", test.ExpectedResult, result)
@@ -206,3 +379,476 @@ func TestUpdateOrderStatusUseCase(t *testing.T) {
 		})
 	}
 }
+
+func TestListOrdersUseCase(t *testing.T) {
+	tests := map[string]struct {
+		Description        string
+		Filter             models.OrderFilter
+		ExpectedLimit      int
+		RepoResult         []models.Order
+		ExpectedIds        []uint
+		ExpectedNextCursor int64
+		ExpectedErr        error
+	}{
+		"ListFirstPageWithMoreOrdersLeft": {
+			Description:        "Should return a page of the requested size and a cursor to the next one",
+			Filter:             models.OrderFilter{CustomerId: 1, Limit: 2},
+			ExpectedLimit:      3,
+			RepoResult:         []models.Order{{Model: gorm.Model{ID: 9}}, {Model: gorm.Model{ID: 7}}, {Model: gorm.Model{ID: 4}}},
+			ExpectedIds:        []uint{9, 7},
+			ExpectedNextCursor: 7,
+		},
+		"ListLastPage": {
+			Description:   "Should return the remaining orders without a cursor",
+			Filter:        models.OrderFilter{CustomerId: 1, Limit: 2, AfterId: 7},
+			ExpectedLimit: 3,
+			RepoResult:    []models.Order{{Model: gorm.Model{ID: 4}}},
+			ExpectedIds:   []uint{4},
+		},
+		"ListWithDefaultPageSize": {
+			Description:   "Should apply the default page size when none is given",
+			Filter:        models.OrderFilter{RestaurantId: 33},
+			ExpectedLimit: 21,
+			RepoResult:    []models.Order{},
+			ExpectedIds:   []uint{},
+		},
+		"FailListDueToInvalidStatusFilter": {
+			Description: "Should fail listing orders when filtering by unknown status",
+			Filter:      models.OrderFilter{Status: "Under-Preparation"},
+			ExpectedErr: models.InvalidStatusChangeErr{Message: "given status 'Under-Preparation' is invalid"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Logf("running %v", name)
+			ordersRepoMock := ordersMock.NewMockOrderRepo(t)
+			ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, ordersMock.NewMockStatusHistoryRepo(t), ordersMock.NewMockOutboxRepo(t), ordersMock.NewMockIdempotencyRepo(t), ordersMock.NewMockProcessedMessageRepo(t), ordersMock.NewMockPromotionRepo(t), ordersMock.NewMockTransactor(t), ordersMock.NewMockOrderNotifier(t), usdPricer(ordersMock.NewMockPromotionRepo(t)), exchange.NewStaticRates("USD", nil), usecase.DefaultCancellationPolicy(), loggerMock.NewMockLogger(t))
+			if test.ExpectedErr == nil {
+				repoFilter := test.Filter
+				repoFilter.Limit = test.ExpectedLimit
+				ordersRepoMock.On("List", mock.Anything, repoFilter).Return(test.RepoResult, nil)
+			}
+			page, err := ordersUseCase.ListOrders(context.Background(), test.Filter)
+			if !reflect.DeepEqual(err, test.ExpectedErr) {
+				t.Errorf("expected error to be %v, but got %v", test.ExpectedErr, err)
+			}
+			if test.ExpectedErr != nil {
+				ordersRepoMock.AssertNumberOfCalls(t, "List", 0)
+				return
+			}
+			ids := []uint{}
+			for _, o := range page.Orders {
+				ids = append(ids, o.ID)
+			}
+			if !slices.Equal(ids, test.ExpectedIds) {
+				t.Errorf("expected orders %v, but got %v", test.ExpectedIds, ids)
+			}
+			if page.NextCursor != test.ExpectedNextCursor {
+				t.Errorf("expected next cursor %v, but got %v", test.ExpectedNextCursor, page.NextCursor)
+			}
+		})
+	}
+}
+
+func TestWatchOrderUseCase(t *testing.T) {
+	ordersRepoMock := ordersMock.NewMockOrderRepo(t)
+	ordersHub := hub.NewOrderHub(4)
+	ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, ordersMock.NewMockStatusHistoryRepo(t), ordersMock.NewMockOutboxRepo(t), ordersMock.NewMockIdempotencyRepo(t), ordersMock.NewMockProcessedMessageRepo(t), ordersMock.NewMockPromotionRepo(t), ordersMock.NewMockTransactor(t), ordersHub, usdPricer(ordersMock.NewMockPromotionRepo(t)), exchange.NewStaticRates("USD", nil), usecase.DefaultCancellationPolicy(), loggerMock.NewMockLogger(t))
+	ordersRepoMock.On("GetById", mock.Anything, int64(1)).Return(models.Order{Model: gorm.Model{ID: 1}, Status: "New"}, nil)
+	ordersRepoMock.On("GetById", mock.Anything, int64(2)).Return(models.Order{}, models.OrderNotFoundErr{Id: 2})
+
+	feed, err := ordersUseCase.WatchOrder(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("expected watching order to succeed, but got %v", err)
+	}
+	if len(feed.Current) != 1 || feed.Current[0].Status != "New" {
+		t.Errorf("expected current state to be the new order, but got %v", feed.Current)
+	}
+	ordersHub.Notify(models.Order{Model: gorm.Model{ID: 2}, Status: "Approved"})
+	ordersHub.Notify(models.Order{Model: gorm.Model{ID: 1}, Status: "Approved"})
+	if o := <-feed.Updates; o.ID != 1 || o.Status != "Approved" {
+		t.Errorf("expected an update of order 1 to Approved, but got %v", o)
+	}
+	feed.Close()
+	if ordersHub.Subscribers() != 0 {
+		t.Errorf("expected closing the feed to unsubscribe, but hub has %d subscribers", ordersHub.Subscribers())
+	}
+
+	if _, err := ordersUseCase.WatchOrder(context.Background(), 2); err == nil {
+		t.Errorf("expected watching a missing order to fail")
+	}
+	if ordersHub.Subscribers() != 0 {
+		t.Errorf("expected a failed watch to unsubscribe, but hub has %d subscribers", ordersHub.Subscribers())
+	}
+}
+
+func TestWatchRestaurantOrdersUseCase(t *testing.T) {
+	ordersRepoMock := ordersMock.NewMockOrderRepo(t)
+	ordersHub := hub.NewOrderHub(4)
+	ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, ordersMock.NewMockStatusHistoryRepo(t), ordersMock.NewMockOutboxRepo(t), ordersMock.NewMockIdempotencyRepo(t), ordersMock.NewMockProcessedMessageRepo(t), ordersMock.NewMockPromotionRepo(t), ordersMock.NewMockTransactor(t), ordersHub, usdPricer(ordersMock.NewMockPromotionRepo(t)), exchange.NewStaticRates("USD", nil), usecase.DefaultCancellationPolicy(), loggerMock.NewMockLogger(t))
+	// 250 new orders, listed newest first a hundred at a time
+	ordersRepoMock.EXPECT().List(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, filter models.OrderFilter) ([]models.Order, error) {
+		if filter.Status != "New" {
+			return nil, nil
+		}
+		var orders []models.Order
+		for id := uint(250); id > 0 && len(orders) < filter.Limit; id-- {
+			if filter.AfterId == 0 || int64(id) < filter.AfterId {
+				orders = append(orders, models.Order{Model: gorm.Model{ID: id}, RestaurantId: 33, Status: "New"})
+			}
+		}
+		return orders, nil
+	})
+
+	feed, err := ordersUseCase.WatchRestaurantOrders(context.Background(), 33)
+	if err != nil {
+		t.Fatalf("expected watching the restaurant to succeed, but got %v", err)
+	}
+	defer feed.Close()
+	if len(feed.Current) != 250 || feed.Current[0].ID != 250 || feed.Current[249].ID != 1 {
+		t.Errorf("expected all 250 orders in progress, but got %d", len(feed.Current))
+	}
+}
+
+func TestUpdateOrderStatusWithExpectedPreviousStatusUseCase(t *testing.T) {
+	ordersRepoMock := ordersMock.NewMockOrderRepo(t)
+	ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, ordersMock.NewMockStatusHistoryRepo(t), ordersMock.NewMockOutboxRepo(t), ordersMock.NewMockIdempotencyRepo(t), ordersMock.NewMockProcessedMessageRepo(t), ordersMock.NewMockPromotionRepo(t), ordersMock.NewMockTransactor(t), ordersMock.NewMockOrderNotifier(t), usdPricer(ordersMock.NewMockPromotionRepo(t)), exchange.NewStaticRates("USD", nil), usecase.DefaultCancellationPolicy(), loggerMock.NewMockLogger(t))
+	// approved since the caller saw it as new, cancelling it now would override the approval
+	ordersRepoMock.On("GetById", mock.Anything, int64(1)).Return(models.Order{Model: gorm.Model{ID: 1}, Status: "Approved"}, nil)
+
+	_, err := ordersUseCase.UpdateOrderStatus(context.Background(), models.StatusChange{OrderId: 1, PreviousStatus: "New", Status: "Cancelled", Actor: models.SystemActor})
+	expectedErr := models.InvalidStatusChangeErr{Message: "order with id 1 is no longer in status 'New'"}
+	if !reflect.DeepEqual(err, expectedErr) {
+		t.Errorf("expected error to be %v, but got %v", expectedErr, err)
+	}
+	ordersRepoMock.AssertNumberOfCalls(t, "UpdateOrderStatus", 0)
+}
+
+func TestUpdateOrderStatusDoesNotLetCustomersCancelUseCase(t *testing.T) {
+	ordersRepoMock := ordersMock.NewMockOrderRepo(t)
+	ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, ordersMock.NewMockStatusHistoryRepo(t), ordersMock.NewMockOutboxRepo(t), ordersMock.NewMockIdempotencyRepo(t), ordersMock.NewMockProcessedMessageRepo(t), ordersMock.NewMockPromotionRepo(t), ordersMock.NewMockTransactor(t), ordersMock.NewMockOrderNotifier(t), usdPricer(ordersMock.NewMockPromotionRepo(t)), exchange.NewStaticRates("USD", nil), usecase.DefaultCancellationPolicy(), loggerMock.NewMockLogger(t))
+
+	// an approved order would be cancelled without the fee of the cancellation policy
+	_, err := ordersUseCase.UpdateOrderStatus(context.Background(), models.StatusChange{OrderId: 1, Status: "Cancelled", Actor: models.CustomerActor})
+	expectedErr := models.InvalidStatusChangeErr{Message: "order with id 1 can only be cancelled by its customer through CancelOrder"}
+	if !reflect.DeepEqual(err, expectedErr) {
+		t.Errorf("expected error to be %v, but got %v", expectedErr, err)
+	}
+	ordersRepoMock.AssertNumberOfCalls(t, "GetById", 0)
+}
+
+func TestGetOrderTimelineUseCase(t *testing.T) {
+	placedAt := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	timeline := []models.StatusChange{
+		{OrderId: 1, Status: "New", Actor: models.CustomerActor, ChangedAt: placedAt},
+		{OrderId: 1, PreviousStatus: "New", Status: "Approved", Actor: models.RestaurantActor, ChangedAt: placedAt.Add(time.Minute)},
+	}
+	tests := map[string]struct {
+		GetByIdErr       error
+		ExpectedTimeline []models.StatusChange
+		ExpectedErr      error
+	}{
+		"ReturnTransitionsOfOrder": {
+			ExpectedTimeline: timeline,
+		},
+		"FailForUnknownOrder": {
+			GetByIdErr:  models.OrderNotFoundErr{Id: 1},
+			ExpectedErr: models.OrderNotFoundErr{Id: 1},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ordersRepoMock := ordersMock.NewMockOrderRepo(t)
+			historyMock := ordersMock.NewMockStatusHistoryRepo(t)
+			ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, historyMock, ordersMock.NewMockOutboxRepo(t), ordersMock.NewMockIdempotencyRepo(t), ordersMock.NewMockProcessedMessageRepo(t), ordersMock.NewMockPromotionRepo(t), ordersMock.NewMockTransactor(t), ordersMock.NewMockOrderNotifier(t), usdPricer(ordersMock.NewMockPromotionRepo(t)), exchange.NewStaticRates("USD", nil), usecase.DefaultCancellationPolicy(), loggerMock.NewMockLogger(t))
+			ordersRepoMock.On("GetById", mock.Anything, int64(1)).Return(models.Order{Model: gorm.Model{ID: 1}}, test.GetByIdErr)
+			if test.GetByIdErr == nil {
+				historyMock.On("List", mock.Anything, int64(1)).Return(timeline, nil)
+			}
+			result, err := ordersUseCase.GetOrderTimeline(context.Background(), 1)
+			if !reflect.DeepEqual(err, test.ExpectedErr) {
+				t.Errorf("expected error to be %v, but got %v", test.ExpectedErr, err)
+			}
+			if !reflect.DeepEqual(result, test.ExpectedTimeline) {
+				t.Errorf("expected timeline to be %v, but got %v", test.ExpectedTimeline, result)
+			}
+		})
+	}
+}
+
+func TestPlaceOrderWithIdempotencyKeyUseCase(t *testing.T) {
+	input := models.Order{
+		CustomerId:   1,
+		RestaurantId: 1,
+		Status:       "New",
+		GrandTotal:   models.Money{Amount: 1000, Currency: "USD"},
+		Items:        []models.OrderedItem{{OrderedItemId: 1, OrderedQuantity: 10, Price: models.Money{Amount: 100, Currency: "USD"}, Name: "Pepsi"}},
+	}
+	// the order as created once priced
+	priced := input
+	priced.Currency = "USD"
+	priced.Tip = models.Money{Currency: "USD"}
+	priced.Items = untaxed(input.Items)
+	priced.Pricing = models.PriceBreakdown{Subtotal: models.Money{Amount: 1000, Currency: "USD"}, Fees: models.Money{Currency: "USD"}, Taxes: models.Money{Currency: "USD"}, Discounts: models.Money{Currency: "USD"}, Tip: models.Money{Currency: "USD"}}
+	created := priced
+	created.ID = 7
+
+	t.Run("CreateOrderAndSaveKey", func(t *testing.T) {
+		ordersRepoMock := ordersMock.NewMockOrderRepo(t)
+		outboxMock := ordersMock.NewMockOutboxRepo(t)
+		idempotencyMock := ordersMock.NewMockIdempotencyRepo(t)
+		txMock := ordersMock.NewMockTransactor(t)
+		notifierMock := ordersMock.NewMockOrderNotifier(t)
+		historyMock := ordersMock.NewMockStatusHistoryRepo(t)
+		ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, historyMock, outboxMock, idempotencyMock, ordersMock.NewMockProcessedMessageRepo(t), ordersMock.NewMockPromotionRepo(t), txMock, notifierMock, usdPricer(ordersMock.NewMockPromotionRepo(t)), exchange.NewStaticRates("USD", nil), usecase.DefaultCancellationPolicy(), logger.NewLogger())
+
+		idempotencyMock.On("Find", mock.Anything, "key-1").Return(models.IdempotencyKey{}, false, nil)
+		txMock.EXPECT().WithinTransaction(mock.Anything, mock.Anything).RunAndReturn(runInTransaction)
+		ordersRepoMock.On("Create", mock.Anything, priced).Return(created, nil)
+		idempotencyMock.On("Save", mock.Anything, mock.MatchedBy(func(k models.IdempotencyKey) bool {
+			return k.Key == "key-1" && k.OrderID == 7 && k.RequestHash != ""
+		})).Return(nil)
+		historyMock.On("Add", mock.Anything, mock.Anything).Return(nil)
+		outboxMock.On("Add", mock.Anything, mock.Anything).Return(nil)
+		notifierMock.On("Notify", created).Return()
+
+		o, err := ordersUseCase.PlaceOrder(context.Background(), input, "key-1")
+		if err != nil || o.ID != 7 {
+			t.Errorf("expected order 7 to be created, but got %v, err: %v", o.ID, err)
+		}
+	})
+
+	t.Run("ReturnOriginalOrderForRepeatedKey", func(t *testing.T) {
+		ordersRepoMock := ordersMock.NewMockOrderRepo(t)
+		idempotencyMock := ordersMock.NewMockIdempotencyRepo(t)
+		txMock := ordersMock.NewMockTransactor(t)
+		ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, ordersMock.NewMockStatusHistoryRepo(t), ordersMock.NewMockOutboxRepo(t), idempotencyMock, ordersMock.NewMockProcessedMessageRepo(t), ordersMock.NewMockPromotionRepo(t), txMock, ordersMock.NewMockOrderNotifier(t), usdPricer(ordersMock.NewMockPromotionRepo(t)), exchange.NewStaticRates("USD", nil), usecase.DefaultCancellationPolicy(), logger.NewLogger())
+
+		var savedHash string
+		firstCall := ordersMock.NewMockIdempotencyRepo(t)
+		firstCall.On("Find", mock.Anything, "key-1").Return(models.IdempotencyKey{}, false, nil)
+		firstCall.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			savedHash = args[1].(models.IdempotencyKey).RequestHash
+		}).Return(nil)
+		firstRepo := ordersMock.NewMockOrderRepo(t)
+		firstRepo.On("Create", mock.Anything, priced).Return(created, nil)
+		firstOutbox := ordersMock.NewMockOutboxRepo(t)
+		firstOutbox.On("Add", mock.Anything, mock.Anything).Return(nil)
+		firstHistory := ordersMock.NewMockStatusHistoryRepo(t)
+		firstHistory.On("Add", mock.Anything, mock.Anything).Return(nil)
+		firstNotifier := ordersMock.NewMockOrderNotifier(t)
+		firstNotifier.On("Notify", created).Return()
+		firstTx := ordersMock.NewMockTransactor(t)
+		firstTx.EXPECT().WithinTransaction(mock.Anything, mock.Anything).RunAndReturn(runInTransaction)
+		if _, err := usecase.NewOrderUseCase(firstRepo, firstHistory, firstOutbox, firstCall, ordersMock.NewMockProcessedMessageRepo(t), ordersMock.NewMockPromotionRepo(t), firstTx, firstNotifier, usdPricer(ordersMock.NewMockPromotionRepo(t)), exchange.NewStaticRates("USD", nil), usecase.DefaultCancellationPolicy(), logger.NewLogger()).PlaceOrder(context.Background(), input, "key-1"); err != nil {
+			t.Fatalf("expected first order placement to succeed, but got %v", err)
+		}
+
+		idempotencyMock.On("Find", mock.Anything, "key-1").Return(models.IdempotencyKey{Key: "key-1", OrderID: 7, RequestHash: savedHash}, true, nil)
+		ordersRepoMock.On("GetById", mock.Anything, int64(7)).Return(created, nil)
+
+		o, err := ordersUseCase.PlaceOrder(context.Background(), input, "key-1")
+		if err != nil || o.ID != 7 {
+			t.Errorf("expected the original order 7 to be returned, but got %v, err: %v", o.ID, err)
+		}
+		ordersRepoMock.AssertNumberOfCalls(t, "Create", 0)
+		txMock.AssertNumberOfCalls(t, "WithinTransaction", 0)
+	})
+
+	t.Run("KeepTheFingerprintOfARetryCarryingResponseFields", func(t *testing.T) {
+		var hashes []string
+		for _, order := range []models.Order{input, func() models.Order {
+			// a retry echoing fields the service sets, such as the taxes of its items or the pricing of the order
+			retry := input
+			retry.Items = []models.OrderedItem{input.Items[0]}
+			retry.Items[0].TaxCategory = "reduced"
+			retry.Items[0].TaxRate = 0.05
+			retry.Pricing = models.PriceBreakdown{Subtotal: models.Money{Amount: 1000, Currency: "USD"}}
+			retry.TaxRegion = "US-NY"
+			return retry
+		}()} {
+			idempotencyMock := ordersMock.NewMockIdempotencyRepo(t)
+			idempotencyMock.On("Find", mock.Anything, "key-1").Return(models.IdempotencyKey{}, false, nil)
+			idempotencyMock.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				hashes = append(hashes, args[1].(models.IdempotencyKey).RequestHash)
+			}).Return(nil)
+			ordersRepoMock := ordersMock.NewMockOrderRepo(t)
+			ordersRepoMock.On("Create", mock.Anything, mock.Anything).Return(created, nil)
+			outboxMock := ordersMock.NewMockOutboxRepo(t)
+			outboxMock.On("Add", mock.Anything, mock.Anything).Return(nil)
+			historyMock := ordersMock.NewMockStatusHistoryRepo(t)
+			historyMock.On("Add", mock.Anything, mock.Anything).Return(nil)
+			notifierMock := ordersMock.NewMockOrderNotifier(t)
+			notifierMock.On("Notify", mock.Anything).Return()
+			txMock := ordersMock.NewMockTransactor(t)
+			txMock.EXPECT().WithinTransaction(mock.Anything, mock.Anything).RunAndReturn(runInTransaction)
+			if _, err := usecase.NewOrderUseCase(ordersRepoMock, historyMock, outboxMock, idempotencyMock, ordersMock.NewMockProcessedMessageRepo(t), ordersMock.NewMockPromotionRepo(t), txMock, notifierMock, usdPricer(ordersMock.NewMockPromotionRepo(t)), exchange.NewStaticRates("USD", nil), usecase.DefaultCancellationPolicy(), logger.NewLogger()).PlaceOrder(context.Background(), order, "key-1"); err != nil {
+				t.Fatalf("expected order placement to succeed, but got %v", err)
+			}
+		}
+		if len(hashes) != 2 || hashes[0] != hashes[1] {
+			t.Errorf("expected the retry to keep the fingerprint of the request, but got %v", hashes)
+		}
+	})
+
+	t.Run("FailForRepeatedKeyWithDifferentPayload", func(t *testing.T) {
+		ordersRepoMock := ordersMock.NewMockOrderRepo(t)
+		idempotencyMock := ordersMock.NewMockIdempotencyRepo(t)
+		ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, ordersMock.NewMockStatusHistoryRepo(t), ordersMock.NewMockOutboxRepo(t), idempotencyMock, ordersMock.NewMockProcessedMessageRepo(t), ordersMock.NewMockPromotionRepo(t), ordersMock.NewMockTransactor(t), ordersMock.NewMockOrderNotifier(t), usdPricer(ordersMock.NewMockPromotionRepo(t)), exchange.NewStaticRates("USD", nil), usecase.DefaultCancellationPolicy(), logger.NewLogger())
+		idempotencyMock.On("Find", mock.Anything, "key-1").Return(models.IdempotencyKey{Key: "key-1", OrderID: 7, RequestHash: "another-payload"}, true, nil)
+
+		_, err := ordersUseCase.PlaceOrder(context.Background(), input, "key-1")
+		if !errors.As(err, &models.IdempotencyConflictErr{}) {
+			t.Errorf("expected an idempotency conflict, but got %v", err)
+		}
+		ordersRepoMock.AssertNumberOfCalls(t, "Create", 0)
+	})
+
+	t.Run("ReturnOrderOfConcurrentRequestWithSameKey", func(t *testing.T) {
+		ordersRepoMock := ordersMock.NewMockOrderRepo(t)
+		outboxMock := ordersMock.NewMockOutboxRepo(t)
+		idempotencyMock := ordersMock.NewMockIdempotencyRepo(t)
+		txMock := ordersMock.NewMockTransactor(t)
+		notifierMock := ordersMock.NewMockOrderNotifier(t)
+		ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, ordersMock.NewMockStatusHistoryRepo(t), outboxMock, idempotencyMock, ordersMock.NewMockProcessedMessageRepo(t), ordersMock.NewMockPromotionRepo(t), txMock, notifierMock, usdPricer(ordersMock.NewMockPromotionRepo(t)), exchange.NewStaticRates("USD", nil), usecase.DefaultCancellationPolicy(), logger.NewLogger())
+
+		var savedHash string
+		idempotencyMock.On("Find", mock.Anything, "key-1").Return(models.IdempotencyKey{}, false, nil).Once()
+		txMock.EXPECT().WithinTransaction(mock.Anything, mock.Anything).RunAndReturn(runInTransaction)
+		ordersRepoMock.On("Create", mock.Anything, priced).Return(models.Order{Model: gorm.Model{ID: 8}}, nil)
+		idempotencyMock.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			savedHash = args[1].(models.IdempotencyKey).RequestHash
+		}).Return(models.IdempotencyKeyInUseErr{Key: "key-1"})
+		idempotencyMock.On("Find", mock.Anything, "key-1").Return(func(context.Context, string) (models.IdempotencyKey, bool, error) {
+			return models.IdempotencyKey{Key: "key-1", OrderID: 7, RequestHash: savedHash}, true, nil
+		}).Once()
+		ordersRepoMock.On("GetById", mock.Anything, int64(7)).Return(created, nil)
+
+		o, err := ordersUseCase.PlaceOrder(context.Background(), input, "key-1")
+		if err != nil || o.ID != 7 {
+			t.Errorf("expected the order of the winning request to be returned, but got %v, err: %v", o.ID, err)
+		}
+		outboxMock.AssertNumberOfCalls(t, "Add", 0)
+		notifierMock.AssertNumberOfCalls(t, "Notify", 0)
+	})
+}
+
+// isOrderEvent
+// whether the outbox message carries a cloudevent of the given type about the given order
+func isOrderEvent(msg models.OutboxMessage, eventType string, orderId string) bool {
+	event, err := messaging.DecodeCloudEvent(&messaging.Message{Data: msg.Data, Attributes: msg.Attributes})
+	return err == nil && event.Type == eventType && event.Subject == orderId && event.Source == usecase.EventSource &&
+		event.DataContentType == messaging.ProtobufContentType && !event.Time.IsZero() && msg.OrderingKey == orderId &&
+		event.Extensions[messaging.SchemaVersionExtension] == "1"
+}
+
+func TestPlaceOrderWithCouponUseCase(t *testing.T) {
+	input := models.Order{
+		CustomerId:   1,
+		RestaurantId: 1,
+		CouponCode:   "SPRING",
+		GrandTotal:   models.Money{Amount: 900, Currency: "USD"},
+		Items:        []models.OrderedItem{{OrderedItemId: 1, OrderedQuantity: 10, Price: models.Money{Amount: 100, Currency: "USD"}, Name: "Pepsi"}},
+	}
+	spring := models.Promotion{Model: gorm.Model{ID: 4}, Code: "SPRING", Kind: "percent_off", Percent: 0.1, MaxUsesPerCustomer: 1}
+	discount := models.Discount{PromotionID: 4, Code: "SPRING", Kind: "percent_off", Amount: models.Money{Amount: 100, Currency: "USD"}}
+	tests := map[string]struct {
+		RedeemErr   error
+		ExpectedErr error
+	}{
+		"RedeemThePromotion": {},
+		"FailOnceTheCustomerUsedItUp": {
+			RedeemErr:   models.InvalidCouponErr{Code: "SPRING", Reason: "it can only be used 1 times"},
+			ExpectedErr: models.InvalidCouponErr{Code: "SPRING", Reason: "it can only be used 1 times"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ordersRepoMock := ordersMock.NewMockOrderRepo(t)
+			promotionsMock := ordersMock.NewMockPromotionRepo(t)
+			txMock := ordersMock.NewMockTransactor(t)
+			historyMock := ordersMock.NewMockStatusHistoryRepo(t)
+			outboxMock := ordersMock.NewMockOutboxRepo(t)
+			notifierMock := ordersMock.NewMockOrderNotifier(t)
+			ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, historyMock, outboxMock, ordersMock.NewMockIdempotencyRepo(t), ordersMock.NewMockProcessedMessageRepo(t), promotionsMock, txMock, notifierMock, usdPricer(promotionsMock), exchange.NewStaticRates("USD", nil), usecase.DefaultCancellationPolicy(), logger.NewLogger())
+
+			promotionsMock.On("GetByCode", mock.Anything, "SPRING").Return(spring, nil)
+			txMock.EXPECT().WithinTransaction(mock.Anything, mock.Anything).RunAndReturn(runInTransaction)
+			ordersRepoMock.EXPECT().Create(mock.Anything, mock.MatchedBy(func(o models.Order) bool {
+				return len(o.Discounts) == 1 && o.Discounts[0] == discount && o.Pricing.Discounts == discount.Amount && o.GrandTotal == input.GrandTotal
+			})).RunAndReturn(func(ctx context.Context, o models.Order) (models.Order, error) {
+				o.ID = 9
+				return o, nil
+			})
+			promotionsMock.On("Redeem", mock.Anything, mock.MatchedBy(func(r models.Redemption) bool {
+				return r.PromotionID == 4 && r.OrderID == 9 && r.CustomerId == 1
+			})).Return(test.RedeemErr)
+			if test.ExpectedErr == nil {
+				historyMock.On("Add", mock.Anything, mock.Anything).Return(nil)
+				outboxMock.On("Add", mock.Anything, mock.MatchedBy(func(messages []models.OutboxMessage) bool {
+					var created pb.OrderCreatedV1
+					return proto.Unmarshal(messages[0].Data, &created) == nil && created.CouponCode == "SPRING" &&
+						len(created.Discounts) == 1 && created.Discounts[0].GetAmount().GetAmountMinor() == 100
+				})).Return(nil)
+				notifierMock.On("Notify", mock.Anything).Return()
+			}
+
+			o, err := ordersUseCase.PlaceOrder(context.Background(), input, "")
+			if test.ExpectedErr != nil {
+				if !errors.Is(err, test.ExpectedErr) {
+					t.Fatalf("expected error %v, but got %v", test.ExpectedErr, err)
+				}
+				notifierMock.AssertNotCalled(t, "Notify", mock.Anything)
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if o.ID != 9 || len(o.Discounts) != 1 {
+				t.Errorf("expected order 9 to be discounted, but got %+v", o)
+			}
+		})
+	}
+}
+
+func TestRevertRedemptionsUseCase(t *testing.T) {
+	tests := map[string]struct {
+		Status       string
+		Discounts    []models.Discount
+		ExpectRevert bool
+	}{
+		"RevertWhenRejected":           {Status: "Rejected", Discounts: []models.Discount{{PromotionID: 4, Code: "SPRING"}}, ExpectRevert: true},
+		"RevertWhenCancelled":          {Status: "Cancelled", Discounts: []models.Discount{{PromotionID: 4, Code: "SPRING"}}, ExpectRevert: true},
+		"KeepWhenApproved":             {Status: "Approved", Discounts: []models.Discount{{PromotionID: 4, Code: "SPRING"}}},
+		"NothingToRevertWithoutCoupon": {Status: "Rejected"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ordersRepoMock := ordersMock.NewMockOrderRepo(t)
+			promotionsMock := ordersMock.NewMockPromotionRepo(t)
+			txMock := ordersMock.NewMockTransactor(t)
+			historyMock := ordersMock.NewMockStatusHistoryRepo(t)
+			outboxMock := ordersMock.NewMockOutboxRepo(t)
+			notifierMock := ordersMock.NewMockOrderNotifier(t)
+			ordersUseCase := usecase.NewOrderUseCase(ordersRepoMock, historyMock, outboxMock, ordersMock.NewMockIdempotencyRepo(t), ordersMock.NewMockProcessedMessageRepo(t), promotionsMock, txMock, notifierMock, usdPricer(promotionsMock), exchange.NewStaticRates("USD", nil), usecase.DefaultCancellationPolicy(), logger.NewLogger())
+
+			ordersRepoMock.On("GetById", mock.Anything, int64(1)).Return(models.Order{Model: gorm.Model{ID: 1}, Status: "New", Discounts: test.Discounts}, nil)
+			txMock.EXPECT().WithinTransaction(mock.Anything, mock.Anything).RunAndReturn(runInTransaction)
+			ordersRepoMock.On("UpdateOrderStatus", mock.Anything, mock.Anything).Return(models.Order{Model: gorm.Model{ID: 1}, Status: test.Status, Discounts: test.Discounts}, nil)
+			historyMock.On("Add", mock.Anything, mock.Anything).Return(nil)
+			outboxMock.On("Add", mock.Anything, mock.Anything).Return(nil)
+			notifierMock.On("Notify", mock.Anything).Return()
+			if test.ExpectRevert {
+				promotionsMock.On("RevertRedemptions", mock.Anything, int64(1), mock.AnythingOfType("time.Time")).Return(nil)
+			}
+
+			if _, err := ordersUseCase.UpdateOrderStatus(context.Background(), models.StatusChange{OrderId: 1, Status: test.Status, Actor: models.RestaurantActor}); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !test.ExpectRevert {
+				promotionsMock.AssertNotCalled(t, "RevertRedemptions", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
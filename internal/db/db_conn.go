@@ -24,7 +24,7 @@ func InitDB() (*gorm.DB, error) {
 		password,
 	)
 	DB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err := DB.AutoMigrate(models.Order{}, models.OrderedItem{}); err != nil {
+	if err := DB.AutoMigrate(models.Order{}, models.OrderedItem{}, models.TaxLine{}, models.Discount{}, models.Fee{}, models.Promotion{}, models.Redemption{}, models.OutboxMessage{}, models.IdempotencyKey{}, models.ProcessedMessage{}, models.OrderStatusHistory{}); err != nil {
 		log.Fatal("failed to migrate db tables, err: %w", err)
 	}
 	if err != nil {
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"fmt"
+	"github.com/nawafswe/orders-service/internal/models"
+	"gorm.io/gorm"
+	"math"
+)
+
+// legacyAmounts
+// the float columns amounts used to be stored in, each replaced by the <prefix>amount and <prefix>currency columns of a models.Money.
+// The float columns are still written alongside Money (see models.Order.LegacyGrandTotal), so a rollback or an older replica during
+// a rolling deploy reads the right amounts. Rows written by an older replica have no currency and are converted on the next startup
+var legacyAmounts = []struct {
+	Table  string
+	Column string
+	Prefix string
+}{
+	{Table: "orders", Column: "grand_total", Prefix: "grand_total_"},
+	{Table: "orders", Column: "pricing_subtotal", Prefix: "pricing_subtotal_"},
+	{Table: "orders", Column: "pricing_fees", Prefix: "pricing_fees_"},
+	{Table: "orders", Column: "pricing_taxes", Prefix: "pricing_taxes_"},
+	{Table: "orders", Column: "cancellation_fee", Prefix: "cancellation_fee_"},
+	{Table: "ordered_items", Column: "price", Prefix: "price_"},
+}
+
+// MigrateLegacyAmounts
+// converts the float amounts of rows stored before Money to minor units of the given currency.
+// Only rows without a currency are converted, so it is safe to run on every startup
+func MigrateLegacyAmounts(db *gorm.DB, currency string) error {
+	scale := math.Pow10(models.CurrencyExponent(currency))
+	for _, l := range legacyAmounts {
+		if !db.Migrator().HasColumn(l.Table, l.Column) {
+			continue
+		}
+		q := fmt.Sprintf("UPDATE %[1]s SET %[3]samount = ROUND(%[2]s * ?), %[3]scurrency = ? WHERE %[3]scurrency IS NULL AND %[2]s IS NOT NULL", l.Table, l.Column, l.Prefix)
+		if err := db.Exec(q, scale, currency).Error; err != nil {
+			return fmt.Errorf("failed to migrate %v.%v to minor units, err: %w", l.Table, l.Column, err)
+		}
+	}
+	// orders placed before they had a currency of their own are in the currency of their grand total
+	if err := db.Exec("UPDATE orders SET currency = grand_total_currency WHERE currency IS NULL AND grand_total_currency IS NOT NULL").Error; err != nil {
+		return fmt.Errorf("failed to migrate the currency of orders, err: %w", err)
+	}
+	return nil
+}
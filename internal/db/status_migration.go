@@ -0,0 +1,16 @@
+package db
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+)
+
+// BackfillStatusChangedAt
+// orders created before status_changed_at was set on creation have been in their status since they were created.
+// Only rows without it are updated, so it is safe to run on every startup
+func BackfillStatusChangedAt(db *gorm.DB) error {
+	if err := db.Exec("UPDATE orders SET status_changed_at = created_at WHERE status_changed_at IS NULL").Error; err != nil {
+		return fmt.Errorf("failed to backfill the status change time of orders, err: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+package models
+
+// Cancellation
+// a customer asking to cancel one of their orders
+type Cancellation struct {
+	OrderId    int64
+	CustomerId int64
+	ReasonCode string
+	Reason     string
+}
@@ -0,0 +1,72 @@
+package models
+
+import "fmt"
+
+// FeeKind
+// what a fee of an order is charged for
+type FeeKind int
+
+const (
+	// DeliveryFee depends on the distance the order is delivered over
+	DeliveryFee FeeKind = iota
+	// ServiceFee is a share of the basket
+	ServiceFee
+	// SmallBasketFee is charged on baskets below a minimum
+	SmallBasketFee
+)
+
+var feeKindNames = map[FeeKind]string{
+	DeliveryFee:    "delivery",
+	ServiceFee:     "service",
+	SmallBasketFee: "small_basket",
+}
+
+func (k FeeKind) String() string {
+	return feeKindNames[k]
+}
+
+// ParseFeeKind
+// maps the persisted kind name back to its FeeKind value
+func ParseFeeKind(kind string) (FeeKind, error) {
+	for k, name := range feeKindNames {
+		if name == kind {
+			return k, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid fee kind %v", kind)
+}
+
+// Fee
+// a fee charged on an order by the fee policy, the fees of an order sum up to the fees of its pricing
+type Fee struct {
+	ID      uint `gorm:"primarykey"`
+	OrderID uint `gorm:"index"`
+	Kind    string
+	Amount  Money `gorm:"embedded;embeddedPrefix:amount_"`
+}
+
+func (Fee) TableName() string {
+	return "order_fees"
+}
+
+// DeliveryOutOfRangeErr
+// the order is delivered further than the restaurant delivers
+type DeliveryOutOfRangeErr struct {
+	RestaurantId int64
+	Distance     int64
+	MaxDistance  int64
+}
+
+func (d DeliveryOutOfRangeErr) Error() string {
+	return fmt.Sprintf("restaurant %v delivers up to %v meters, the order is %v meters away", d.RestaurantId, d.MaxDistance, d.Distance)
+}
+
+// InvalidTipErr
+// the tip of the customer cannot be charged
+type InvalidTipErr struct {
+	Tip Money
+}
+
+func (i InvalidTipErr) Error() string {
+	return fmt.Sprintf("tip %v should not be negative", i.Tip)
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// IdempotencyKey
+// remembers which order a client supplied key created, and a fingerprint of the request that created it
+type IdempotencyKey struct {
+	Key         string `gorm:"primaryKey"`
+	OrderID     uint
+	RequestHash string
+	CreatedAt   time.Time `gorm:"index"`
+}
+
+// IdempotencyConflictErr
+// the key was already used by a request with a different payload
+type IdempotencyConflictErr struct {
+	Key string
+}
+
+func (i IdempotencyConflictErr) Error() string {
+	return fmt.Sprintf("idempotency key %v was already used for a different order", i.Key)
+}
+
+// IdempotencyKeyInUseErr
+// another request saved the key first
+type IdempotencyKeyInUseErr struct {
+	Key string
+}
+
+func (i IdempotencyKeyInUseErr) Error() string {
+	return fmt.Sprintf("idempotency key %v is already in use", i.Key)
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"fmt"
+	"math"
+)
+
+// Money
+// an amount in the minor units of its ISO 4217 currency (e.g. cents), so amounts add up without floating point rounding
+type Money struct {
+	Amount   int64  `gorm:"type:bigint"`
+	Currency string `gorm:"type:varchar(3)"`
+}
+
+// ValidateCurrency
+// fails for anything that is not an upper case ISO 4217 code of a currency with a minor unit
+func ValidateCurrency(currency string) error {
+	if _, ok := currencies[currency]; !ok {
+		return fmt.Errorf("invalid currency %v, expected an ISO 4217 code such as USD", currency)
+	}
+	return nil
+}
+
+// CurrencyExponent
+// the number of decimals of the currency, 2 for an unknown one
+func CurrencyExponent(currency string) int {
+	if e, ok := currencies[currency]; ok {
+		return e
+	}
+	return 2
+}
+
+// FromMajor
+// converts an amount in major units, such as the deprecated float prices, rounding it to the minor unit
+func FromMajor(amount float64, currency string) Money {
+	return Money{Amount: int64(math.Round(amount * math.Pow10(CurrencyExponent(currency)))), Currency: currency}
+}
+
+// Major
+// the amount in major units, for the deprecated float fields only
+func (m Money) Major() float64 {
+	return float64(m.Amount) / math.Pow10(CurrencyExponent(m.Currency))
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Add
+// sums two amounts of the same currency, a zero amount without currency takes the currency of the other
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency == "" && m.Amount == 0 {
+		m.Currency = other.Currency
+	}
+	if other.Currency == "" && other.Amount == 0 {
+		other.Currency = m.Currency
+	}
+	if m.Currency != other.Currency {
+		return Money{}, CurrencyMismatchErr{Expected: m.Currency, Given: other.Currency}
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// Times
+// the amount multiplied by a quantity
+func (m Money) Times(quantity int64) Money {
+	return Money{Amount: m.Amount * quantity, Currency: m.Currency}
+}
+
+// Scale
+// the amount multiplied by a rate such as a fee percentage, rounded half away from zero to the minor unit
+func (m Money) Scale(rate float64) Money {
+	return Money{Amount: int64(math.Round(float64(m.Amount) * rate)), Currency: m.Currency}
+}
+
+// Convert
+// the amount in another currency at the given rate, the price of one major unit of m in to, rounded to the minor unit
+func (m Money) Convert(to string, rate float64) Money {
+	scale := math.Pow10(CurrencyExponent(to) - CurrencyExponent(m.Currency))
+	return Money{Amount: int64(math.Round(float64(m.Amount) * rate * scale)), Currency: to}
+}
+
+// String
+// the amount in major units followed by the currency, e.g. 25.50 USD
+func (m Money) String() string {
+	return fmt.Sprintf("%.*f %v", CurrencyExponent(m.Currency), m.Major(), m.Currency)
+}
+
+// CurrencyMismatchErr
+// amounts of different currencies were combined
+type CurrencyMismatchErr struct {
+	Expected string
+	Given    string
+}
+
+func (c CurrencyMismatchErr) Error() string {
+	return fmt.Sprintf("currency %v does not match the currency %v of the order", c.Given, c.Expected)
+}
+
+// ExchangeRateNotFoundErr
+// there is no rate to convert between the currencies
+type ExchangeRateNotFoundErr struct {
+	From string
+	To   string
+}
+
+func (e ExchangeRateNotFoundErr) Error() string {
+	return fmt.Sprintf("no exchange rate from %v to %v", e.From, e.To)
+}
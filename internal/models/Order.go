@@ -1,7 +1,9 @@
 package models
 
 import (
+	"fmt"
 	"gorm.io/gorm"
+	"time"
 )
 
 type OrderStatus int
@@ -12,18 +14,74 @@ type OrderStatus int
 const (
 	New OrderStatus = iota
 	Approved
+	// Preparing the restaurant started preparing the order
+	Preparing
 	Rejected
 	Cancelled
 	Delivered
 )
 
+var orderStatusNames = map[OrderStatus]string{
+	New:       "New",
+	Approved:  "Approved",
+	Preparing: "Preparing",
+	Rejected:  "Rejected",
+	Cancelled: "Cancelled",
+	Delivered: "Delivered",
+}
+
+func (s OrderStatus) String() string {
+	return orderStatusNames[s]
+}
+
+// ParseOrderStatus
+// maps the persisted/transported status name back to its OrderStatus value
+func ParseOrderStatus(status string) (OrderStatus, error) {
+	for s, name := range orderStatusNames {
+		if name == status {
+			return s, nil
+		}
+	}
+	return 0, InvalidStatusChangeErr{Message: fmt.Sprintf("given status '%v' is invalid", status)}
+}
+
 type Order struct {
 	gorm.Model
-	CustomerId   int64
-	RestaurantId int64
-	Status       string
-	GrandTotal   float64
-	Items        []OrderedItem `gorm:"foreignKey:order_id"` // one to many
+	CustomerId   int64  `gorm:"index"`
+	RestaurantId int64  `gorm:"index"`
+	Status       string `gorm:"index:idx_orders_status_changed_at,priority:1"`
+	// Currency of the restaurant, every amount of the order is in it
+	Currency   string `gorm:"type:varchar(3);index"`
+	GrandTotal Money  `gorm:"embedded;embeddedPrefix:grand_total_"`
+	// GrandTotalMajor the deprecated double grand total sent by an older client, converted to GrandTotal once the currency of the restaurant is known
+	GrandTotalMajor float64 `gorm:"-"`
+	// Pricing the breakdown of the grand total, computed when the order is placed
+	Pricing PriceBreakdown `gorm:"embedded;embeddedPrefix:pricing_"`
+	Items   []OrderedItem  `gorm:"foreignKey:order_id"` // one to many
+	// TaxRegion of the restaurant the taxes were computed for, TaxInclusive when the item prices include them
+	TaxRegion    string
+	TaxInclusive bool
+	// TaxLines the taxes of the order per category and rate, they sum up to the taxes of the pricing
+	TaxLines []TaxLine `gorm:"foreignKey:OrderID"`
+	// CouponCode sent by the customer, Discounts what its promotion took off the order
+	CouponCode string
+	Discounts  []Discount `gorm:"foreignKey:OrderID"`
+	// DeliveryDistance from the restaurant to the customer in meters, the delivery fee depends on it
+	DeliveryDistance int64
+	// Tip the customer chose to give on top of the grand total, Fees what the fee policy charged
+	Tip  Money `gorm:"embedded;embeddedPrefix:tip_"`
+	Fees []Fee `gorm:"foreignKey:OrderID"`
+	// the last status change, empty until the status first changes except StatusChangedAt which starts at the creation of the order
+	StatusReasonCode string
+	StatusReason     string
+	StatusChangedBy  string
+	StatusChangedAt  *time.Time `gorm:"index:idx_orders_status_changed_at,priority:2"`
+	// CancellationFee charged to the customer for cancelling the order, zero for a free cancellation
+	CancellationFee Money `gorm:"embedded;embeddedPrefix:cancellation_fee_"`
+	// LegacyGrandTotal and LegacyCancellationFee are the deprecated float columns in major units, written by the repository
+	// alongside Money so replicas that predate it still read the right amounts until the columns are dropped
+	LegacyGrandTotal      float64 `gorm:"column:grand_total"`
+	LegacyCancellationFee float64 `gorm:"column:cancellation_fee"`
 }
 
 type InvalidStatusChangeErr struct {
@@ -33,3 +91,52 @@ type InvalidStatusChangeErr struct {
 func (i InvalidStatusChangeErr) Error() string {
 	return i.Message
 }
+
+// CancellationDeniedErr
+// the cancellation policy does not let the customer cancel the order in its current status
+type CancellationDeniedErr struct {
+	Message string
+}
+
+func (c CancellationDeniedErr) Error() string {
+	return c.Message
+}
+
+// NotOrderOwnerErr
+// the order does not belong to the customer acting on it
+type NotOrderOwnerErr struct {
+	OrderId    int64
+	CustomerId int64
+}
+
+func (n NotOrderOwnerErr) Error() string {
+	return fmt.Sprintf("order with id %v does not belong to customer %v", n.OrderId, n.CustomerId)
+}
+
+type OrderNotFoundErr struct {
+	Id int64
+}
+
+func (o OrderNotFoundErr) Error() string {
+	return fmt.Sprintf("order with id %v not found", o.Id)
+}
+
+// OrderFilter
+// criteria for listing orders, zero values are ignored.
+// Orders are returned newest first, AfterId is the keyset cursor: only orders with a smaller id are returned
+type OrderFilter struct {
+	CustomerId   int64
+	RestaurantId int64
+	Status       string
+	CreatedFrom  time.Time
+	CreatedTo    time.Time
+	AfterId      int64
+	Limit        int
+}
+
+// OrderPage
+// a page of orders, NextCursor is zero when there are no more orders
+type OrderPage struct {
+	Orders     []Order
+	NextCursor int64
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// OrderStatusHistory
+// a status transition of an order, written in the same transaction as the transition itself
+type OrderStatusHistory struct {
+	ID             uint  `gorm:"primarykey"`
+	OrderId        int64 `gorm:"index"`
+	PreviousStatus string
+	Status         string
+	ReasonCode     string
+	Reason         string
+	Actor          string
+	ChangedAt      time.Time
+}
+
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
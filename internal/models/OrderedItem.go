@@ -7,6 +7,17 @@ type OrderedItem struct {
 	OrderedQuantity int32
 	Name            string
 	OrderedItemId   int64
-	Price           float64
-	OrderID         uint `gorm:"column:order_id"` // Foreign key to the Order model
+	Price           Money `gorm:"embedded;embeddedPrefix:price_"`
+	// PriceMajor the deprecated double price sent by an older client, converted to Price once the currency of the restaurant is known
+	PriceMajor float64 `gorm:"-"`
+	// TaxCategory of the item, such as standard or reduced, resolved from the tax catalog when the order is priced
+	TaxCategory string
+	TaxRate     float64
+	// Discount the share of the discounts of the order taken off the whole ordered quantity, taxes are computed on the amount left
+	Discount Money `gorm:"embedded;embeddedPrefix:discount_"`
+	// Tax of the whole ordered quantity, included in the price with inclusive pricing
+	Tax     Money `gorm:"embedded;embeddedPrefix:tax_"`
+	OrderID uint  `gorm:"column:order_id"` // Foreign key to the Order model
+	// LegacyPrice the deprecated float column, see Order.LegacyGrandTotal
+	LegacyPrice float64 `gorm:"column:price"`
 }
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// OutboxMessage
+// an event waiting to be published, written in the same transaction as the change it describes
+type OutboxMessage struct {
+	ID            uint `gorm:"primarykey"`
+	CreatedAt     time.Time
+	Topic         string
+	OrderingKey   string `gorm:"index"`
+	Data          []byte
+	Attributes    map[string]string `gorm:"serializer:json"`
+	Attempts      int
+	NextAttemptAt time.Time  `gorm:"index"`
+	SentAt        *time.Time `gorm:"index"`
+	LastError     string
+}
+
+// OutboxStats
+// how far behind the relay is, OldestPendingAt is zero when nothing is pending
+type OutboxStats struct {
+	Pending         int64
+	OldestPendingAt time.Time
+}
+
+func (s OutboxStats) Lag() time.Duration {
+	if s.OldestPendingAt.IsZero() {
+		return 0
+	}
+	return time.Since(s.OldestPendingAt)
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"fmt"
+)
+
+// PriceBreakdown
+// how the grand total of an order is made up, the total is the subtotal of its items plus fees, taxes and the tip less discounts
+type PriceBreakdown struct {
+	Subtotal  Money `gorm:"embedded;embeddedPrefix:subtotal_"`
+	Fees      Money `gorm:"embedded;embeddedPrefix:fees_"`
+	Taxes     Money `gorm:"embedded;embeddedPrefix:taxes_"`
+	Discounts Money `gorm:"embedded;embeddedPrefix:discounts_"`
+	Tip       Money `gorm:"embedded;embeddedPrefix:tip_"`
+	// the deprecated float columns, see Order.LegacyGrandTotal
+	LegacySubtotal float64 `gorm:"column:subtotal"`
+	LegacyFees     float64 `gorm:"column:fees"`
+	LegacyTaxes    float64 `gorm:"column:taxes"`
+}
+
+func (b PriceBreakdown) Total() (Money, error) {
+	total, err := b.Subtotal.Add(b.Fees)
+	if err != nil {
+		return Money{}, err
+	}
+	if total, err = total.Add(b.Taxes); err != nil {
+		return Money{}, err
+	}
+	if total, err = total.Add(b.Tip); err != nil {
+		return Money{}, err
+	}
+	return total.Add(b.Discounts.Times(-1))
+}
+
+// PriceMismatchErr
+// the grand total sent by the client does not match the one computed from the items
+type PriceMismatchErr struct {
+	Given     Money
+	Breakdown PriceBreakdown
+}
+
+func (p PriceMismatchErr) Error() string {
+	total, _ := p.Breakdown.Total()
+	return fmt.Sprintf("grand total %v does not match the computed total %v (subtotal %v, fees %v, taxes %v, tip %v, discounts %v)",
+		p.Given, total, p.Breakdown.Subtotal, p.Breakdown.Fees, p.Breakdown.Taxes, p.Breakdown.Tip, p.Breakdown.Discounts)
+}
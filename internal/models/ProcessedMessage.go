@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// ProcessedMessage
+// an inbound event already applied by a subscription, written in the same transaction as its effect
+type ProcessedMessage struct {
+	Subscription string    `gorm:"primaryKey"`
+	MessageId    string    `gorm:"primaryKey"`
+	ProcessedAt  time.Time `gorm:"index"`
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+// PromotionKind
+// how a promotion discounts an order
+type PromotionKind int
+
+const (
+	// PercentOffPromotion takes a share of the basket off
+	PercentOffPromotion PromotionKind = iota
+	// FixedOffPromotion takes a fixed amount off the basket
+	FixedOffPromotion
+	// BuyXGetYPromotion gives units of an item for free for every units of it paid
+	BuyXGetYPromotion
+)
+
+var promotionKindNames = map[PromotionKind]string{
+	PercentOffPromotion: "percent_off",
+	FixedOffPromotion:   "fixed_off",
+	BuyXGetYPromotion:   "buy_x_get_y",
+}
+
+func (k PromotionKind) String() string {
+	return promotionKindNames[k]
+}
+
+// ParsePromotionKind
+// maps the persisted kind name back to its PromotionKind value
+func ParsePromotionKind(kind string) (PromotionKind, error) {
+	for k, name := range promotionKindNames {
+		if name == kind {
+			return k, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid promotion kind %v", kind)
+}
+
+// Promotion
+// a discount customers get by placing an order with its code. Zero values of the conditions are ignored
+type Promotion struct {
+	gorm.Model
+	Code string `gorm:"uniqueIndex"`
+	Kind string
+	// Percent of the basket taken off by a percent_off promotion, between 0 and 1
+	Percent float64
+	// AmountOff taken off the basket by a fixed_off promotion, at most the basket itself
+	AmountOff Money `gorm:"embedded;embeddedPrefix:amount_off_"`
+	// a buy_x_get_y promotion gives FreeQuantity units of the item for every BuyQuantity units of it paid
+	OrderedItemId int64
+	BuyQuantity   int32
+	FreeQuantity  int32
+	// MinimumBasket the items of the order should at least add up to
+	MinimumBasket Money `gorm:"embedded;embeddedPrefix:minimum_basket_"`
+	// MaxUsesPerCustomer the orders of a customer the promotion can be redeemed on, not counting rejected or cancelled ones
+	MaxUsesPerCustomer int64
+	ValidFrom          *time.Time
+	ValidUntil         *time.Time
+}
+
+// Validate
+// rejects a promotion of an unknown kind, taking off a share of the basket outside of 0 and 1, a negative amount
+// or an amount without a valid currency, and giving units for free without paid and free quantities
+func (p Promotion) Validate() error {
+	kind, err := ParsePromotionKind(p.Kind)
+	if err != nil {
+		return err
+	}
+	if p.Percent < 0 || p.Percent > 1 {
+		return fmt.Errorf("percent %v of promotion %v should be between 0 and 1", p.Percent, p.Code)
+	}
+	switch kind {
+	case FixedOffPromotion:
+		if p.AmountOff.Amount < 0 {
+			return fmt.Errorf("amount off %v of promotion %v should not be negative", p.AmountOff, p.Code)
+		}
+		if err := ValidateCurrency(p.AmountOff.Currency); err != nil {
+			return fmt.Errorf("amount off of promotion %v has an %w", p.Code, err)
+		}
+	case BuyXGetYPromotion:
+		if p.BuyQuantity <= 0 || p.FreeQuantity <= 0 {
+			return fmt.Errorf("buy quantity %v and free quantity %v of promotion %v should be positive", p.BuyQuantity, p.FreeQuantity, p.Code)
+		}
+	}
+	return nil
+}
+
+// BeforeSave
+// promotions are validated before they are stored, whichever tool creates them
+func (p *Promotion) BeforeSave(tx *gorm.DB) error {
+	return p.Validate()
+}
+
+// Redemption
+// the use of a promotion by an order, reverted when the order is rejected or cancelled
+type Redemption struct {
+	ID          uint  `gorm:"primarykey"`
+	PromotionID uint  `gorm:"index"`
+	OrderID     uint  `gorm:"index"`
+	CustomerId  int64 `gorm:"index"`
+	RedeemedAt  time.Time
+	RevertedAt  *time.Time
+}
+
+func (Redemption) TableName() string {
+	return "promotion_redemptions"
+}
+
+// Discount
+// what a promotion took off an order, OrderedItemId is the item it was taken off or 0 for the whole basket
+type Discount struct {
+	ID            uint `gorm:"primarykey"`
+	OrderID       uint `gorm:"index"`
+	PromotionID   uint
+	Code          string
+	Kind          string
+	OrderedItemId int64
+	Amount        Money `gorm:"embedded;embeddedPrefix:amount_"`
+}
+
+func (Discount) TableName() string {
+	return "order_discounts"
+}
+
+// InvalidCouponErr
+// the coupon code cannot be applied to the order
+type InvalidCouponErr struct {
+	Code   string
+	Reason string
+}
+
+func (i InvalidCouponErr) Error() string {
+	return fmt.Sprintf("coupon %v cannot be applied, %v", i.Code, i.Reason)
+}
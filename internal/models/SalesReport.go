@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// SalesReportFilter
+// the orders a sales report is made of, zero values are ignored. Currency is the one the report is converted to
+type SalesReportFilter struct {
+	RestaurantId int64
+	CreatedFrom  time.Time
+	CreatedTo    time.Time
+	Currency     string
+}
+
+// CurrencyTotal
+// the sum of the grand totals of the orders placed in one currency
+type CurrencyTotal struct {
+	Total  Money
+	Orders int64
+}
+
+// SalesReport
+// the sales of every currency as placed, and their sum converted to the currency of the report.
+// Rejected and cancelled orders are not sales
+type SalesReport struct {
+	ByCurrency []CurrencyTotal
+	Total      Money
+	Orders     int64
+}
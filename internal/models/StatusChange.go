@@ -0,0 +1,61 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Actor
+// who changed the status of an order
+type Actor int
+
+const (
+	SystemActor Actor = iota
+	CustomerActor
+	RestaurantActor
+)
+
+var actorNames = map[Actor]string{
+	SystemActor:     "system",
+	CustomerActor:   "customer",
+	RestaurantActor: "restaurant",
+}
+
+func (a Actor) String() string {
+	return actorNames[a]
+}
+
+// ParseActor
+// maps the persisted/transported actor name back to its Actor value
+func ParseActor(actor string) (Actor, error) {
+	for a, name := range actorNames {
+		if name == actor {
+			return a, nil
+		}
+	}
+	return 0, InvalidActorErr{Actor: actor}
+}
+
+// InvalidActorErr
+// the actor name is not one of the known actors
+type InvalidActorErr struct {
+	Actor string
+}
+
+func (i InvalidActorErr) Error() string {
+	return fmt.Sprintf("given actor '%v' is invalid", i.Actor)
+}
+
+// StatusChange
+// a change of the status of an order, why it happened and who made it.
+// PreviousStatus and ChangedAt are set by the use case when the change is applied
+type StatusChange struct {
+	OrderId        int64
+	PreviousStatus string
+	Status         string
+	// ReasonCode is machine readable, e.g. OUT_OF_STOCK, Reason is free text meant for the customer
+	ReasonCode string
+	Reason     string
+	Actor      Actor
+	ChangedAt  time.Time
+}
@@ -0,0 +1,48 @@
+package models
+
+import "fmt"
+
+// TaxLine
+// the tax of the items of an order sharing a category and rate, Taxable is the amount the rate applies to
+type TaxLine struct {
+	ID       uint `gorm:"primarykey"`
+	OrderID  uint `gorm:"index"`
+	Category string
+	Rate     float64
+	Taxable  Money `gorm:"embedded;embeddedPrefix:taxable_"`
+	Tax      Money `gorm:"embedded;embeddedPrefix:tax_"`
+}
+
+func (TaxLine) TableName() string {
+	return "order_tax_lines"
+}
+
+// ItemTax
+// the tax of one item of an order, for the whole ordered quantity
+type ItemTax struct {
+	Category string
+	Rate     float64
+	Tax      Money
+}
+
+// TaxAssessment
+// the taxes of an order as computed for its region. Items follows the order of the items of the order.
+// With inclusive pricing the taxes are part of the item prices, otherwise they are added on top of them
+type TaxAssessment struct {
+	Region    string
+	Inclusive bool
+	Items     []ItemTax
+	Lines     []TaxLine
+	Total     Money
+}
+
+// UnknownTaxCategoryErr
+// the region of the restaurant has no rate for the tax category of an item
+type UnknownTaxCategoryErr struct {
+	Region   string
+	Category string
+}
+
+func (u UnknownTaxCategoryErr) Error() string {
+	return fmt.Sprintf("tax category %v is unknown in region %v", u.Category, u.Region)
+}
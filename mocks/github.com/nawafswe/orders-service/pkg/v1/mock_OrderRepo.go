@@ -4,6 +4,7 @@ package v1
 
 import (
 	context "context"
+	time "time"
 
 	models "github.com/nawafswe/orders-service/internal/models"
 	mock "github.com/stretchr/testify/mock"
@@ -79,9 +80,293 @@ func (_c *MockOrderRepo_Create_Call) RunAndReturn(run func(context.Context, mode
 	return _c
 }
 
-// UpdateOrderStatus provides a mock function with given fields: ctx, id, status
-func (_m *MockOrderRepo) UpdateOrderStatus(ctx context.Context, id int64, status string) (models.Order, error) {
-	ret := _m.Called(ctx, id, status)
+// GetById provides a mock function with given fields: ctx, id
+func (_m *MockOrderRepo) GetById(ctx context.Context, id int64) (models.Order, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetById")
+	}
+
+	var r0 models.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (models.Order, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) models.Order); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(models.Order)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockOrderRepo_GetById_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetById'
+type MockOrderRepo_GetById_Call struct {
+	*mock.Call
+}
+
+// GetById is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int64
+func (_e *MockOrderRepo_Expecter) GetById(ctx interface{}, id interface{}) *MockOrderRepo_GetById_Call {
+	return &MockOrderRepo_GetById_Call{Call: _e.mock.On("GetById", ctx, id)}
+}
+
+func (_c *MockOrderRepo_GetById_Call) Run(run func(ctx context.Context, id int64)) *MockOrderRepo_GetById_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *MockOrderRepo_GetById_Call) Return(_a0 models.Order, _a1 error) *MockOrderRepo_GetById_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockOrderRepo_GetById_Call) RunAndReturn(run func(context.Context, int64) (models.Order, error)) *MockOrderRepo_GetById_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// List provides a mock function with given fields: ctx, filter
+func (_m *MockOrderRepo) List(ctx context.Context, filter models.OrderFilter) ([]models.Order, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for List")
+	}
+
+	var r0 []models.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.OrderFilter) ([]models.Order, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.OrderFilter) []models.Order); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.OrderFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockOrderRepo_List_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'List'
+type MockOrderRepo_List_Call struct {
+	*mock.Call
+}
+
+// List is a helper method to define mock.On call
+//   - ctx context.Context
+//   - filter models.OrderFilter
+func (_e *MockOrderRepo_Expecter) List(ctx interface{}, filter interface{}) *MockOrderRepo_List_Call {
+	return &MockOrderRepo_List_Call{Call: _e.mock.On("List", ctx, filter)}
+}
+
+func (_c *MockOrderRepo_List_Call) Run(run func(ctx context.Context, filter models.OrderFilter)) *MockOrderRepo_List_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.OrderFilter))
+	})
+	return _c
+}
+
+func (_c *MockOrderRepo_List_Call) Return(_a0 []models.Order, _a1 error) *MockOrderRepo_List_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockOrderRepo_List_Call) RunAndReturn(run func(context.Context, models.OrderFilter) ([]models.Order, error)) *MockOrderRepo_List_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListStale provides a mock function with given fields: ctx, status, changedBefore, limit
+func (_m *MockOrderRepo) ListStale(ctx context.Context, status string, changedBefore time.Time, limit int) ([]models.Order, error) {
+	ret := _m.Called(ctx, status, changedBefore, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListStale")
+	}
+
+	var r0 []models.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, int) ([]models.Order, error)); ok {
+		return rf(ctx, status, changedBefore, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time, int) []models.Order); ok {
+		r0 = rf(ctx, status, changedBefore, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time, int) error); ok {
+		r1 = rf(ctx, status, changedBefore, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockOrderRepo_ListStale_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListStale'
+type MockOrderRepo_ListStale_Call struct {
+	*mock.Call
+}
+
+// ListStale is a helper method to define mock.On call
+//   - ctx context.Context
+//   - status string
+//   - changedBefore time.Time
+//   - limit int
+func (_e *MockOrderRepo_Expecter) ListStale(ctx interface{}, status interface{}, changedBefore interface{}, limit interface{}) *MockOrderRepo_ListStale_Call {
+	return &MockOrderRepo_ListStale_Call{Call: _e.mock.On("ListStale", ctx, status, changedBefore, limit)}
+}
+
+func (_c *MockOrderRepo_ListStale_Call) Run(run func(ctx context.Context, status string, changedBefore time.Time, limit int)) *MockOrderRepo_ListStale_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(time.Time), args[3].(int))
+	})
+	return _c
+}
+
+func (_c *MockOrderRepo_ListStale_Call) Return(_a0 []models.Order, _a1 error) *MockOrderRepo_ListStale_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockOrderRepo_ListStale_Call) RunAndReturn(run func(context.Context, string, time.Time, int) ([]models.Order, error)) *MockOrderRepo_ListStale_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SalesByCurrency provides a mock function with given fields: ctx, filter
+func (_m *MockOrderRepo) SalesByCurrency(ctx context.Context, filter models.SalesReportFilter) ([]models.CurrencyTotal, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SalesByCurrency")
+	}
+
+	var r0 []models.CurrencyTotal
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.SalesReportFilter) ([]models.CurrencyTotal, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.SalesReportFilter) []models.CurrencyTotal); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.CurrencyTotal)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.SalesReportFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockOrderRepo_SalesByCurrency_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SalesByCurrency'
+type MockOrderRepo_SalesByCurrency_Call struct {
+	*mock.Call
+}
+
+// SalesByCurrency is a helper method to define mock.On call
+//   - ctx context.Context
+//   - filter models.SalesReportFilter
+func (_e *MockOrderRepo_Expecter) SalesByCurrency(ctx interface{}, filter interface{}) *MockOrderRepo_SalesByCurrency_Call {
+	return &MockOrderRepo_SalesByCurrency_Call{Call: _e.mock.On("SalesByCurrency", ctx, filter)}
+}
+
+func (_c *MockOrderRepo_SalesByCurrency_Call) Run(run func(ctx context.Context, filter models.SalesReportFilter)) *MockOrderRepo_SalesByCurrency_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.SalesReportFilter))
+	})
+	return _c
+}
+
+func (_c *MockOrderRepo_SalesByCurrency_Call) Return(_a0 []models.CurrencyTotal, _a1 error) *MockOrderRepo_SalesByCurrency_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockOrderRepo_SalesByCurrency_Call) RunAndReturn(run func(context.Context, models.SalesReportFilter) ([]models.CurrencyTotal, error)) *MockOrderRepo_SalesByCurrency_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetCancellationFee provides a mock function with given fields: ctx, orderId, fee
+func (_m *MockOrderRepo) SetCancellationFee(ctx context.Context, orderId int64, fee models.Money) error {
+	ret := _m.Called(ctx, orderId, fee)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetCancellationFee")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, models.Money) error); ok {
+		r0 = rf(ctx, orderId, fee)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockOrderRepo_SetCancellationFee_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetCancellationFee'
+type MockOrderRepo_SetCancellationFee_Call struct {
+	*mock.Call
+}
+
+// SetCancellationFee is a helper method to define mock.On call
+//   - ctx context.Context
+//   - orderId int64
+//   - fee models.Money
+func (_e *MockOrderRepo_Expecter) SetCancellationFee(ctx interface{}, orderId interface{}, fee interface{}) *MockOrderRepo_SetCancellationFee_Call {
+	return &MockOrderRepo_SetCancellationFee_Call{Call: _e.mock.On("SetCancellationFee", ctx, orderId, fee)}
+}
+
+func (_c *MockOrderRepo_SetCancellationFee_Call) Run(run func(ctx context.Context, orderId int64, fee models.Money)) *MockOrderRepo_SetCancellationFee_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64), args[2].(models.Money))
+	})
+	return _c
+}
+
+func (_c *MockOrderRepo_SetCancellationFee_Call) Return(_a0 error) *MockOrderRepo_SetCancellationFee_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockOrderRepo_SetCancellationFee_Call) RunAndReturn(run func(context.Context, int64, models.Money) error) *MockOrderRepo_SetCancellationFee_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateOrderStatus provides a mock function with given fields: ctx, change
+func (_m *MockOrderRepo) UpdateOrderStatus(ctx context.Context, change models.StatusChange) (models.Order, error) {
+	ret := _m.Called(ctx, change)
 
 	if len(ret) == 0 {
 		panic("no return value specified for UpdateOrderStatus")
@@ -89,17 +374,17 @@ func (_m *MockOrderRepo) UpdateOrderStatus(ctx context.Context, id int64, status
 
 	var r0 models.Order
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (models.Order, error)); ok {
-		return rf(ctx, id, status)
+	if rf, ok := ret.Get(0).(func(context.Context, models.StatusChange) (models.Order, error)); ok {
+		return rf(ctx, change)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64, string) models.Order); ok {
-		r0 = rf(ctx, id, status)
+	if rf, ok := ret.Get(0).(func(context.Context, models.StatusChange) models.Order); ok {
+		r0 = rf(ctx, change)
 	} else {
 		r0 = ret.Get(0).(models.Order)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
-		r1 = rf(ctx, id, status)
+	if rf, ok := ret.Get(1).(func(context.Context, models.StatusChange) error); ok {
+		r1 = rf(ctx, change)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -114,15 +399,14 @@ type MockOrderRepo_UpdateOrderStatus_Call struct {
 
 // UpdateOrderStatus is a helper method to define mock.On call
 //   - ctx context.Context
-//   - id int64
-//   - status string
-func (_e *MockOrderRepo_Expecter) UpdateOrderStatus(ctx interface{}, id interface{}, status interface{}) *MockOrderRepo_UpdateOrderStatus_Call {
-	return &MockOrderRepo_UpdateOrderStatus_Call{Call: _e.mock.On("UpdateOrderStatus", ctx, id, status)}
+//   - change models.StatusChange
+func (_e *MockOrderRepo_Expecter) UpdateOrderStatus(ctx interface{}, change interface{}) *MockOrderRepo_UpdateOrderStatus_Call {
+	return &MockOrderRepo_UpdateOrderStatus_Call{Call: _e.mock.On("UpdateOrderStatus", ctx, change)}
 }
 
-func (_c *MockOrderRepo_UpdateOrderStatus_Call) Run(run func(ctx context.Context, id int64, status string)) *MockOrderRepo_UpdateOrderStatus_Call {
+func (_c *MockOrderRepo_UpdateOrderStatus_Call) Run(run func(ctx context.Context, change models.StatusChange)) *MockOrderRepo_UpdateOrderStatus_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(int64), args[2].(string))
+		run(args[0].(context.Context), args[1].(models.StatusChange))
 	})
 	return _c
 }
@@ -132,7 +416,7 @@ func (_c *MockOrderRepo_UpdateOrderStatus_Call) Return(_a0 models.Order, _a1 err
 	return _c
 }
 
-func (_c *MockOrderRepo_UpdateOrderStatus_Call) RunAndReturn(run func(context.Context, int64, string) (models.Order, error)) *MockOrderRepo_UpdateOrderStatus_Call {
+func (_c *MockOrderRepo_UpdateOrderStatus_Call) RunAndReturn(run func(context.Context, models.StatusChange) (models.Order, error)) *MockOrderRepo_UpdateOrderStatus_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -5,6 +5,7 @@ package v1
 import (
 	context "context"
 
+	orders "github.com/nawafswe/orders-service/internal/app/orders"
 	models "github.com/nawafswe/orders-service/internal/models"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -22,9 +23,252 @@ func (_m *MockOrderUseCase) EXPECT() *MockOrderUseCase_Expecter {
 	return &MockOrderUseCase_Expecter{mock: &_m.Mock}
 }
 
-// HandleOrderApproval provides a mock function with given fields: ctx
-func (_m *MockOrderUseCase) HandleOrderApproval(ctx context.Context) {
-	_m.Called(ctx)
+// CancelOrder provides a mock function with given fields: ctx, cancellation
+func (_m *MockOrderUseCase) CancelOrder(ctx context.Context, cancellation models.Cancellation) (models.Order, error) {
+	ret := _m.Called(ctx, cancellation)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CancelOrder")
+	}
+
+	var r0 models.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.Cancellation) (models.Order, error)); ok {
+		return rf(ctx, cancellation)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.Cancellation) models.Order); ok {
+		r0 = rf(ctx, cancellation)
+	} else {
+		r0 = ret.Get(0).(models.Order)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.Cancellation) error); ok {
+		r1 = rf(ctx, cancellation)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockOrderUseCase_CancelOrder_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CancelOrder'
+type MockOrderUseCase_CancelOrder_Call struct {
+	*mock.Call
+}
+
+// CancelOrder is a helper method to define mock.On call
+//   - ctx context.Context
+//   - cancellation models.Cancellation
+func (_e *MockOrderUseCase_Expecter) CancelOrder(ctx interface{}, cancellation interface{}) *MockOrderUseCase_CancelOrder_Call {
+	return &MockOrderUseCase_CancelOrder_Call{Call: _e.mock.On("CancelOrder", ctx, cancellation)}
+}
+
+func (_c *MockOrderUseCase_CancelOrder_Call) Run(run func(ctx context.Context, cancellation models.Cancellation)) *MockOrderUseCase_CancelOrder_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.Cancellation))
+	})
+	return _c
+}
+
+func (_c *MockOrderUseCase_CancelOrder_Call) Return(_a0 models.Order, _a1 error) *MockOrderUseCase_CancelOrder_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockOrderUseCase_CancelOrder_Call) RunAndReturn(run func(context.Context, models.Cancellation) (models.Order, error)) *MockOrderUseCase_CancelOrder_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetOrder provides a mock function with given fields: ctx, orderId
+func (_m *MockOrderUseCase) GetOrder(ctx context.Context, orderId int64) (models.Order, error) {
+	ret := _m.Called(ctx, orderId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrder")
+	}
+
+	var r0 models.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (models.Order, error)); ok {
+		return rf(ctx, orderId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) models.Order); ok {
+		r0 = rf(ctx, orderId)
+	} else {
+		r0 = ret.Get(0).(models.Order)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, orderId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockOrderUseCase_GetOrder_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetOrder'
+type MockOrderUseCase_GetOrder_Call struct {
+	*mock.Call
+}
+
+// GetOrder is a helper method to define mock.On call
+//   - ctx context.Context
+//   - orderId int64
+func (_e *MockOrderUseCase_Expecter) GetOrder(ctx interface{}, orderId interface{}) *MockOrderUseCase_GetOrder_Call {
+	return &MockOrderUseCase_GetOrder_Call{Call: _e.mock.On("GetOrder", ctx, orderId)}
+}
+
+func (_c *MockOrderUseCase_GetOrder_Call) Run(run func(ctx context.Context, orderId int64)) *MockOrderUseCase_GetOrder_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *MockOrderUseCase_GetOrder_Call) Return(_a0 models.Order, _a1 error) *MockOrderUseCase_GetOrder_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockOrderUseCase_GetOrder_Call) RunAndReturn(run func(context.Context, int64) (models.Order, error)) *MockOrderUseCase_GetOrder_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetOrderTimeline provides a mock function with given fields: ctx, orderId
+func (_m *MockOrderUseCase) GetOrderTimeline(ctx context.Context, orderId int64) ([]models.StatusChange, error) {
+	ret := _m.Called(ctx, orderId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrderTimeline")
+	}
+
+	var r0 []models.StatusChange
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]models.StatusChange, error)); ok {
+		return rf(ctx, orderId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []models.StatusChange); ok {
+		r0 = rf(ctx, orderId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.StatusChange)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, orderId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockOrderUseCase_GetOrderTimeline_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetOrderTimeline'
+type MockOrderUseCase_GetOrderTimeline_Call struct {
+	*mock.Call
+}
+
+// GetOrderTimeline is a helper method to define mock.On call
+//   - ctx context.Context
+//   - orderId int64
+func (_e *MockOrderUseCase_Expecter) GetOrderTimeline(ctx interface{}, orderId interface{}) *MockOrderUseCase_GetOrderTimeline_Call {
+	return &MockOrderUseCase_GetOrderTimeline_Call{Call: _e.mock.On("GetOrderTimeline", ctx, orderId)}
+}
+
+func (_c *MockOrderUseCase_GetOrderTimeline_Call) Run(run func(ctx context.Context, orderId int64)) *MockOrderUseCase_GetOrderTimeline_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64))
+	})
+	return _c
+}
+
+func (_c *MockOrderUseCase_GetOrderTimeline_Call) Return(_a0 []models.StatusChange, _a1 error) *MockOrderUseCase_GetOrderTimeline_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockOrderUseCase_GetOrderTimeline_Call) RunAndReturn(run func(context.Context, int64) ([]models.StatusChange, error)) *MockOrderUseCase_GetOrderTimeline_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetSalesReport provides a mock function with given fields: ctx, filter
+func (_m *MockOrderUseCase) GetSalesReport(ctx context.Context, filter models.SalesReportFilter) (models.SalesReport, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSalesReport")
+	}
+
+	var r0 models.SalesReport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.SalesReportFilter) (models.SalesReport, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.SalesReportFilter) models.SalesReport); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		r0 = ret.Get(0).(models.SalesReport)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.SalesReportFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockOrderUseCase_GetSalesReport_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSalesReport'
+type MockOrderUseCase_GetSalesReport_Call struct {
+	*mock.Call
+}
+
+// GetSalesReport is a helper method to define mock.On call
+//   - ctx context.Context
+//   - filter models.SalesReportFilter
+func (_e *MockOrderUseCase_Expecter) GetSalesReport(ctx interface{}, filter interface{}) *MockOrderUseCase_GetSalesReport_Call {
+	return &MockOrderUseCase_GetSalesReport_Call{Call: _e.mock.On("GetSalesReport", ctx, filter)}
+}
+
+func (_c *MockOrderUseCase_GetSalesReport_Call) Run(run func(ctx context.Context, filter models.SalesReportFilter)) *MockOrderUseCase_GetSalesReport_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.SalesReportFilter))
+	})
+	return _c
+}
+
+func (_c *MockOrderUseCase_GetSalesReport_Call) Return(_a0 models.SalesReport, _a1 error) *MockOrderUseCase_GetSalesReport_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockOrderUseCase_GetSalesReport_Call) RunAndReturn(run func(context.Context, models.SalesReportFilter) (models.SalesReport, error)) *MockOrderUseCase_GetSalesReport_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// HandleOrderApproval provides a mock function with given fields: ctx, eventId, change
+func (_m *MockOrderUseCase) HandleOrderApproval(ctx context.Context, eventId string, change models.StatusChange) error {
+	ret := _m.Called(ctx, eventId, change)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HandleOrderApproval")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.StatusChange) error); ok {
+		r0 = rf(ctx, eventId, change)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
 // MockOrderUseCase_HandleOrderApproval_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HandleOrderApproval'
@@ -34,30 +278,45 @@ type MockOrderUseCase_HandleOrderApproval_Call struct {
 
 // HandleOrderApproval is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *MockOrderUseCase_Expecter) HandleOrderApproval(ctx interface{}) *MockOrderUseCase_HandleOrderApproval_Call {
-	return &MockOrderUseCase_HandleOrderApproval_Call{Call: _e.mock.On("HandleOrderApproval", ctx)}
+//   - eventId string
+//   - change models.StatusChange
+func (_e *MockOrderUseCase_Expecter) HandleOrderApproval(ctx interface{}, eventId interface{}, change interface{}) *MockOrderUseCase_HandleOrderApproval_Call {
+	return &MockOrderUseCase_HandleOrderApproval_Call{Call: _e.mock.On("HandleOrderApproval", ctx, eventId, change)}
 }
 
-func (_c *MockOrderUseCase_HandleOrderApproval_Call) Run(run func(ctx context.Context)) *MockOrderUseCase_HandleOrderApproval_Call {
+func (_c *MockOrderUseCase_HandleOrderApproval_Call) Run(run func(ctx context.Context, eventId string, change models.StatusChange)) *MockOrderUseCase_HandleOrderApproval_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context))
+		run(args[0].(context.Context), args[1].(string), args[2].(models.StatusChange))
 	})
 	return _c
 }
 
-func (_c *MockOrderUseCase_HandleOrderApproval_Call) Return() *MockOrderUseCase_HandleOrderApproval_Call {
-	_c.Call.Return()
+func (_c *MockOrderUseCase_HandleOrderApproval_Call) Return(_a0 error) *MockOrderUseCase_HandleOrderApproval_Call {
+	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockOrderUseCase_HandleOrderApproval_Call) RunAndReturn(run func(context.Context)) *MockOrderUseCase_HandleOrderApproval_Call {
+func (_c *MockOrderUseCase_HandleOrderApproval_Call) RunAndReturn(run func(context.Context, string, models.StatusChange) error) *MockOrderUseCase_HandleOrderApproval_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// HandleOrderRejection provides a mock function with given fields: ctx
-func (_m *MockOrderUseCase) HandleOrderRejection(ctx context.Context) {
-	_m.Called(ctx)
+// HandleOrderRejection provides a mock function with given fields: ctx, eventId, change
+func (_m *MockOrderUseCase) HandleOrderRejection(ctx context.Context, eventId string, change models.StatusChange) error {
+	ret := _m.Called(ctx, eventId, change)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HandleOrderRejection")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.StatusChange) error); ok {
+		r0 = rf(ctx, eventId, change)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
 // MockOrderUseCase_HandleOrderRejection_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HandleOrderRejection'
@@ -67,30 +326,89 @@ type MockOrderUseCase_HandleOrderRejection_Call struct {
 
 // HandleOrderRejection is a helper method to define mock.On call
 //   - ctx context.Context
-func (_e *MockOrderUseCase_Expecter) HandleOrderRejection(ctx interface{}) *MockOrderUseCase_HandleOrderRejection_Call {
-	return &MockOrderUseCase_HandleOrderRejection_Call{Call: _e.mock.On("HandleOrderRejection", ctx)}
+//   - eventId string
+//   - change models.StatusChange
+func (_e *MockOrderUseCase_Expecter) HandleOrderRejection(ctx interface{}, eventId interface{}, change interface{}) *MockOrderUseCase_HandleOrderRejection_Call {
+	return &MockOrderUseCase_HandleOrderRejection_Call{Call: _e.mock.On("HandleOrderRejection", ctx, eventId, change)}
 }
 
-func (_c *MockOrderUseCase_HandleOrderRejection_Call) Run(run func(ctx context.Context)) *MockOrderUseCase_HandleOrderRejection_Call {
+func (_c *MockOrderUseCase_HandleOrderRejection_Call) Run(run func(ctx context.Context, eventId string, change models.StatusChange)) *MockOrderUseCase_HandleOrderRejection_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context))
+		run(args[0].(context.Context), args[1].(string), args[2].(models.StatusChange))
 	})
 	return _c
 }
 
-func (_c *MockOrderUseCase_HandleOrderRejection_Call) Return() *MockOrderUseCase_HandleOrderRejection_Call {
-	_c.Call.Return()
+func (_c *MockOrderUseCase_HandleOrderRejection_Call) Return(_a0 error) *MockOrderUseCase_HandleOrderRejection_Call {
+	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *MockOrderUseCase_HandleOrderRejection_Call) RunAndReturn(run func(context.Context)) *MockOrderUseCase_HandleOrderRejection_Call {
+func (_c *MockOrderUseCase_HandleOrderRejection_Call) RunAndReturn(run func(context.Context, string, models.StatusChange) error) *MockOrderUseCase_HandleOrderRejection_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// PlaceOrder provides a mock function with given fields: ctx, order
-func (_m *MockOrderUseCase) PlaceOrder(ctx context.Context, order models.Order) (models.Order, error) {
-	ret := _m.Called(ctx, order)
+// ListOrders provides a mock function with given fields: ctx, filter
+func (_m *MockOrderUseCase) ListOrders(ctx context.Context, filter models.OrderFilter) (models.OrderPage, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListOrders")
+	}
+
+	var r0 models.OrderPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.OrderFilter) (models.OrderPage, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.OrderFilter) models.OrderPage); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		r0 = ret.Get(0).(models.OrderPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.OrderFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockOrderUseCase_ListOrders_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListOrders'
+type MockOrderUseCase_ListOrders_Call struct {
+	*mock.Call
+}
+
+// ListOrders is a helper method to define mock.On call
+//   - ctx context.Context
+//   - filter models.OrderFilter
+func (_e *MockOrderUseCase_Expecter) ListOrders(ctx interface{}, filter interface{}) *MockOrderUseCase_ListOrders_Call {
+	return &MockOrderUseCase_ListOrders_Call{Call: _e.mock.On("ListOrders", ctx, filter)}
+}
+
+func (_c *MockOrderUseCase_ListOrders_Call) Run(run func(ctx context.Context, filter models.OrderFilter)) *MockOrderUseCase_ListOrders_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(models.OrderFilter))
+	})
+	return _c
+}
+
+func (_c *MockOrderUseCase_ListOrders_Call) Return(_a0 models.OrderPage, _a1 error) *MockOrderUseCase_ListOrders_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockOrderUseCase_ListOrders_Call) RunAndReturn(run func(context.Context, models.OrderFilter) (models.OrderPage, error)) *MockOrderUseCase_ListOrders_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PlaceOrder provides a mock function with given fields: ctx, order, idempotencyKey
+func (_m *MockOrderUseCase) PlaceOrder(ctx context.Context, order models.Order, idempotencyKey string) (models.Order, error) {
+	ret := _m.Called(ctx, order, idempotencyKey)
 
 	if len(ret) == 0 {
 		panic("no return value specified for PlaceOrder")
@@ -98,17 +416,17 @@ func (_m *MockOrderUseCase) PlaceOrder(ctx context.Context, order models.Order)
 
 	var r0 models.Order
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, models.Order) (models.Order, error)); ok {
-		return rf(ctx, order)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Order, string) (models.Order, error)); ok {
+		return rf(ctx, order, idempotencyKey)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, models.Order) models.Order); ok {
-		r0 = rf(ctx, order)
+	if rf, ok := ret.Get(0).(func(context.Context, models.Order, string) models.Order); ok {
+		r0 = rf(ctx, order, idempotencyKey)
 	} else {
 		r0 = ret.Get(0).(models.Order)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, models.Order) error); ok {
-		r1 = rf(ctx, order)
+	if rf, ok := ret.Get(1).(func(context.Context, models.Order, string) error); ok {
+		r1 = rf(ctx, order, idempotencyKey)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -124,13 +442,14 @@ type MockOrderUseCase_PlaceOrder_Call struct {
 // PlaceOrder is a helper method to define mock.On call
 //   - ctx context.Context
 //   - order models.Order
-func (_e *MockOrderUseCase_Expecter) PlaceOrder(ctx interface{}, order interface{}) *MockOrderUseCase_PlaceOrder_Call {
-	return &MockOrderUseCase_PlaceOrder_Call{Call: _e.mock.On("PlaceOrder", ctx, order)}
+//   - idempotencyKey string
+func (_e *MockOrderUseCase_Expecter) PlaceOrder(ctx interface{}, order interface{}, idempotencyKey interface{}) *MockOrderUseCase_PlaceOrder_Call {
+	return &MockOrderUseCase_PlaceOrder_Call{Call: _e.mock.On("PlaceOrder", ctx, order, idempotencyKey)}
 }
 
-func (_c *MockOrderUseCase_PlaceOrder_Call) Run(run func(ctx context.Context, order models.Order)) *MockOrderUseCase_PlaceOrder_Call {
+func (_c *MockOrderUseCase_PlaceOrder_Call) Run(run func(ctx context.Context, order models.Order, idempotencyKey string)) *MockOrderUseCase_PlaceOrder_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(models.Order))
+		run(args[0].(context.Context), args[1].(models.Order), args[2].(string))
 	})
 	return _c
 }
@@ -140,100 +459,146 @@ func (_c *MockOrderUseCase_PlaceOrder_Call) Return(_a0 models.Order, _a1 error)
 	return _c
 }
 
-func (_c *MockOrderUseCase_PlaceOrder_Call) RunAndReturn(run func(context.Context, models.Order) (models.Order, error)) *MockOrderUseCase_PlaceOrder_Call {
+func (_c *MockOrderUseCase_PlaceOrder_Call) RunAndReturn(run func(context.Context, models.Order, string) (models.Order, error)) *MockOrderUseCase_PlaceOrder_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// PublishOrderCreatedEvent provides a mock function with given fields: ctx, order
-func (_m *MockOrderUseCase) PublishOrderCreatedEvent(ctx context.Context, order models.Order) {
-	_m.Called(ctx, order)
+// UpdateOrderStatus provides a mock function with given fields: ctx, change
+func (_m *MockOrderUseCase) UpdateOrderStatus(ctx context.Context, change models.StatusChange) (models.Order, error) {
+	ret := _m.Called(ctx, change)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateOrderStatus")
+	}
+
+	var r0 models.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.StatusChange) (models.Order, error)); ok {
+		return rf(ctx, change)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.StatusChange) models.Order); ok {
+		r0 = rf(ctx, change)
+	} else {
+		r0 = ret.Get(0).(models.Order)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.StatusChange) error); ok {
+		r1 = rf(ctx, change)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// MockOrderUseCase_PublishOrderCreatedEvent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PublishOrderCreatedEvent'
-type MockOrderUseCase_PublishOrderCreatedEvent_Call struct {
+// MockOrderUseCase_UpdateOrderStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateOrderStatus'
+type MockOrderUseCase_UpdateOrderStatus_Call struct {
 	*mock.Call
 }
 
-// PublishOrderCreatedEvent is a helper method to define mock.On call
+// UpdateOrderStatus is a helper method to define mock.On call
 //   - ctx context.Context
-//   - order models.Order
-func (_e *MockOrderUseCase_Expecter) PublishOrderCreatedEvent(ctx interface{}, order interface{}) *MockOrderUseCase_PublishOrderCreatedEvent_Call {
-	return &MockOrderUseCase_PublishOrderCreatedEvent_Call{Call: _e.mock.On("PublishOrderCreatedEvent", ctx, order)}
+//   - change models.StatusChange
+func (_e *MockOrderUseCase_Expecter) UpdateOrderStatus(ctx interface{}, change interface{}) *MockOrderUseCase_UpdateOrderStatus_Call {
+	return &MockOrderUseCase_UpdateOrderStatus_Call{Call: _e.mock.On("UpdateOrderStatus", ctx, change)}
 }
 
-func (_c *MockOrderUseCase_PublishOrderCreatedEvent_Call) Run(run func(ctx context.Context, order models.Order)) *MockOrderUseCase_PublishOrderCreatedEvent_Call {
+func (_c *MockOrderUseCase_UpdateOrderStatus_Call) Run(run func(ctx context.Context, change models.StatusChange)) *MockOrderUseCase_UpdateOrderStatus_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(models.Order))
+		run(args[0].(context.Context), args[1].(models.StatusChange))
 	})
 	return _c
 }
 
-func (_c *MockOrderUseCase_PublishOrderCreatedEvent_Call) Return() *MockOrderUseCase_PublishOrderCreatedEvent_Call {
-	_c.Call.Return()
+func (_c *MockOrderUseCase_UpdateOrderStatus_Call) Return(_a0 models.Order, _a1 error) *MockOrderUseCase_UpdateOrderStatus_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockOrderUseCase_PublishOrderCreatedEvent_Call) RunAndReturn(run func(context.Context, models.Order)) *MockOrderUseCase_PublishOrderCreatedEvent_Call {
+func (_c *MockOrderUseCase_UpdateOrderStatus_Call) RunAndReturn(run func(context.Context, models.StatusChange) (models.Order, error)) *MockOrderUseCase_UpdateOrderStatus_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// PublishOrderStatusChanged provides a mock function with given fields: ctx, order
-func (_m *MockOrderUseCase) PublishOrderStatusChanged(ctx context.Context, order models.Order) {
-	_m.Called(ctx, order)
+// WatchOrder provides a mock function with given fields: ctx, orderId
+func (_m *MockOrderUseCase) WatchOrder(ctx context.Context, orderId int64) (orders.OrderFeed, error) {
+	ret := _m.Called(ctx, orderId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WatchOrder")
+	}
+
+	var r0 orders.OrderFeed
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (orders.OrderFeed, error)); ok {
+		return rf(ctx, orderId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) orders.OrderFeed); ok {
+		r0 = rf(ctx, orderId)
+	} else {
+		r0 = ret.Get(0).(orders.OrderFeed)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, orderId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// MockOrderUseCase_PublishOrderStatusChanged_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PublishOrderStatusChanged'
-type MockOrderUseCase_PublishOrderStatusChanged_Call struct {
+// MockOrderUseCase_WatchOrder_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WatchOrder'
+type MockOrderUseCase_WatchOrder_Call struct {
 	*mock.Call
 }
 
-// PublishOrderStatusChanged is a helper method to define mock.On call
+// WatchOrder is a helper method to define mock.On call
 //   - ctx context.Context
-//   - order models.Order
-func (_e *MockOrderUseCase_Expecter) PublishOrderStatusChanged(ctx interface{}, order interface{}) *MockOrderUseCase_PublishOrderStatusChanged_Call {
-	return &MockOrderUseCase_PublishOrderStatusChanged_Call{Call: _e.mock.On("PublishOrderStatusChanged", ctx, order)}
+//   - orderId int64
+func (_e *MockOrderUseCase_Expecter) WatchOrder(ctx interface{}, orderId interface{}) *MockOrderUseCase_WatchOrder_Call {
+	return &MockOrderUseCase_WatchOrder_Call{Call: _e.mock.On("WatchOrder", ctx, orderId)}
 }
 
-func (_c *MockOrderUseCase_PublishOrderStatusChanged_Call) Run(run func(ctx context.Context, order models.Order)) *MockOrderUseCase_PublishOrderStatusChanged_Call {
+func (_c *MockOrderUseCase_WatchOrder_Call) Run(run func(ctx context.Context, orderId int64)) *MockOrderUseCase_WatchOrder_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(models.Order))
+		run(args[0].(context.Context), args[1].(int64))
 	})
 	return _c
 }
 
-func (_c *MockOrderUseCase_PublishOrderStatusChanged_Call) Return() *MockOrderUseCase_PublishOrderStatusChanged_Call {
-	_c.Call.Return()
+func (_c *MockOrderUseCase_WatchOrder_Call) Return(_a0 orders.OrderFeed, _a1 error) *MockOrderUseCase_WatchOrder_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockOrderUseCase_PublishOrderStatusChanged_Call) RunAndReturn(run func(context.Context, models.Order)) *MockOrderUseCase_PublishOrderStatusChanged_Call {
+func (_c *MockOrderUseCase_WatchOrder_Call) RunAndReturn(run func(context.Context, int64) (orders.OrderFeed, error)) *MockOrderUseCase_WatchOrder_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// UpdateOrderStatus provides a mock function with given fields: ctx, orderId, status
-func (_m *MockOrderUseCase) UpdateOrderStatus(ctx context.Context, orderId int64, status string) (models.Order, error) {
-	ret := _m.Called(ctx, orderId, status)
+// WatchRestaurantOrders provides a mock function with given fields: ctx, restaurantId
+func (_m *MockOrderUseCase) WatchRestaurantOrders(ctx context.Context, restaurantId int64) (orders.OrderFeed, error) {
+	ret := _m.Called(ctx, restaurantId)
 
 	if len(ret) == 0 {
-		panic("no return value specified for UpdateOrderStatus")
+		panic("no return value specified for WatchRestaurantOrders")
 	}
 
-	var r0 models.Order
+	var r0 orders.OrderFeed
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (models.Order, error)); ok {
-		return rf(ctx, orderId, status)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (orders.OrderFeed, error)); ok {
+		return rf(ctx, restaurantId)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64, string) models.Order); ok {
-		r0 = rf(ctx, orderId, status)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) orders.OrderFeed); ok {
+		r0 = rf(ctx, restaurantId)
 	} else {
-		r0 = ret.Get(0).(models.Order)
+		r0 = ret.Get(0).(orders.OrderFeed)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
-		r1 = rf(ctx, orderId, status)
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, restaurantId)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -241,32 +606,31 @@ func (_m *MockOrderUseCase) UpdateOrderStatus(ctx context.Context, orderId int64
 	return r0, r1
 }
 
-// MockOrderUseCase_UpdateOrderStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateOrderStatus'
-type MockOrderUseCase_UpdateOrderStatus_Call struct {
+// MockOrderUseCase_WatchRestaurantOrders_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WatchRestaurantOrders'
+type MockOrderUseCase_WatchRestaurantOrders_Call struct {
 	*mock.Call
 }
 
-// UpdateOrderStatus is a helper method to define mock.On call
+// WatchRestaurantOrders is a helper method to define mock.On call
 //   - ctx context.Context
-//   - orderId int64
-//   - status string
-func (_e *MockOrderUseCase_Expecter) UpdateOrderStatus(ctx interface{}, orderId interface{}, status interface{}) *MockOrderUseCase_UpdateOrderStatus_Call {
-	return &MockOrderUseCase_UpdateOrderStatus_Call{Call: _e.mock.On("UpdateOrderStatus", ctx, orderId, status)}
+//   - restaurantId int64
+func (_e *MockOrderUseCase_Expecter) WatchRestaurantOrders(ctx interface{}, restaurantId interface{}) *MockOrderUseCase_WatchRestaurantOrders_Call {
+	return &MockOrderUseCase_WatchRestaurantOrders_Call{Call: _e.mock.On("WatchRestaurantOrders", ctx, restaurantId)}
 }
 
-func (_c *MockOrderUseCase_UpdateOrderStatus_Call) Run(run func(ctx context.Context, orderId int64, status string)) *MockOrderUseCase_UpdateOrderStatus_Call {
+func (_c *MockOrderUseCase_WatchRestaurantOrders_Call) Run(run func(ctx context.Context, restaurantId int64)) *MockOrderUseCase_WatchRestaurantOrders_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].(int64), args[2].(string))
+		run(args[0].(context.Context), args[1].(int64))
 	})
 	return _c
 }
 
-func (_c *MockOrderUseCase_UpdateOrderStatus_Call) Return(_a0 models.Order, _a1 error) *MockOrderUseCase_UpdateOrderStatus_Call {
+func (_c *MockOrderUseCase_WatchRestaurantOrders_Call) Return(_a0 orders.OrderFeed, _a1 error) *MockOrderUseCase_WatchRestaurantOrders_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockOrderUseCase_UpdateOrderStatus_Call) RunAndReturn(run func(context.Context, int64, string) (models.Order, error)) *MockOrderUseCase_UpdateOrderStatus_Call {
+func (_c *MockOrderUseCase_WatchRestaurantOrders_Call) RunAndReturn(run func(context.Context, int64) (orders.OrderFeed, error)) *MockOrderUseCase_WatchRestaurantOrders_Call {
 	_c.Call.Return(run)
 	return _c
 }
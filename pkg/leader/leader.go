@@ -0,0 +1,62 @@
+package leader
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Lease
+// the ownership of a lock. Lost is closed once the lease no longer holds, because it was released or the holder lost it,
+// e.g. its db session died
+type Lease interface {
+	Lost() <-chan struct{}
+	Release() error
+}
+
+// Locker
+// named locks held by at most one replica at a time
+type Locker interface {
+	// TryAcquire returns the lease of the lock when no one else holds it, without waiting for it
+	TryAcquire(ctx context.Context, name string) (Lease, bool, error)
+}
+
+// RunAsLeader
+// runs job on the replica holding the named lock. Replicas that do not hold it try again every retryInterval,
+// the context of the job is cancelled once the lease is lost, and the lease is released when job returns.
+// Blocks until the context is cancelled
+func RunAsLeader(ctx context.Context, locker Locker, name string, retryInterval time.Duration, job func(ctx context.Context)) {
+	for ctx.Err() == nil {
+		lease, acquired, err := locker.TryAcquire(ctx, name)
+		if err != nil {
+			log.Printf("failed to acquire lock %v, err: %v\n", name, err)
+		}
+		if acquired {
+			lead(ctx, lease, name, job)
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+func lead(ctx context.Context, lease Lease, name string, job func(ctx context.Context)) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-lease.Lost():
+			if ctx.Err() == nil {
+				log.Printf("lost lock %v, stopping its job\n", name)
+			}
+			cancel()
+		case <-jobCtx.Done():
+		}
+	}()
+	job(jobCtx)
+	cancel()
+	if err := lease.Release(); err != nil {
+		log.Printf("failed to release lock %v, err: %v\n", name, err)
+	}
+}
@@ -0,0 +1,106 @@
+package leader_test
+
+import (
+	"context"
+	"github.com/nawafswe/orders-service/pkg/leader"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInMemoryLocker(t *testing.T) {
+	locker := leader.NewInMemory()
+	lease, acquired, err := locker.TryAcquire(context.Background(), "expiry")
+	if err != nil || !acquired {
+		t.Fatalf("expected the free lock to be acquired, but got %v, err: %v", acquired, err)
+	}
+	if _, acquired, _ := locker.TryAcquire(context.Background(), "expiry"); acquired {
+		t.Errorf("expected a held lock not to be acquired twice")
+	}
+	if _, acquired, _ := locker.TryAcquire(context.Background(), "outbox"); !acquired {
+		t.Errorf("expected locks to be independent of each other")
+	}
+	if err := lease.Release(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	select {
+	case <-lease.Lost():
+	default:
+		t.Errorf("expected a released lease to be lost")
+	}
+	if _, acquired, _ := locker.TryAcquire(context.Background(), "expiry"); !acquired {
+		t.Errorf("expected a released lock to be acquired again")
+	}
+}
+
+func TestInMemoryLockerExpire(t *testing.T) {
+	locker := leader.NewInMemory()
+	lease, _, _ := locker.TryAcquire(context.Background(), "expiry")
+	locker.Expire("expiry")
+	select {
+	case <-lease.Lost():
+	default:
+		t.Fatalf("expected an expired lease to be lost")
+	}
+	next, acquired, _ := locker.TryAcquire(context.Background(), "expiry")
+	if !acquired {
+		t.Fatalf("expected an expired lock to be acquired by someone else")
+	}
+	// the former holder releasing late must not free the lock of the new one
+	_ = lease.Release()
+	if !locker.Held("expiry") {
+		t.Errorf("expected the lock to stay with its new holder")
+	}
+	_ = next.Release()
+}
+
+func TestRunAsLeader(t *testing.T) {
+	locker := leader.NewInMemory()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	leaders := make(chan string, 10)
+	stopped := make(chan string, 10)
+	job := func(replica string) func(ctx context.Context) {
+		return func(ctx context.Context) {
+			leaders <- replica
+			<-ctx.Done()
+			stopped <- replica
+		}
+	}
+	var wg sync.WaitGroup
+	for _, replica := range []string{"replica-1", "replica-2"} {
+		wg.Add(1)
+		go func(replica string) {
+			defer wg.Done()
+			leader.RunAsLeader(ctx, locker, "expiry", 10*time.Millisecond, job(replica))
+		}(replica)
+	}
+
+	first := <-leaders
+	select {
+	case second := <-leaders:
+		t.Fatalf("expected a single leader, but %v runs alongside %v", second, first)
+	case <-time.After(100 * time.Millisecond):
+	}
+	// the leader's lease lapses, its job is stopped and a replica takes over
+	locker.Expire("expiry")
+	select {
+	case replica := <-stopped:
+		if replica != first {
+			t.Errorf("expected the job of %v to be stopped, but %v was", first, replica)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected the job of %v to be stopped once it lost the lock", first)
+	}
+	select {
+	case <-leaders:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected a new leader after %v lost the lock", first)
+	}
+	cancel()
+	wg.Wait()
+	if locker.Held("expiry") {
+		t.Errorf("expected the lock to be released once every replica stopped")
+	}
+}
@@ -0,0 +1,74 @@
+package leader
+
+import (
+	"context"
+	"sync"
+)
+
+// InMemoryLocker
+// locks shared by the lockers of a single process, for tests and running locally
+type InMemoryLocker struct {
+	mu     sync.Mutex
+	leases map[string]*memoryLease
+}
+
+func NewInMemory() *InMemoryLocker {
+	return &InMemoryLocker{leases: make(map[string]*memoryLease)}
+}
+
+func (m *InMemoryLocker) TryAcquire(_ context.Context, name string) (Lease, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, held := m.leases[name]; held {
+		return nil, false, nil
+	}
+	lease := &memoryLease{locker: m, name: name, lost: make(chan struct{})}
+	m.leases[name] = lease
+	return lease, true, nil
+}
+
+// Expire
+// takes the lock away from its holder as if its lease had lapsed
+func (m *InMemoryLocker) Expire(name string) {
+	m.mu.Lock()
+	lease, held := m.leases[name]
+	delete(m.leases, name)
+	m.mu.Unlock()
+	if held {
+		lease.markLost()
+	}
+}
+
+// Held
+// whether someone holds the lock
+func (m *InMemoryLocker) Held(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, held := m.leases[name]
+	return held
+}
+
+type memoryLease struct {
+	locker *InMemoryLocker
+	name   string
+	lost   chan struct{}
+	once   sync.Once
+}
+
+func (l *memoryLease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+func (l *memoryLease) Release() error {
+	l.locker.mu.Lock()
+	if l.locker.leases[l.name] == l {
+		delete(l.locker.leases, l.name)
+	}
+	l.locker.mu.Unlock()
+	l.markLost()
+	return nil
+}
+
+func (l *memoryLease) markLost() {
+	l.once.Do(func() { close(l.lost) })
+}
@@ -0,0 +1,130 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"gorm.io/gorm"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+)
+
+// PostgresLocker
+// session level advisory locks. A lease holds a connection of the pool for as long as it lasts, the lock is released
+// by postgres when that session ends, so a crashed replica cannot keep it. The session is pinged every renewInterval
+// and the lease is lost as soon as a ping fails
+type PostgresLocker struct {
+	db            *gorm.DB
+	renewInterval time.Duration
+}
+
+func NewPostgres(db *gorm.DB, renewInterval time.Duration) Locker {
+	return PostgresLocker{db: db, renewInterval: renewInterval}
+}
+
+// lockKey
+// advisory locks are keyed by a bigint, the name is hashed into one
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+func (p PostgresLocker) TryAcquire(ctx context.Context, name string) (Lease, bool, error) {
+	sqlDB, err := p.db.DB()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get the db connection pool, err: %w", err)
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get a db connection for lock %v, err: %w", name, err)
+	}
+	key := lockKey(name)
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		_ = conn.Close()
+		return nil, false, fmt.Errorf("failed to try lock %v, err: %w", name, err)
+	}
+	if !acquired {
+		_ = conn.Close()
+		return nil, false, nil
+	}
+	lease := &postgresLease{
+		conn: conn,
+		name: name,
+		key:  key,
+		lost: make(chan struct{}),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go lease.renew(p.renewInterval)
+	return lease, true, nil
+}
+
+type postgresLease struct {
+	conn     *sql.Conn
+	name     string
+	key      int64
+	lost     chan struct{}
+	lostOnce sync.Once
+	// stop ends renew, which closes done once it returned
+	stop        chan struct{}
+	done        chan struct{}
+	releaseOnce sync.Once
+}
+
+func (l *postgresLease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// renew
+// pings the session holding the lock until the lease is released or a ping fails
+func (l *postgresLease) renew(interval time.Duration) {
+	defer close(l.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			_, err := l.conn.ExecContext(ctx, "SELECT 1")
+			cancel()
+			if err != nil {
+				log.Printf("lost the session holding lock %v, err: %v\n", l.name, err)
+				l.markLost()
+				return
+			}
+		}
+	}
+}
+
+// Release
+// unlocks and returns the connection to the pool, the session is closed instead when unlocking fails so the lock is freed anyway
+func (l *postgresLease) Release() error {
+	var err error
+	l.releaseOnce.Do(func() {
+		close(l.stop)
+		<-l.done
+		l.markLost()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, unlockErr := l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key); unlockErr != nil {
+			err = fmt.Errorf("failed to unlock %v, err: %w", l.name, unlockErr)
+			// a bad connection is discarded instead of going back to the pool, ending the session and its lock
+			_ = l.conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		if closeErr := l.conn.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close the connection of lock %v, err: %w", l.name, closeErr)
+		}
+	})
+	return err
+}
+
+func (l *postgresLease) markLost() {
+	l.lostOnce.Do(func() { close(l.lost) })
+}
@@ -0,0 +1,146 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"strings"
+	"time"
+)
+
+// CloudEvents binary mode, the context attributes travel as message attributes prefixed with ce- and the data is the message body
+const (
+	CloudEventsSpecVersion = "1.0"
+	ProtobufContentType    = "application/protobuf"
+
+	ceAttrPrefix      = "ce-"
+	ceIdAttr          = "ce-id"
+	ceSourceAttr      = "ce-source"
+	ceTypeAttr        = "ce-type"
+	ceSpecVersionAttr = "ce-specversion"
+	ceTimeAttr        = "ce-time"
+	ceSubjectAttr     = "ce-subject"
+	ceContentTypeAttr = "content-type"
+	ceDataSchemaAttr  = "ce-dataschema"
+)
+
+// CloudEvent
+// the context attributes of a CloudEvents 1.0 event along with its data
+type CloudEvent struct {
+	ID              string
+	Source          string
+	Type            string
+	SpecVersion     string
+	Time            time.Time
+	DataContentType string
+	DataSchema      string
+	Subject         string
+	// Extensions are the other ce- attributes, keyed without the prefix
+	Extensions map[string]string
+	Data       []byte
+}
+
+// Attributes
+// the binary mode attributes of the event, merged with transport attributes such as correlation-id
+func (e CloudEvent) Attributes(transport map[string]string) map[string]string {
+	attributes := make(map[string]string, len(transport)+len(e.Extensions)+8)
+	for k, v := range transport {
+		attributes[k] = v
+	}
+	for k, v := range e.Extensions {
+		attributes[ceAttrPrefix+k] = v
+	}
+	attributes[ceIdAttr] = e.ID
+	attributes[ceSourceAttr] = e.Source
+	attributes[ceTypeAttr] = e.Type
+	attributes[ceSpecVersionAttr] = e.SpecVersion
+	if !e.Time.IsZero() {
+		attributes[ceTimeAttr] = e.Time.UTC().Format(time.RFC3339Nano)
+	}
+	if e.Subject != "" {
+		attributes[ceSubjectAttr] = e.Subject
+	}
+	if e.DataContentType != "" {
+		attributes[ceContentTypeAttr] = e.DataContentType
+	}
+	if e.DataSchema != "" {
+		attributes[ceDataSchemaAttr] = e.DataSchema
+	}
+	return attributes
+}
+
+// IsCloudEvent
+// whether the message carries any of the required binary mode attributes, a message with only some of them is still an event and fails to decode.
+// Extensions do not count, Upcast sets the schema version extension on every message
+func IsCloudEvent(msg *Message) bool {
+	for _, attr := range []string{ceIdAttr, ceSourceAttr, ceTypeAttr, ceSpecVersionAttr} {
+		if _, ok := msg.Attributes[attr]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// DecodeCloudEvent
+// reads a binary mode event from the message, failing when a required attribute is missing or the spec version is unsupported
+func DecodeCloudEvent(msg *Message) (CloudEvent, error) {
+	e := CloudEvent{
+		ID:              msg.Attributes[ceIdAttr],
+		Source:          msg.Attributes[ceSourceAttr],
+		Type:            msg.Attributes[ceTypeAttr],
+		SpecVersion:     msg.Attributes[ceSpecVersionAttr],
+		DataContentType: msg.Attributes[ceContentTypeAttr],
+		DataSchema:      msg.Attributes[ceDataSchemaAttr],
+		Subject:         msg.Attributes[ceSubjectAttr],
+		Extensions:      make(map[string]string),
+		Data:            msg.Data,
+	}
+	for attr, v := range map[string]string{ceIdAttr: e.ID, ceSourceAttr: e.Source, ceTypeAttr: e.Type, ceSpecVersionAttr: e.SpecVersion} {
+		if v == "" {
+			return CloudEvent{}, fmt.Errorf("missing required cloudevents attribute %v", attr)
+		}
+	}
+	if e.SpecVersion != CloudEventsSpecVersion {
+		return CloudEvent{}, fmt.Errorf("unsupported cloudevents spec version %v", e.SpecVersion)
+	}
+	if t, ok := msg.Attributes[ceTimeAttr]; ok {
+		parsed, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return CloudEvent{}, fmt.Errorf("invalid cloudevents time %v, err: %w", t, err)
+		}
+		e.Time = parsed
+	}
+	for k, v := range msg.Attributes {
+		switch k {
+		case ceIdAttr, ceSourceAttr, ceTypeAttr, ceSpecVersionAttr, ceTimeAttr, ceSubjectAttr, ceDataSchemaAttr:
+			continue
+		}
+		if strings.HasPrefix(k, ceAttrPrefix) {
+			e.Extensions[strings.TrimPrefix(k, ceAttrPrefix)] = v
+		}
+	}
+	return e, nil
+}
+
+// CloudEventHandler
+// decodes the CloudEvents envelope and its protobuf data into a new T before calling fn,
+// a message that is not a valid event or whose data cannot be decoded is dropped
+func CloudEventHandler[T any, PT interface {
+	*T
+	proto.Message
+}](fn func(ctx context.Context, event CloudEvent, payload PT) error) Handler {
+	return func(ctx context.Context, msg *Message) error {
+		event, err := DecodeCloudEvent(msg)
+		if err != nil {
+			return Drop(fmt.Errorf("invalid cloudevent, err: %w", err))
+		}
+		if event.DataContentType != "" && event.DataContentType != ProtobufContentType {
+			return Drop(fmt.Errorf("unsupported data content type %v of event %v", event.DataContentType, event.ID))
+		}
+		payload := PT(new(T))
+		if err := proto.Unmarshal(event.Data, payload); err != nil {
+			return Drop(fmt.Errorf("failed to unmarshal %T of event %v, err: %w", payload, event.ID, err))
+		}
+		return fn(ctx, event, payload)
+	}
+}
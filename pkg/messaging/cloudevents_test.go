@@ -0,0 +1,142 @@
+package messaging_test
+
+import (
+	"context"
+	"errors"
+	"github.com/nawafswe/orders-service/pkg/messaging"
+	pb "github.com/nawafswe/orders-service/proto"
+	"google.golang.org/protobuf/proto"
+	"testing"
+	"time"
+)
+
+func orderStatusEvent() messaging.CloudEvent {
+	return messaging.CloudEvent{
+		ID:              "evt-1",
+		Source:          "/orders-service",
+		Type:            "com.nawafswe.orders.order.status_changed",
+		SpecVersion:     messaging.CloudEventsSpecVersion,
+		Time:            time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+		DataContentType: messaging.ProtobufContentType,
+		Subject:         "1",
+		Extensions:      map[string]string{"traceparent": "00-abc-def-01"},
+	}
+}
+
+func TestCloudEventRoundTrip(t *testing.T) {
+	expected := orderStatusEvent()
+	attributes := expected.Attributes(map[string]string{"correlation-id": "abc"})
+	if attributes["correlation-id"] != "abc" || attributes["ce-id"] != "evt-1" || attributes["ce-subject"] != "1" || attributes["ce-traceparent"] != "00-abc-def-01" {
+		t.Fatalf("unexpected binary mode attributes %v", attributes)
+	}
+	event, err := messaging.DecodeCloudEvent(&messaging.Message{Data: []byte("1"), Attributes: attributes})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if event.ID != expected.ID || event.Source != expected.Source || event.Type != expected.Type || event.Subject != expected.Subject ||
+		event.DataContentType != expected.DataContentType || !event.Time.Equal(expected.Time) || string(event.Data) != "1" {
+		t.Errorf("expected %+v, but got %+v", expected, event)
+	}
+	if len(event.Extensions) != 1 || event.Extensions["traceparent"] != "00-abc-def-01" {
+		t.Errorf("expected only the traceparent extension, but got %v", event.Extensions)
+	}
+}
+
+func TestDecodeInvalidCloudEvent(t *testing.T) {
+	tests := map[string]func(attributes map[string]string){
+		"missing id":               func(a map[string]string) { delete(a, "ce-id") },
+		"missing source":           func(a map[string]string) { delete(a, "ce-source") },
+		"missing type":             func(a map[string]string) { delete(a, "ce-type") },
+		"missing spec version":     func(a map[string]string) { delete(a, "ce-specversion") },
+		"unsupported spec version": func(a map[string]string) { a["ce-specversion"] = "0.3" },
+		"invalid time":             func(a map[string]string) { a["ce-time"] = "yesterday" },
+	}
+	for name, corrupt := range tests {
+		t.Run(name, func(t *testing.T) {
+			attributes := orderStatusEvent().Attributes(nil)
+			corrupt(attributes)
+			if _, err := messaging.DecodeCloudEvent(&messaging.Message{Attributes: attributes}); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestCloudEventHandler(t *testing.T) {
+	data, _ := proto.Marshal(&pb.OrderStatus{OrderId: 1, Status: "Approved"})
+	tests := map[string]struct {
+		Attributes   map[string]string
+		Data         []byte
+		ExpectedDrop bool
+	}{
+		"decodes the event and its data": {
+			Attributes: orderStatusEvent().Attributes(nil),
+			Data:       data,
+		},
+		"drops a message without an envelope": {
+			Attributes:   map[string]string{"event-id": "evt-1"},
+			Data:         data,
+			ExpectedDrop: true,
+		},
+		"drops an unsupported content type": {
+			Attributes: func() map[string]string {
+				e := orderStatusEvent()
+				e.DataContentType = "application/json"
+				return e.Attributes(nil)
+			}(),
+			Data:         []byte(`{"orderId":1}`),
+			ExpectedDrop: true,
+		},
+		"drops undecodable data": {
+			Attributes:   orderStatusEvent().Attributes(nil),
+			Data:         []byte{0xff},
+			ExpectedDrop: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			called := false
+			h := messaging.CloudEventHandler(func(ctx context.Context, event messaging.CloudEvent, status *pb.OrderStatus) error {
+				called = true
+				if event.ID != "evt-1" || status.OrderId != 1 || status.Status != "Approved" {
+					t.Errorf("unexpected event %+v with %v", event, status)
+				}
+				return nil
+			})
+			err := h(context.Background(), &messaging.Message{ID: "msg-1", Data: test.Data, Attributes: test.Attributes})
+			var dropErr messaging.DropErr
+			if errors.As(err, &dropErr) != test.ExpectedDrop {
+				t.Errorf("expected drop %v, but got %v", test.ExpectedDrop, err)
+			}
+			if called == test.ExpectedDrop {
+				t.Errorf("expected the handler to be called %v", !test.ExpectedDrop)
+			}
+		})
+	}
+}
+
+func TestEventIdPrefersCloudEventId(t *testing.T) {
+	msg := &messaging.Message{ID: "msg-1", Attributes: map[string]string{"ce-id": "evt-1", "event-id": "evt-2"}}
+	if id := messaging.EventId(msg); id != "evt-1" {
+		t.Errorf("expected evt-1, but got %v", id)
+	}
+}
+
+func TestIsCloudEvent(t *testing.T) {
+	tests := map[string]struct {
+		Attributes map[string]string
+		Expected   bool
+	}{
+		"event":                      {Attributes: orderStatusEvent().Attributes(nil), Expected: true},
+		"event missing its id":       {Attributes: map[string]string{"ce-specversion": "1.0"}, Expected: true},
+		"legacy message":             {Attributes: map[string]string{"event-id": "evt-1"}},
+		"legacy message with schema": {Attributes: map[string]string{"event-id": "evt-1", messaging.SchemaVersionAttr: "1"}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := messaging.IsCloudEvent(&messaging.Message{Attributes: test.Attributes}); got != test.Expected {
+				t.Errorf("expected %v, but got %v", test.Expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"log"
+	"sync"
+)
+
+// Handler
+// processes a message received on a subscription, the message is acked when it returns nil and nacked otherwise
+type Handler func(ctx context.Context, msg *Message) error
+
+// Middleware
+// wraps the handler of the given subscription, e.g. to log or trace every message
+type Middleware func(subscription string, next Handler) Handler
+
+// DropErr
+// a failure that redelivering the message can never fix, the message is dead-lettered right away instead of retried
+type DropErr struct {
+	Err error
+}
+
+func (d DropErr) Error() string {
+	return fmt.Sprintf("message dropped: %v", d.Err)
+}
+
+func (d DropErr) Unwrap() error {
+	return d.Err
+}
+
+func Drop(err error) error {
+	return DropErr{Err: err}
+}
+
+// SkipErr
+// a valid message that no longer applies, e.g. an illegal status transition of an order that moved on, it is acked without being retried or dead-lettered
+type SkipErr struct {
+	Err error
+}
+
+func (s SkipErr) Error() string {
+	return fmt.Sprintf("message skipped: %v", s.Err)
+}
+
+func (s SkipErr) Unwrap() error {
+	return s.Err
+}
+
+func Skip(err error) error {
+	return SkipErr{Err: err}
+}
+
+// ProtoHandler
+// decodes the message data into a new T before calling fn, a message that cannot be decoded is dropped
+func ProtoHandler[T any, PT interface {
+	*T
+	proto.Message
+}](fn func(ctx context.Context, msg *Message, payload PT) error) Handler {
+	return func(ctx context.Context, msg *Message) error {
+		payload := PT(new(T))
+		if err := proto.Unmarshal(msg.Data, payload); err != nil {
+			return Drop(fmt.Errorf("failed to unmarshal %T, err: %w", payload, err))
+		}
+		return fn(ctx, msg, payload)
+	}
+}
+
+// EventId
+// the cloudevents id, or an event-id attribute, stays the same across the publisher's own retries, the broker message id is used otherwise
+func EventId(msg *Message) string {
+	for _, attr := range []string{ceIdAttr, "event-id"} {
+		if id, ok := msg.Attributes[attr]; ok && id != "" {
+			return id
+		}
+	}
+	return msg.ID
+}
+
+// Outcome
+// what the consumer did with a message once its handler returned
+type Outcome int
+
+const (
+	Acked Outcome = iota
+	Nacked
+	DeadLettered
+)
+
+// Consumer
+// receives messages for every registered subscription and dispatches them through the middleware chain
+type Consumer struct {
+	ms          MessageService
+	middlewares []Middleware
+	handlers    map[string]Handler
+	policies    map[string]RetryPolicy
+	order       []string
+	attempts    *deliveryAttempts
+}
+
+// NewConsumer
+// the first middleware is the outermost one, it sees the message first and the handler result last
+func NewConsumer(ms MessageService, middlewares ...Middleware) *Consumer {
+	return &Consumer{
+		ms:          ms,
+		middlewares: middlewares,
+		handlers:    make(map[string]Handler),
+		policies:    make(map[string]RetryPolicy),
+		attempts:    newDeliveryAttempts(deliveryAttemptsTTL, maxDeliveryAttempts),
+	}
+}
+
+// Register
+// the subscription uses DefaultRetryPolicy until SetRetryPolicy is called for it
+func (c *Consumer) Register(subscription string, h Handler) {
+	if _, ok := c.handlers[subscription]; !ok {
+		c.order = append(c.order, subscription)
+		c.policies[subscription] = DefaultRetryPolicy(subscription)
+	}
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		h = c.middlewares[i](subscription, h)
+	}
+	c.handlers[subscription] = h
+}
+
+func (c *Consumer) SetRetryPolicy(subscription string, p RetryPolicy) {
+	c.policies[subscription] = p
+}
+
+// Subscriptions
+// the registered subscriptions in registration order
+func (c *Consumer) Subscriptions() []string {
+	return append([]string(nil), c.order...)
+}
+
+// ProvisionDeadLetters
+// creates the dead-letter topic of every registered subscription along with a subscription on it, so dead letters are kept until inspected
+func (c *Consumer) ProvisionDeadLetters() {
+	for _, subscription := range c.order {
+		topic := c.policies[subscription].DeadLetterTopic
+		if topic == "" {
+			continue
+		}
+		c.ms.CreateTopic(topic)
+		c.ms.CreateSub(topic+"-sub", topic)
+	}
+}
+
+// Dispatch
+// runs the registered handler of the subscription for a single message
+func (c *Consumer) Dispatch(ctx context.Context, subscription string, msg *Message) error {
+	h, ok := c.handlers[subscription]
+	if !ok {
+		return fmt.Errorf("no handler registered for subscription %v", subscription)
+	}
+	return h(ctx, msg)
+}
+
+// Process
+// dispatches the message and settles it: acked on success or when skipped, nacked for redelivery after the retry backoff on failure,
+// and published to the dead-letter topic when the failure is permanent or the retry policy is exhausted
+func (c *Consumer) Process(ctx context.Context, subscription string, msg Delivery) Outcome {
+	err := c.Dispatch(ctx, subscription, msg.Message)
+	var skipErr SkipErr
+	if err == nil || errors.As(err, &skipErr) {
+		c.attempts.done(subscription, msg.Message)
+		msg.Ack()
+		return Acked
+	}
+	policy := c.policies[subscription]
+	attempt := c.attempts.failed(subscription, msg.Message)
+	var dropErr DropErr
+	if !errors.As(err, &dropErr) && attempt < policy.MaxAttempts {
+		msg.NackWithDelay(policy.Backoff(attempt))
+		return Nacked
+	}
+	if policy.DeadLetterTopic == "" {
+		log.Printf("discarding message %v of subscription %v after %d attempts, err: %v\n", msg.ID, subscription, attempt, err)
+		c.attempts.done(subscription, msg.Message)
+		msg.Ack()
+		return Acked
+	}
+	if _, pubErr := c.ms.Publish(ctx, policy.DeadLetterTopic, deadLetterMessage(subscription, msg.Message, attempt, err)); pubErr != nil {
+		// keep the message on the subscription rather than losing it
+		log.Printf("failed to dead-letter message %v of subscription %v, err: %v\n", msg.ID, subscription, pubErr)
+		msg.Nack()
+		return Nacked
+	}
+	log.Printf("dead-lettered message %v of subscription %v to %v after %d attempts, err: %v\n", msg.ID, subscription, policy.DeadLetterTopic, attempt, err)
+	c.attempts.done(subscription, msg.Message)
+	msg.Ack()
+	return DeadLettered
+}
+
+// Start
+// receives messages on every registered subscription until the context is cancelled
+func (c *Consumer) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, subscription := range c.order {
+		wg.Add(1)
+		go func(subscription string) {
+			defer wg.Done()
+			if err := c.receive(ctx, subscription); err != nil {
+				log.Printf("stopped receiving messages for subscription %v, err: %v\n", subscription, err)
+			}
+		}(subscription)
+	}
+	wg.Wait()
+}
+
+func (c *Consumer) receive(ctx context.Context, subscription string) error {
+	log.Printf("===== starting to receive messages for subscription %v =====\n", subscription)
+	return c.ms.Subscribe(ctx, subscription, func(ctx context.Context, d Delivery) {
+		c.Process(ctx, subscription, d)
+	})
+}
@@ -0,0 +1,179 @@
+package messaging_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	loggerMock "github.com/nawafswe/orders-service/mocks/github.com/nawafswe/orders-service/pkg/logger"
+	messagesMock "github.com/nawafswe/orders-service/mocks/github.com/nawafswe/orders-service/pkg/messaging"
+	"github.com/nawafswe/orders-service/pkg/messaging"
+	pb "github.com/nawafswe/orders-service/proto"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/protobuf/proto"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConsumerDispatch(t *testing.T) {
+	data, _ := proto.Marshal(&pb.OrderStatus{OrderId: 7, Status: "Approved"})
+	tests := map[string]struct {
+		Msg          *messaging.Message
+		HandlerErr   error
+		ExpectedErr  bool
+		ExpectedDrop bool
+		Called       bool
+	}{
+		"handler succeeds": {
+			Msg:    &messaging.Message{ID: "1", Data: data},
+			Called: true,
+		},
+		"handler fails": {
+			Msg:         &messaging.Message{ID: "2", Data: data},
+			HandlerErr:  errors.New("db is down"),
+			ExpectedErr: true,
+			Called:      true,
+		},
+		"handler drops the message": {
+			Msg:          &messaging.Message{ID: "3", Data: data},
+			HandlerErr:   messaging.Drop(errors.New("illegal transition")),
+			ExpectedErr:  true,
+			ExpectedDrop: true,
+			Called:       true,
+		},
+		"undecodable payload is dropped": {
+			Msg:          &messaging.Message{ID: "4", Data: []byte{0xff, 0xff}},
+			ExpectedErr:  true,
+			ExpectedDrop: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			called := false
+			c := messaging.NewConsumer(messagesMock.NewMockMessageService(t))
+			c.Register("approveOrder", messaging.ProtoHandler(func(ctx context.Context, msg *messaging.Message, cmd *pb.OrderStatus) error {
+				called = true
+				if cmd.OrderId != 7 || cmd.Status != "Approved" {
+					t.Errorf("unexpected payload %v", cmd)
+				}
+				return test.HandlerErr
+			}))
+			err := c.Dispatch(context.Background(), "approveOrder", test.Msg)
+			if (err != nil) != test.ExpectedErr {
+				t.Errorf("expected error %v, but got %v", test.ExpectedErr, err)
+			}
+			var dropErr messaging.DropErr
+			if errors.As(err, &dropErr) != test.ExpectedDrop {
+				t.Errorf("expected drop %v, but got %v", test.ExpectedDrop, err)
+			}
+			if called != test.Called {
+				t.Errorf("expected handler called %v, but got %v", test.Called, called)
+			}
+		})
+	}
+}
+
+func TestConsumerDispatchUnknownSubscription(t *testing.T) {
+	c := messaging.NewConsumer(messagesMock.NewMockMessageService(t))
+	if err := c.Dispatch(context.Background(), "unknown", &messaging.Message{}); err == nil {
+		t.Errorf("expected an error for a subscription without handler")
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var calls []string
+	trace := func(name string) messaging.Middleware {
+		return func(subscription string, next messaging.Handler) messaging.Handler {
+			return func(ctx context.Context, msg *messaging.Message) error {
+				calls = append(calls, name+":"+subscription)
+				return next(ctx, msg)
+			}
+		}
+	}
+	c := messaging.NewConsumer(messagesMock.NewMockMessageService(t), trace("outer"), trace("inner"))
+	c.Register("rejectOrder", func(ctx context.Context, msg *messaging.Message) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+	if err := c.Dispatch(context.Background(), "rejectOrder", &messaging.Message{}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	expected := []string{"outer:rejectOrder", "inner:rejectOrder", "handler"}
+	if len(calls) != len(expected) {
+		t.Fatalf("expected calls %v, but got %v", expected, calls)
+	}
+	for i := range expected {
+		if calls[i] != expected[i] {
+			t.Errorf("expected calls %v, but got %v", expected, calls)
+		}
+	}
+}
+
+func TestWithCorrelationId(t *testing.T) {
+	tests := map[string]struct {
+		Attributes map[string]string
+		Expected   string
+	}{
+		"attribute is propagated":         {Attributes: map[string]string{"correlation-id": "abc"}, Expected: "abc"},
+		"missing attribute gets a new id": {Attributes: map[string]string{}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got string
+			h := messaging.WithCorrelationId()("approveOrder", func(ctx context.Context, msg *messaging.Message) error {
+				got, _ = ctx.Value("correlation-id").(string)
+				return nil
+			})
+			_ = h(context.Background(), &messaging.Message{Attributes: test.Attributes})
+			if got == "" || (test.Expected != "" && got != test.Expected) {
+				t.Errorf("expected correlation id %q, but got %q", test.Expected, got)
+			}
+		})
+	}
+}
+
+func TestWithRecovery(t *testing.T) {
+	l := loggerMock.NewMockLogger(t)
+	l.On("Error", mock.Anything, mock.Anything).Return()
+	h := messaging.WithRecovery(l)("approveOrder", func(ctx context.Context, msg *messaging.Message) error {
+		panic("boom")
+	})
+	if err := h(context.Background(), &messaging.Message{ID: "1"}); err == nil {
+		t.Errorf("expected panic to be converted into an error")
+	}
+}
+
+func TestWithMetrics(t *testing.T) {
+	metrics := messaging.NewConsumerMetrics()
+	results := []error{nil, nil, errors.New("failed"), messaging.Drop(errors.New("invalid"))}
+	i := 0
+	h := messaging.WithMetrics(metrics)("approveOrder", func(ctx context.Context, msg *messaging.Message) error {
+		err := results[i]
+		i++
+		return err
+	})
+	for range results {
+		_ = h(context.Background(), &messaging.Message{})
+	}
+	stats := metrics.Snapshot()["approveOrder"]
+	if stats.Processed != 2 || stats.Failed != 1 || stats.Dropped != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestConsumerMetricsServeHTTP(t *testing.T) {
+	metrics := messaging.NewConsumerMetrics()
+	h := messaging.WithMetrics(metrics)("approveOrder", func(ctx context.Context, msg *messaging.Message) error {
+		return messaging.Skip(errors.New("already approved"))
+	})
+	_ = h(context.Background(), &messaging.Message{})
+	res := httptest.NewRecorder()
+	metrics.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	var stats map[string]messaging.SubscriptionStats
+	if err := json.Unmarshal(res.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if res.Header().Get("Content-Type") != "application/json" || stats["approveOrder"].Skipped != 1 {
+		t.Errorf("expected the stats of approveOrder as json, but got %v", res.Body.String())
+	}
+}
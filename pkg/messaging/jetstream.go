@@ -0,0 +1,175 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"log"
+	"strconv"
+	"time"
+)
+
+// orderingKeyHeader
+// JetStream has no ordering keys, the key travels as a header so subscribers still see it
+const orderingKeyHeader = "ordering-key"
+
+type JetStreamConfig struct {
+	// Stream holds every topic of the service, topics are subjects under SubjectPrefix
+	Stream        string
+	SubjectPrefix string
+	// AckWait is how long the server waits for an ack before redelivering a message
+	AckWait time.Duration
+	// MaxDeliver caps the deliveries of a message on the server, it is a safety net behind the consumer retry policy
+	// which dead-letters a message before reaching it
+	MaxDeliver int
+}
+
+func DefaultJetStreamConfig() JetStreamConfig {
+	return JetStreamConfig{
+		Stream:        "ORDERS",
+		SubjectPrefix: "orders",
+		AckWait:       30 * time.Second,
+		MaxDeliver:    10,
+	}
+}
+
+// JetStreamMessageService
+// the NATS JetStream adapter. Topics are subjects of a single stream, subscriptions are durable pull consumers
+// filtered on the subject of their topic, and a nack with a delay is left to the server to redeliver
+type JetStreamMessageService struct {
+	nc  *nats.Conn
+	js  jetstream.JetStream
+	cfg JetStreamConfig
+}
+
+// NewJetStream
+// connects to the NATS server at url and makes sure the stream of the service exists
+func NewJetStream(ctx context.Context, url string, cfg JetStreamConfig) (MessageService, error) {
+	nc, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats at %v, err: %w", url, err)
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("failed to obtain a jetstream context, err: %w", err)
+	}
+	if _, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{Name: cfg.Stream, Subjects: []string{cfg.SubjectPrefix + ".>"}}); err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("failed to create stream %v, err: %w", cfg.Stream, err)
+	}
+	return JetStreamMessageService{nc: nc, js: js, cfg: cfg}, nil
+}
+
+func (j JetStreamMessageService) subject(topic string) string {
+	return j.cfg.SubjectPrefix + "." + topic
+}
+
+// CreateTopic
+// every topic is already a subject of the service stream
+func (j JetStreamMessageService) CreateTopic(string) {}
+
+func (j JetStreamMessageService) CreateSub(id string, topic string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := j.js.CreateOrUpdateConsumer(ctx, j.cfg.Stream, jetstream.ConsumerConfig{
+		Durable:       id,
+		FilterSubject: j.subject(topic),
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       j.cfg.AckWait,
+		MaxDeliver:    j.cfg.MaxDeliver,
+	})
+	if err != nil {
+		log.Fatalf("failed to create subscription: %v, err: %v\n", id, err)
+	}
+}
+
+// Publish
+// returns the stream sequence of the message, the event id (ce-id or event-id attribute) is used as the message id so the server drops duplicates
+func (j JetStreamMessageService) Publish(ctx context.Context, topic string, msg *Message) (string, error) {
+	ack, err := j.js.PublishMsg(ctx, toNats(j.subject(topic), msg))
+	if err != nil {
+		return "", fmt.Errorf("failed to publish message on topic %s, err: %w", topic, err)
+	}
+	return strconv.FormatUint(ack.Sequence, 10), nil
+}
+
+func (j JetStreamMessageService) PublishAsync(ctx context.Context, topic string, msg *Message) {
+	go func() {
+		if _, err := j.Publish(context.WithoutCancel(ctx), topic, msg); err != nil {
+			log.Printf("failed to publish message with ID: %v, err: %v\n", msg.ID, err)
+		}
+	}()
+}
+
+func (j JetStreamMessageService) Subscribe(ctx context.Context, id string, f func(ctx context.Context, d Delivery)) error {
+	consumer, err := j.js.Consumer(ctx, j.cfg.Stream, id)
+	if err != nil {
+		if errors.Is(err, jetstream.ErrConsumerNotFound) {
+			return fmt.Errorf("subscription:%v not found", id)
+		}
+		return fmt.Errorf("failed to get subscription %v, err: %w", id, err)
+	}
+	cc, err := consumer.Consume(func(m jetstream.Msg) {
+		msg, err := fromNats(m)
+		if err != nil {
+			log.Printf("failed to read metadata of a message of subscription %v, err: %v\n", id, err)
+			_ = m.Nak()
+			return
+		}
+		d := NewDelivery(msg, func() { _ = m.Ack() }, func() { _ = m.Nak() })
+		d.nackWithDelay = func(delay time.Duration) { _ = m.NakWithDelay(delay) }
+		f(ctx, d)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to consume subscription %v, err: %w", id, err)
+	}
+	<-ctx.Done()
+	cc.Stop()
+	return nil
+}
+
+// Close
+// drains the connection so pending acks reach the server
+func (j JetStreamMessageService) Close() error {
+	return j.nc.Drain()
+}
+
+func toNats(subject string, msg *Message) *nats.Msg {
+	m := nats.NewMsg(subject)
+	m.Data = msg.Data
+	for k, v := range msg.Attributes {
+		m.Header.Set(k, v)
+	}
+	if msg.OrderingKey != "" {
+		m.Header.Set(orderingKeyHeader, msg.OrderingKey)
+	}
+	if id := EventId(&Message{Attributes: msg.Attributes}); id != "" {
+		m.Header.Set(nats.MsgIdHdr, id)
+	}
+	return m
+}
+
+func fromNats(m jetstream.Msg) (*Message, error) {
+	meta, err := m.Metadata()
+	if err != nil {
+		return nil, err
+	}
+	attributes := make(map[string]string, len(m.Headers()))
+	for k := range m.Headers() {
+		if k == orderingKeyHeader || k == nats.MsgIdHdr {
+			continue
+		}
+		attributes[k] = m.Headers().Get(k)
+	}
+	return &Message{
+		ID:              strconv.FormatUint(meta.Sequence.Stream, 10),
+		Data:            m.Data(),
+		Attributes:      attributes,
+		OrderingKey:     m.Headers().Get(orderingKeyHeader),
+		PublishTime:     meta.Timestamp,
+		DeliveryAttempt: int(meta.NumDelivered),
+	}, nil
+}
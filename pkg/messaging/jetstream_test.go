@@ -0,0 +1,150 @@
+package messaging_test
+
+import (
+	"context"
+	"errors"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nawafswe/orders-service/pkg/messaging"
+	"sync"
+	"testing"
+	"time"
+)
+
+// runJetStream
+// starts an embedded NATS server with JetStream enabled and connects the adapter to it
+func runJetStream(t *testing.T, cfg messaging.JetStreamConfig) messaging.MessageService {
+	t.Helper()
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, JetStream: true, StoreDir: t.TempDir(), NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatalf("failed to create nats server, err: %v", err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatalf("nats server is not ready")
+	}
+	t.Cleanup(srv.Shutdown)
+	ms, err := messaging.NewJetStream(context.Background(), srv.ClientURL(), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	t.Cleanup(func() { _ = ms.Close() })
+	ms.CreateTopic("orderStatusChanged")
+	ms.CreateSub("notifications", "orderStatusChanged")
+	return ms
+}
+
+// collect
+// subscribes until n deliveries were received, handle settles every delivery
+func collect(t *testing.T, ms messaging.MessageService, subscription string, n int, handle func(ctx context.Context, d messaging.Delivery)) []*messaging.Message {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var mu sync.Mutex
+	var received []*messaging.Message
+	err := ms.Subscribe(ctx, subscription, func(ctx context.Context, d messaging.Delivery) {
+		mu.Lock()
+		received = append(received, d.Message)
+		done := len(received) == n
+		mu.Unlock()
+		handle(ctx, d)
+		if done {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != n {
+		t.Fatalf("expected %d deliveries on %v, but got %d", n, subscription, len(received))
+	}
+	return received
+}
+
+func TestJetStreamPublishSubscribe(t *testing.T) {
+	ms := runJetStream(t, messaging.DefaultJetStreamConfig())
+	id, err := ms.Publish(context.Background(), "orderStatusChanged", &messaging.Message{
+		Data:        []byte("1"),
+		Attributes:  map[string]string{"correlation-id": "abc"},
+		OrderingKey: "1",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	msg := collect(t, ms, "notifications", 1, func(_ context.Context, d messaging.Delivery) { d.Ack() })[0]
+	if msg.ID != id || string(msg.Data) != "1" || msg.OrderingKey != "1" || msg.DeliveryAttempt != 1 {
+		t.Errorf("unexpected message %+v", msg)
+	}
+	if len(msg.Attributes) != 1 || msg.Attributes["correlation-id"] != "abc" {
+		t.Errorf("expected the attributes to be kept as headers, but got %v", msg.Attributes)
+	}
+	if err := ms.Subscribe(context.Background(), "unknown", func(context.Context, messaging.Delivery) {}); err == nil {
+		t.Errorf("expected subscribing to an unknown subscription to fail")
+	}
+}
+
+func TestJetStreamNakWithDelay(t *testing.T) {
+	ms := runJetStream(t, messaging.DefaultJetStreamConfig())
+	_, _ = ms.Publish(context.Background(), "orderStatusChanged", &messaging.Message{Data: []byte("1")})
+	var nackedAt time.Time
+	received := collect(t, ms, "notifications", 2, func(ctx context.Context, d messaging.Delivery) {
+		if d.DeliveryAttempt == 1 {
+			nackedAt = time.Now()
+			d.NackWithDelay(200 * time.Millisecond)
+			return
+		}
+		d.Ack()
+	})
+	if received[1].DeliveryAttempt != 2 || received[1].ID != received[0].ID {
+		t.Errorf("expected the message to be redelivered, but got %+v", received[1])
+	}
+	if since := time.Since(nackedAt); since < 200*time.Millisecond {
+		t.Errorf("expected the redelivery to wait for the delay, but it came after %v", since)
+	}
+}
+
+func TestJetStreamMaxDeliver(t *testing.T) {
+	cfg := messaging.DefaultJetStreamConfig()
+	cfg.MaxDeliver = 2
+	ms := runJetStream(t, cfg)
+	_, _ = ms.Publish(context.Background(), "orderStatusChanged", &messaging.Message{Data: []byte("1")})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var mu sync.Mutex
+	deliveries := 0
+	_ = ms.Subscribe(ctx, "notifications", func(_ context.Context, d messaging.Delivery) {
+		mu.Lock()
+		deliveries++
+		mu.Unlock()
+		d.Nack()
+	})
+	if deliveries != 2 {
+		t.Errorf("expected the server to stop after 2 deliveries, but got %d", deliveries)
+	}
+}
+
+func TestJetStreamConsumerDeadLetters(t *testing.T) {
+	ms := runJetStream(t, messaging.DefaultJetStreamConfig())
+	ms.CreateTopic("notifications-dead-letter")
+	ms.CreateSub("notifications-dead-letter-sub", "notifications-dead-letter")
+	c := messaging.NewConsumer(ms)
+	c.Register("notifications", func(ctx context.Context, msg *messaging.Message) error {
+		return errors.New("db is down")
+	})
+	c.SetRetryPolicy("notifications", messaging.RetryPolicy{MaxAttempts: 2, MinBackoff: 10 * time.Millisecond, MaxBackoff: 10 * time.Millisecond, DeadLetterTopic: "notifications-dead-letter"})
+	_, _ = ms.Publish(context.Background(), "orderStatusChanged", &messaging.Message{Data: []byte("1")})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Start(ctx)
+	}()
+	deadLetter := collect(t, ms, "notifications-dead-letter-sub", 1, func(_ context.Context, d messaging.Delivery) { d.Ack() })[0]
+	cancel()
+	<-done
+	if string(deadLetter.Data) != "1" || deadLetter.Attributes[messaging.DeadLetterAttemptsAttr] != "2" {
+		t.Errorf("unexpected dead letter %+v", deadLetter)
+	}
+}
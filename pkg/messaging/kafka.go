@@ -0,0 +1,310 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// KafkaClient
+// the part of a kafka client the adapter needs, backed by kafka-go in production and by an in process fake in tests
+type KafkaClient interface {
+	// WriteMessages writes to the topic set on every message, messages with the same key land on the same partition
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	// Reader reads the topic as a member of the consumer group, starting from the offsets the group committed
+	Reader(topic string, groupId string) KafkaReader
+	CreateTopics(ctx context.Context, topics ...string) error
+	Close() error
+}
+
+type KafkaReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+type KafkaConfig struct {
+	// Topics maps the topic names used by the service to kafka topics, names without a mapping are used as is
+	Topics map[string]string
+	// ConsumerGroup is suffixed with the subscription, so every subscription is its own consumer group
+	// and the instances of the service share the messages of a subscription
+	ConsumerGroup string
+	// MinBackoff and MaxBackoff bound the delay before redelivering a message nacked without one, doubling on every attempt
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxDeliver caps the deliveries of a message, it is then committed and left behind. It is a safety net behind the consumer
+	// retry policy which dead-letters a message before reaching it, zero redelivers a message until it is acked
+	MaxDeliver int
+}
+
+func DefaultKafkaConfig() KafkaConfig {
+	return KafkaConfig{
+		Topics: map[string]string{
+			"orderCreated":       "orders.order-created",
+			"orderStatusChanged": "orders.order-status-changed",
+			"orderCancelled":     "orders.order-cancelled",
+			"approveOrder":       "orders.approve-order",
+			"rejectOrder":        "orders.reject-order",
+		},
+		ConsumerGroup: "orders-service",
+		MinBackoff:    time.Second,
+		MaxBackoff:    time.Minute,
+		MaxDeliver:    10,
+	}
+}
+
+// KafkaMessageService
+// the kafka adapter. Ordering keys become message keys so the events of an order share a partition,
+// attributes travel as headers and acking a message commits its offset.
+// Kafka has no subscriptions, a subscription is a consumer group reading the topic it was created on
+type KafkaMessageService struct {
+	client KafkaClient
+	cfg    KafkaConfig
+	mu     sync.Mutex
+	// subs the topic of every subscription, a subscription that was not created reads the topic of the same name
+	subs map[string]string
+}
+
+func NewKafka(client KafkaClient, cfg KafkaConfig) MessageService {
+	return &KafkaMessageService{client: client, cfg: cfg, subs: make(map[string]string)}
+}
+
+func (k *KafkaMessageService) topic(name string) string {
+	if t, ok := k.cfg.Topics[name]; ok {
+		return t
+	}
+	return name
+}
+
+func (k *KafkaMessageService) CreateTopic(topic string) {
+	if err := k.client.CreateTopics(context.Background(), k.topic(topic)); err != nil {
+		log.Fatalf("failed to create topic: %v, err: %v\n", topic, err)
+	}
+}
+
+func (k *KafkaMessageService) CreateSub(id string, topic string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.subs[id] = topic
+}
+
+// Publish
+// kafka does not return an id for a written message, the id of a received message is its topic, partition and offset
+func (k *KafkaMessageService) Publish(ctx context.Context, topic string, msg *Message) (string, error) {
+	if err := k.client.WriteMessages(ctx, toKafka(k.topic(topic), msg)); err != nil {
+		return "", fmt.Errorf("failed to publish message on topic %s, err: %w", topic, err)
+	}
+	return "", nil
+}
+
+func (k *KafkaMessageService) PublishAsync(ctx context.Context, topic string, msg *Message) {
+	go func() {
+		if _, err := k.Publish(context.WithoutCancel(ctx), topic, msg); err != nil {
+			log.Printf("failed to publish message with ID: %v, err: %v\n", msg.ID, err)
+		}
+	}()
+}
+
+// Subscribe
+// messages are handled one at a time in partition order, a nacked message is redelivered before the ones after it once its delay passed,
+// until it was delivered MaxDeliver times
+func (k *KafkaMessageService) Subscribe(ctx context.Context, id string, f func(ctx context.Context, d Delivery)) error {
+	k.mu.Lock()
+	topic, ok := k.subs[id]
+	k.mu.Unlock()
+	if !ok {
+		topic = id
+	}
+	r := k.client.Reader(k.topic(topic), k.cfg.ConsumerGroup+"."+id)
+	defer r.Close()
+	backoff := RetryPolicy{MinBackoff: k.cfg.MinBackoff, MaxBackoff: k.cfg.MaxBackoff}
+	for ctx.Err() == nil {
+		km, err := r.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to fetch message for subscription %v, err: %w", id, err)
+		}
+		for attempt := 1; ; attempt++ {
+			msg := fromKafka(km)
+			msg.DeliveryAttempt = attempt
+			s, settled := deliver(ctx, msg, backoff.Backoff(attempt), f)
+			if !settled {
+				// cancelled while the handler was running, the message is fetched again by the next reader of the group
+				return nil
+			}
+			if !s.acked && k.cfg.MaxDeliver > 0 && attempt >= k.cfg.MaxDeliver {
+				log.Printf("giving up message %v of subscription %v after %d deliveries\n", msg.ID, id, attempt)
+				s.acked = true
+			}
+			if s.acked {
+				// an acked message is committed even when the service is shutting down, so it is not handled twice
+				if err := r.CommitMessages(context.WithoutCancel(ctx), km); err != nil {
+					return fmt.Errorf("failed to commit message %v of subscription %v, err: %w", msg.ID, id, err)
+				}
+				break
+			}
+			// the partition waits for the redelivery, the message is fetched again by the next reader of the group when cancelled
+			if !wait(ctx, s.delay) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// settlement
+// how a delivery was settled, a nacked message is redelivered after its delay
+type settlement struct {
+	acked bool
+	delay time.Duration
+}
+
+// deliver
+// hands the message to f and waits until it is acked or nacked, a plain nack is redelivered after the backoff
+func deliver(ctx context.Context, msg *Message, backoff time.Duration, f func(ctx context.Context, d Delivery)) (s settlement, settled bool) {
+	result := make(chan settlement, 1)
+	var once sync.Once
+	settle := func(s settlement) {
+		once.Do(func() { result <- s })
+	}
+	d := NewDelivery(msg, func() { settle(settlement{acked: true}) }, func() { settle(settlement{delay: backoff}) })
+	d.nackWithDelay = func(delay time.Duration) { settle(settlement{delay: delay}) }
+	f(ctx, d)
+	// f may have settled the message right before the context was cancelled
+	select {
+	case s := <-result:
+		return s, true
+	default:
+	}
+	select {
+	case s := <-result:
+		return s, true
+	case <-ctx.Done():
+		return settlement{}, false
+	}
+}
+
+// wait
+// sleeps for the delay, false when the context was cancelled first
+func wait(ctx context.Context, delay time.Duration) bool {
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (k *KafkaMessageService) Close() error {
+	return k.client.Close()
+}
+
+func toKafka(topic string, msg *Message) kafka.Message {
+	headers := make([]kafka.Header, 0, len(msg.Attributes))
+	for k, v := range msg.Attributes {
+		headers = append(headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	km := kafka.Message{Topic: topic, Value: msg.Data, Headers: headers}
+	if msg.OrderingKey != "" {
+		km.Key = []byte(msg.OrderingKey)
+	}
+	return km
+}
+
+func fromKafka(km kafka.Message) *Message {
+	attributes := make(map[string]string, len(km.Headers))
+	for _, h := range km.Headers {
+		attributes[h.Key] = string(h.Value)
+	}
+	return &Message{
+		ID:          km.Topic + "/" + strconv.Itoa(km.Partition) + "/" + strconv.FormatInt(km.Offset, 10),
+		Data:        km.Value,
+		Attributes:  attributes,
+		OrderingKey: string(km.Key),
+		PublishTime: km.Time,
+	}
+}
+
+// kafkaGoClient
+// the KafkaClient backed by kafka-go
+type kafkaGoClient struct {
+	brokers           []string
+	partitions        int
+	replicationFactor int
+	writer            *kafka.Writer
+}
+
+// NewKafkaClient
+// connects to the given brokers, topics created by the service get the given number of partitions and replicas
+func NewKafkaClient(brokers []string, partitions int, replicationFactor int) KafkaClient {
+	return kafkaGoClient{
+		brokers:           brokers,
+		partitions:        partitions,
+		replicationFactor: replicationFactor,
+		writer:            NewKafkaWriter(brokers...),
+	}
+}
+
+// kafkaBatchTimeout
+// how long the writer waits for more messages before sending a batch, publishes are synchronous
+// so every publish waits for it (kafka-go waits a second by default)
+const kafkaBatchTimeout = 5 * time.Millisecond
+
+// NewKafkaWriter
+// the writer of the kafka client, messages with the same key go to the same partition
+func NewKafkaWriter(brokers ...string) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: kafkaBatchTimeout,
+	}
+}
+
+func (c kafkaGoClient) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	return c.writer.WriteMessages(ctx, msgs...)
+}
+
+func (c kafkaGoClient) Reader(topic string, groupId string) KafkaReader {
+	return kafka.NewReader(kafka.ReaderConfig{Brokers: c.brokers, GroupID: groupId, Topic: topic})
+}
+
+// CreateTopics
+// topics have to be created through the controller broker, existing topics are left untouched
+func (c kafkaGoClient) CreateTopics(_ context.Context, topics ...string) error {
+	conn, err := kafka.Dial("tcp", c.brokers[0])
+	if err != nil {
+		return fmt.Errorf("failed to connect to kafka, err: %w", err)
+	}
+	defer conn.Close()
+	controller, err := conn.Controller()
+	if err != nil {
+		return fmt.Errorf("failed to find the kafka controller, err: %w", err)
+	}
+	controllerConn, err := kafka.Dial("tcp", net.JoinHostPort(controller.Host, strconv.Itoa(controller.Port)))
+	if err != nil {
+		return fmt.Errorf("failed to connect to the kafka controller, err: %w", err)
+	}
+	defer controllerConn.Close()
+	configs := make([]kafka.TopicConfig, 0, len(topics))
+	for _, t := range topics {
+		configs = append(configs, kafka.TopicConfig{Topic: t, NumPartitions: c.partitions, ReplicationFactor: c.replicationFactor})
+	}
+	return controllerConn.CreateTopics(configs...)
+}
+
+func (c kafkaGoClient) Close() error {
+	return c.writer.Close()
+}
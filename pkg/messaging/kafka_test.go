@@ -0,0 +1,338 @@
+package messaging_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/nawafswe/orders-service/pkg/messaging"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol/metadata"
+	"github.com/segmentio/kafka-go/protocol/produce"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeKafka
+// an in process broker with partitioned topics and consumer groups committing offsets
+type fakeKafka struct {
+	mu         sync.Mutex
+	partitions int
+	topics     map[string][][]kafka.Message
+	// committed the next offset to read per group, topic and partition
+	committed map[string]map[int]int64
+	wake      chan struct{}
+}
+
+func newFakeKafka(partitions int) *fakeKafka {
+	return &fakeKafka{
+		partitions: partitions,
+		topics:     make(map[string][][]kafka.Message),
+		committed:  make(map[string]map[int]int64),
+		wake:       make(chan struct{}),
+	}
+}
+
+func (f *fakeKafka) CreateTopics(_ context.Context, topics ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, t := range topics {
+		if _, ok := f.topics[t]; !ok {
+			f.topics[t] = make([][]kafka.Message, f.partitions)
+		}
+	}
+	return nil
+}
+
+func (f *fakeKafka) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	partitions := make([]int, f.partitions)
+	for i := range partitions {
+		partitions[i] = i
+	}
+	for _, m := range msgs {
+		if _, ok := f.topics[m.Topic]; !ok {
+			return kafka.UnknownTopicOrPartition
+		}
+		p := (&kafka.Hash{}).Balance(m, partitions...)
+		m.Partition = p
+		m.Offset = int64(len(f.topics[m.Topic][p]))
+		m.Time = time.Now()
+		f.topics[m.Topic][p] = append(f.topics[m.Topic][p], m)
+	}
+	close(f.wake)
+	f.wake = make(chan struct{})
+	return nil
+}
+
+func (f *fakeKafka) Reader(topic string, groupId string) messaging.KafkaReader {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := groupId + "/" + topic
+	if _, ok := f.committed[key]; !ok {
+		f.committed[key] = make(map[int]int64)
+	}
+	positions := make(map[int]int64)
+	for p, o := range f.committed[key] {
+		positions[p] = o
+	}
+	return &fakeKafkaReader{broker: f, topic: topic, key: key, positions: positions}
+}
+
+func (f *fakeKafka) Close() error {
+	return nil
+}
+
+// partitionsOf
+// the messages written with the given key, by partition
+func (f *fakeKafka) partitionsOf(topic string, key string) map[int]int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	found := make(map[int]int)
+	for p, msgs := range f.topics[topic] {
+		for _, m := range msgs {
+			if string(m.Key) == key {
+				found[p]++
+			}
+		}
+	}
+	return found
+}
+
+type fakeKafkaReader struct {
+	broker    *fakeKafka
+	topic     string
+	key       string
+	positions map[int]int64
+}
+
+func (r *fakeKafkaReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	for {
+		r.broker.mu.Lock()
+		for p, msgs := range r.broker.topics[r.topic] {
+			if pos := r.positions[p]; pos < int64(len(msgs)) {
+				r.positions[p] = pos + 1
+				r.broker.mu.Unlock()
+				return msgs[pos], nil
+			}
+		}
+		wake := r.broker.wake
+		r.broker.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return kafka.Message{}, ctx.Err()
+		case <-wake:
+		}
+	}
+}
+
+func (r *fakeKafkaReader) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	r.broker.mu.Lock()
+	defer r.broker.mu.Unlock()
+	for _, m := range msgs {
+		r.broker.committed[r.key][m.Partition] = m.Offset + 1
+	}
+	return nil
+}
+
+func (r *fakeKafkaReader) Close() error {
+	return nil
+}
+
+func newKafka(broker *fakeKafka) messaging.MessageService {
+	cfg := messaging.DefaultKafkaConfig()
+	cfg.MinBackoff = time.Millisecond
+	cfg.MaxBackoff = 10 * time.Millisecond
+	return newKafkaWith(broker, cfg)
+}
+
+func newKafkaWith(broker *fakeKafka, cfg messaging.KafkaConfig) messaging.MessageService {
+	ms := messaging.NewKafka(broker, cfg)
+	ms.CreateTopic("orderStatusChanged")
+	ms.CreateSub("notifications", "orderStatusChanged")
+	ms.CreateSub("analytics", "orderStatusChanged")
+	return ms
+}
+
+// consume
+// subscribes until n deliveries were received, settle decides whether each one is acked
+func consume(t *testing.T, ms messaging.MessageService, subscription string, n int, settle func(d messaging.Delivery) bool) []*messaging.Message {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var received []*messaging.Message
+	err := ms.Subscribe(ctx, subscription, func(_ context.Context, d messaging.Delivery) {
+		received = append(received, d.Message)
+		if settle(d) {
+			d.Ack()
+		} else {
+			d.Nack()
+		}
+		if len(received) == n {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(received) != n {
+		t.Fatalf("expected %d deliveries on %v, but got %d", n, subscription, len(received))
+	}
+	return received
+}
+
+func ack(messaging.Delivery) bool { return true }
+
+func TestKafkaPublishMapsTopicsKeysAndHeaders(t *testing.T) {
+	broker := newFakeKafka(3)
+	ms := newKafka(broker)
+	for _, orderId := range []string{"1", "2", "1", "3", "1"} {
+		if _, err := ms.Publish(context.Background(), "orderStatusChanged", &messaging.Message{
+			Data:        []byte(orderId),
+			Attributes:  map[string]string{"correlation-id": "abc"},
+			OrderingKey: orderId,
+		}); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	if partitions := broker.partitionsOf("orders.order-status-changed", "1"); len(partitions) != 1 {
+		t.Errorf("expected the events of an order on a single partition, but got %v", partitions)
+	}
+	received := consume(t, ms, "notifications", 5, ack)
+	var order1 []int64
+	for _, msg := range received {
+		if msg.Attributes["correlation-id"] != "abc" {
+			t.Errorf("expected correlation-id header to be kept, but got %v", msg.Attributes)
+		}
+		if msg.OrderingKey == "1" {
+			order1 = append(order1, msg.PublishTime.UnixNano())
+		}
+	}
+	for i := 1; i < len(order1); i++ {
+		if order1[i] < order1[i-1] {
+			t.Errorf("expected the events of an order in publish order")
+		}
+	}
+	if _, err := ms.Publish(context.Background(), "unknown", &messaging.Message{}); err == nil {
+		t.Errorf("expected publishing on an unknown topic to fail")
+	}
+}
+
+func TestKafkaConsumerGroups(t *testing.T) {
+	broker := newFakeKafka(1)
+	ms := newKafka(broker)
+	_, _ = ms.Publish(context.Background(), "orderStatusChanged", &messaging.Message{Data: []byte("1")})
+	_, _ = ms.Publish(context.Background(), "orderStatusChanged", &messaging.Message{Data: []byte("2")})
+
+	first := consume(t, ms, "notifications", 1, ack)
+	// the group resumes after the committed offset
+	second := consume(t, ms, "notifications", 1, ack)
+	if string(first[0].Data) != "1" || string(second[0].Data) != "2" {
+		t.Errorf("expected the group to resume from its committed offset, but got %v then %v", string(first[0].Data), string(second[0].Data))
+	}
+	// another subscription is another group reading from the start
+	if other := consume(t, ms, "analytics", 2, ack); string(other[0].Data) != "1" {
+		t.Errorf("expected every subscription to receive every message, but got %v", string(other[0].Data))
+	}
+}
+
+func TestKafkaNackRedelivers(t *testing.T) {
+	broker := newFakeKafka(1)
+	ms := newKafka(broker)
+	_, _ = ms.Publish(context.Background(), "orderStatusChanged", &messaging.Message{Data: []byte("1")})
+	_, _ = ms.Publish(context.Background(), "orderStatusChanged", &messaging.Message{Data: []byte("2")})
+	calls := 0
+	received := consume(t, ms, "notifications", 3, func(d messaging.Delivery) bool {
+		calls++
+		return calls != 1
+	})
+	expected := []struct {
+		data    string
+		attempt int
+	}{{"1", 1}, {"1", 2}, {"2", 1}}
+	for i, msg := range received {
+		if string(msg.Data) != expected[i].data || msg.DeliveryAttempt != expected[i].attempt {
+			t.Errorf("expected delivery %d to be %v attempt %d, but got %v attempt %d", i+1, expected[i].data, expected[i].attempt, string(msg.Data), msg.DeliveryAttempt)
+		}
+	}
+	if received[0].ID != received[1].ID {
+		t.Errorf("expected a redelivered message to keep its id, but got %v and %v", received[0].ID, received[1].ID)
+	}
+}
+
+func TestKafkaNackWithDelayWaitsBeforeRedelivering(t *testing.T) {
+	broker := newFakeKafka(1)
+	ms := newKafka(broker)
+	_, _ = ms.Publish(context.Background(), "orderStatusChanged", &messaging.Message{Data: []byte("1")})
+	var delivered []time.Time
+	consume(t, ms, "notifications", 2, func(d messaging.Delivery) bool {
+		delivered = append(delivered, time.Now())
+		if d.DeliveryAttempt == 1 {
+			d.NackWithDelay(200 * time.Millisecond)
+			return false
+		}
+		return true
+	})
+	if gap := delivered[1].Sub(delivered[0]); gap < 200*time.Millisecond {
+		t.Errorf("expected the message to be redelivered after its delay, but it was after %v", gap)
+	}
+}
+
+func TestKafkaGivesUpAfterMaxDeliver(t *testing.T) {
+	broker := newFakeKafka(1)
+	cfg := messaging.DefaultKafkaConfig()
+	cfg.MinBackoff = time.Millisecond
+	cfg.MaxDeliver = 3
+	ms := newKafkaWith(broker, cfg)
+	_, _ = ms.Publish(context.Background(), "orderStatusChanged", &messaging.Message{Data: []byte("1")})
+	_, _ = ms.Publish(context.Background(), "orderStatusChanged", &messaging.Message{Data: []byte("2")})
+	received := consume(t, ms, "notifications", 4, func(d messaging.Delivery) bool {
+		return string(d.Data) == "2"
+	})
+	var data []string
+	for _, msg := range received {
+		data = append(data, string(msg.Data))
+	}
+	if !reflect.DeepEqual(data, []string{"1", "1", "1", "2"}) {
+		t.Errorf("expected the first message to be delivered 3 times before the second one, but got %v", data)
+	}
+}
+
+// fakeKafkaTransport
+// answers the metadata and produce requests of a kafka-go writer for a single topic with one partition
+type fakeKafkaTransport struct{}
+
+func (fakeKafkaTransport) RoundTrip(_ context.Context, _ net.Addr, req kafka.Request) (kafka.Response, error) {
+	switch r := req.(type) {
+	case *metadata.Request:
+		topics := make([]metadata.ResponseTopic, 0, len(r.TopicNames))
+		for _, name := range r.TopicNames {
+			topics = append(topics, metadata.ResponseTopic{Name: name, Partitions: []metadata.ResponsePartition{{PartitionIndex: 0}}})
+		}
+		return &metadata.Response{Brokers: []metadata.ResponseBroker{{Host: "localhost", Port: 9092}}, Topics: topics}, nil
+	case *produce.Request:
+		return &produce.Response{Topics: []produce.ResponseTopic{{Topic: r.Topics[0].Topic, Partitions: []produce.ResponsePartition{{}}}}}, nil
+	}
+	return nil, fmt.Errorf("unexpected request %T", req)
+}
+
+func TestKafkaWriterPublishesWithoutWaitingForABatch(t *testing.T) {
+	w := messaging.NewKafkaWriter("localhost:9092")
+	w.Transport = fakeKafkaTransport{}
+	defer w.Close()
+	// the first write also fetches the partitions of the topic
+	if err := w.WriteMessages(context.Background(), kafka.Message{Topic: "orders.order-created", Value: []byte("0")}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		if err := w.WriteMessages(context.Background(), kafka.Message{Topic: "orders.order-created", Value: []byte("1")}); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected 10 synchronous publishes well under a second, but they took %v", elapsed)
+	}
+}
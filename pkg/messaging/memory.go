@@ -0,0 +1,220 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// InMemoryMessageService
+// an in process broker for local development and tests, messages are lost when the process exits.
+// Like pubsub, every subscription of a topic gets its own copy of a message, a nacked message is redelivered right away or after the delay it was nacked with,
+// a message that is neither acked nor nacked is redelivered once the ack deadline passes,
+// and messages sharing an ordering key are delivered one at a time in publish order
+type InMemoryMessageService struct {
+	mu          sync.Mutex
+	topics      map[string][]*memorySubscription
+	subs        map[string]*memorySubscription
+	ackDeadline time.Duration
+	seq         int64
+}
+
+func NewInMemory(ackDeadline time.Duration) MessageService {
+	return &InMemoryMessageService{
+		topics:      make(map[string][]*memorySubscription),
+		subs:        make(map[string]*memorySubscription),
+		ackDeadline: ackDeadline,
+	}
+}
+
+func (m *InMemoryMessageService) CreateTopic(topic string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.topics[topic]; ok {
+		log.Printf("topic %v already exist\n", topic)
+		return
+	}
+	m.topics[topic] = nil
+}
+
+func (m *InMemoryMessageService) CreateSub(id string, topic string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	subs, ok := m.topics[topic]
+	if !ok {
+		log.Printf("failed to create subscription: %v, topic %v not found\n", id, topic)
+		return
+	}
+	if _, ok := m.subs[id]; ok {
+		log.Printf("subscription %v already exist\n", id)
+		return
+	}
+	sub := &memorySubscription{id: id, ackDeadline: m.ackDeadline, wake: make(chan struct{}, 1)}
+	m.subs[id] = sub
+	m.topics[topic] = append(subs, sub)
+}
+
+func (m *InMemoryMessageService) Subscribe(ctx context.Context, id string, f func(ctx context.Context, d Delivery)) error {
+	m.mu.Lock()
+	sub, ok := m.subs[id]
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("subscription:%v not found", id)
+	}
+	return sub.receive(ctx, f)
+}
+
+// Close
+// nothing to release, the messages that were not acked are dropped with the service
+func (m *InMemoryMessageService) Close() error {
+	return nil
+}
+
+func (m *InMemoryMessageService) Publish(_ context.Context, topic string, msg *Message) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	subs, ok := m.topics[topic]
+	if !ok {
+		return "", fmt.Errorf("publish message on topic %s, err: failed to get topic: %v, it is not found", topic, topic)
+	}
+	m.seq++
+	id := strconv.FormatInt(m.seq, 10)
+	now := time.Now()
+	for _, sub := range subs {
+		attributes := make(map[string]string, len(msg.Attributes))
+		for k, v := range msg.Attributes {
+			attributes[k] = v
+		}
+		sub.enqueue(&memoryMessage{id: id, data: msg.Data, attributes: attributes, orderingKey: msg.OrderingKey, publishTime: now})
+	}
+	return id, nil
+}
+
+func (m *InMemoryMessageService) PublishAsync(ctx context.Context, topic string, msg *Message) {
+	if _, err := m.Publish(ctx, topic, msg); err != nil {
+		log.Printf("failed to publish message with ID: %v, err: %v\n", msg.ID, err)
+	}
+}
+
+type memoryMessage struct {
+	id          string
+	data        []byte
+	attributes  map[string]string
+	orderingKey string
+	publishTime time.Time
+	// attempts is bumped on every delivery, an ack or nack of an older delivery is ignored
+	attempts int
+	inFlight bool
+	// notBefore a message nacked with a delay is not redelivered before it
+	notBefore time.Time
+}
+
+type memorySubscription struct {
+	id          string
+	ackDeadline time.Duration
+	mu          sync.Mutex
+	queue       []*memoryMessage
+	wake        chan struct{}
+}
+
+func (s *memorySubscription) enqueue(m *memoryMessage) {
+	s.mu.Lock()
+	s.queue = append(s.queue, m)
+	s.mu.Unlock()
+	s.signal()
+}
+
+func (s *memorySubscription) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next
+// the oldest message that is not in flight, a message waits while an older one with the same ordering key is unacked
+func (s *memorySubscription) next() (*memoryMessage, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	blocked := make(map[string]bool)
+	for _, m := range s.queue {
+		if m.orderingKey != "" {
+			if blocked[m.orderingKey] {
+				continue
+			}
+			blocked[m.orderingKey] = true
+		}
+		if m.inFlight || time.Now().Before(m.notBefore) {
+			continue
+		}
+		m.inFlight = true
+		m.attempts++
+		return m, m.attempts
+	}
+	return nil, 0
+}
+
+// settle
+// acks or nacks the given delivery of the message, a nacked message is redelivered once the delay passed. Later calls for the same delivery are no-ops
+func (s *memorySubscription) settle(m *memoryMessage, attempt int, ack bool, delay time.Duration) {
+	s.mu.Lock()
+	if !m.inFlight || m.attempts != attempt {
+		s.mu.Unlock()
+		return
+	}
+	m.inFlight = false
+	if delay > 0 {
+		m.notBefore = time.Now().Add(delay)
+		time.AfterFunc(delay, s.signal)
+	}
+	if ack {
+		for i, queued := range s.queue {
+			if queued == m {
+				s.queue = append(s.queue[:i], s.queue[i+1:]...)
+				break
+			}
+		}
+	}
+	s.mu.Unlock()
+	s.signal()
+}
+
+func (s *memorySubscription) receive(ctx context.Context, f func(ctx context.Context, d Delivery)) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for ctx.Err() == nil {
+		m, attempt := s.next()
+		if m == nil {
+			select {
+			case <-ctx.Done():
+			case <-s.wake:
+			}
+			continue
+		}
+		wg.Add(1)
+		go func(m *memoryMessage, attempt int) {
+			defer wg.Done()
+			attributes := make(map[string]string, len(m.attributes))
+			for k, v := range m.attributes {
+				attributes[k] = v
+			}
+			msg := &Message{
+				ID:              m.id,
+				Data:            m.data,
+				Attributes:      attributes,
+				PublishTime:     m.publishTime,
+				OrderingKey:     m.orderingKey,
+				DeliveryAttempt: attempt,
+			}
+			d := NewDelivery(msg, func() { s.settle(m, attempt, true, 0) }, func() { s.settle(m, attempt, false, 0) })
+			d.nackWithDelay = func(delay time.Duration) { s.settle(m, attempt, false, delay) }
+			f(ctx, d)
+			// the deadline only runs once f returned, same as pubsub extending the lease while the callback is running
+			time.AfterFunc(s.ackDeadline, func() { s.settle(m, attempt, false, 0) })
+		}(m, attempt)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package messaging_test
+
+import (
+	"context"
+	"github.com/nawafswe/orders-service/pkg/messaging"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newInMemory(ackDeadline time.Duration) messaging.MessageService {
+	ms := messaging.NewInMemory(ackDeadline)
+	ms.CreateTopic("orderStatusChanged")
+	ms.CreateSub("notifications", "orderStatusChanged")
+	ms.CreateSub("analytics", "orderStatusChanged")
+	return ms
+}
+
+// receive
+// collects deliveries of the subscription until n were received, settle decides whether each one is acked
+func receive(t *testing.T, ms messaging.MessageService, subscription string, n int, settle func(d messaging.Delivery) bool) []*messaging.Message {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var mu sync.Mutex
+	var received []*messaging.Message
+	err := ms.Subscribe(ctx, subscription, func(_ context.Context, d messaging.Delivery) {
+		mu.Lock()
+		received = append(received, d.Message)
+		done := len(received) == n
+		mu.Unlock()
+		if settle(d) {
+			d.Ack()
+		} else {
+			d.Nack()
+		}
+		if done {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(received) != n {
+		t.Fatalf("expected %d deliveries on %v, but got %d", n, subscription, len(received))
+	}
+	return received
+}
+
+func TestInMemoryFanOut(t *testing.T) {
+	ms := newInMemory(time.Minute)
+	id, err := ms.Publish(context.Background(), "orderStatusChanged", &messaging.Message{Data: []byte("1"), Attributes: map[string]string{"correlation-id": "abc"}})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	for _, subscription := range []string{"notifications", "analytics"} {
+		msg := receive(t, ms, subscription, 1, func(messaging.Delivery) bool { return true })[0]
+		if msg.ID != id || string(msg.Data) != "1" || msg.Attributes["correlation-id"] != "abc" {
+			t.Errorf("unexpected message %+v on %v", msg, subscription)
+		}
+	}
+}
+
+func TestInMemoryUnknownResources(t *testing.T) {
+	ms := newInMemory(time.Minute)
+	if _, err := ms.Publish(context.Background(), "unknown", &messaging.Message{}); err == nil {
+		t.Errorf("expected publishing on an unknown topic to fail")
+	}
+	if err := ms.Subscribe(context.Background(), "unknown", func(context.Context, messaging.Delivery) {}); err == nil {
+		t.Errorf("expected getting an unknown subscription to fail")
+	}
+}
+
+func TestInMemoryNackRedelivers(t *testing.T) {
+	ms := newInMemory(time.Minute)
+	_, _ = ms.Publish(context.Background(), "orderStatusChanged", &messaging.Message{Data: []byte("1")})
+	calls := 0
+	received := receive(t, ms, "notifications", 3, func(messaging.Delivery) bool {
+		calls++
+		return calls == 3
+	})
+	for i, msg := range received {
+		if msg.DeliveryAttempt != i+1 {
+			t.Errorf("expected delivery attempt %d, but got %d", i+1, msg.DeliveryAttempt)
+		}
+	}
+}
+
+func TestInMemoryNackWithDelay(t *testing.T) {
+	ms := newInMemory(time.Minute)
+	_, _ = ms.Publish(context.Background(), "orderStatusChanged", &messaging.Message{Data: []byte("1")})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var nackedAt, redeliveredAt time.Time
+	_ = ms.Subscribe(ctx, "notifications", func(_ context.Context, d messaging.Delivery) {
+		if d.DeliveryAttempt == 1 {
+			nackedAt = time.Now()
+			d.NackWithDelay(100 * time.Millisecond)
+			return
+		}
+		redeliveredAt = time.Now()
+		d.Ack()
+		cancel()
+	})
+	if redeliveredAt.IsZero() {
+		t.Fatalf("expected the message to be redelivered")
+	}
+	if delay := redeliveredAt.Sub(nackedAt); delay < 100*time.Millisecond {
+		t.Errorf("expected the message to be redelivered after the delay, but it was after %v", delay)
+	}
+}
+
+func TestInMemoryAckDeadlineRedelivers(t *testing.T) {
+	ms := newInMemory(10 * time.Millisecond)
+	_, _ = ms.Publish(context.Background(), "orderStatusChanged", &messaging.Message{Data: []byte("1")})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	deliveries := 0
+	_ = ms.Subscribe(ctx, "notifications", func(_ context.Context, d messaging.Delivery) {
+		deliveries++
+		if deliveries == 2 {
+			d.Ack()
+			cancel()
+		}
+		// the first delivery is neither acked nor nacked
+	})
+	if deliveries != 2 {
+		t.Errorf("expected an unsettled message to be redelivered once, but got %d deliveries", deliveries)
+	}
+}
+
+func TestInMemoryOrderingKey(t *testing.T) {
+	ms := newInMemory(time.Minute)
+	for _, data := range []string{"1", "2", "3"} {
+		_, _ = ms.Publish(context.Background(), "orderStatusChanged", &messaging.Message{Data: []byte(data), OrderingKey: "order-1"})
+	}
+	nacked := false
+	received := receive(t, ms, "notifications", 4, func(d messaging.Delivery) bool {
+		// nack the second message once, it must be redelivered before the third
+		if string(d.Data) == "2" && !nacked {
+			nacked = true
+			return false
+		}
+		return true
+	})
+	expected := []string{"1", "2", "2", "3"}
+	for i, msg := range received {
+		if string(msg.Data) != expected[i] {
+			t.Errorf("expected delivery %d to be %v, but got %v", i+1, expected[i], string(msg.Data))
+		}
+	}
+}
+
+func TestInMemoryConsumer(t *testing.T) {
+	ms := newInMemory(time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	c := messaging.NewConsumer(ms)
+	c.Register("notifications", func(ctx context.Context, msg *messaging.Message) error {
+		cancel()
+		return nil
+	})
+	_, _ = ms.Publish(context.Background(), "orderStatusChanged", &messaging.Message{Data: []byte("1")})
+	c.Start(ctx)
+	if ctx.Err() != context.Canceled {
+		t.Errorf("expected the consumer to handle the published message")
+	}
+}
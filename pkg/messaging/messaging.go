@@ -1,138 +1,73 @@
 package messaging
 
 import (
-	"cloud.google.com/go/pubsub"
 	"context"
-	"fmt"
-	"google.golang.org/grpc/status"
-	"log"
-	"os"
 	"time"
 )
 
-type MessageSender interface {
-	PublishAsync(ctx context.Context, topic string, msg *pubsub.Message)
+// Message
+// a broker neutral message, adapters translate it from and to the message type of their broker
+type Message struct {
+	// ID is assigned by the broker when the message is published
+	ID         string
+	Data       []byte
+	Attributes map[string]string
+	// OrderingKey messages sharing a key are delivered in the order they were published
+	OrderingKey string
+	PublishTime time.Time
+	// DeliveryAttempt starts at 1, brokers that do not track it leave it at 0
+	DeliveryAttempt int
 }
 
-type MessageReceiver interface {
-	Subscribe(ctx context.Context, subscription string) error
+type Publisher interface {
+	// Publish blocks until the broker acknowledged the message and returns its server id
+	Publish(ctx context.Context, topic string, msg *Message) (string, error)
+	PublishAsync(ctx context.Context, topic string, msg *Message)
 }
 
-// Would embed MessageSender, MessageReceiver
-
-type MessageService interface {
-	MessageSender
-	CreateSub(id string, topic *pubsub.Topic)
-	CreateTopic(topic string) *pubsub.Topic
-	GetSubscription(ctx context.Context, id string) (*pubsub.Subscription, error)
-	CreateTopicWithSchema(topic string, tc pubsub.TopicConfig)
-	GetTopic(ctx context.Context, topicId string) (*pubsub.Topic, error)
-}
-type MessageServiceImpl struct {
-	C *pubsub.Client
-}
-
-func New(ctx context.Context, projectId string) MessageService {
-	// we need a longed lived context to maintain client connection, using withCancel or timeout will cause unauthorized error, because the context going to be cancelled
-	c, err := pubsub.NewClient(ctx, projectId)
-	if err != nil {
-		log.Fatalf("failed to obtain a pubsub client for project: %v, err: %v\n", projectId, err)
-	}
-	return MessageServiceImpl{C: c}
+type Subscriber interface {
+	// Subscribe delivers the messages of the subscription to f until the context is cancelled, f must ack or nack every delivery
+	Subscribe(ctx context.Context, subscription string, f func(ctx context.Context, d Delivery)) error
 }
 
-func (p MessageServiceImpl) CreateSub(subId string, t *pubsub.Topic) {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	sub, err := p.C.CreateSubscription(ctx, subId, pubsub.SubscriptionConfig{
-		Topic: t,
-	})
-
-	if err != nil {
-		if e, ok := status.FromError(err); !ok {
-			log.Fatalf("failed to create subscription: %v, err: %v\n", subId, err)
-		} else {
-			log.Printf("rpc error, err: %v", e)
-			return
-		}
-	}
-
-	log.Printf("Created a subscription with exactly once delivery enabled: %v\n", sub)
-
+type MessageService interface {
+	Publisher
+	Subscriber
+	CreateTopic(topic string)
+	CreateSub(id string, topic string)
+	// Close releases the connection to the broker, the service cannot be used afterwards
+	Close() error
 }
 
-func (p MessageServiceImpl) CreateTopic(topic string) *pubsub.Topic {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	t := p.C.Topic(topic)
-	if val, _ := t.Exists(ctx); !val {
-		_, err := p.C.CreateTopic(ctx, topic)
-		if err != nil {
-			log.Fatalf("failed to create topic: %v, err: %v\n", topic, err)
-		}
-		log.Printf("topic created successfully")
-	} else {
-		log.Printf("topic %v already exist\n", topic)
-	}
-	return t
+// Delivery
+// a received message along with the way to settle it with the broker that delivered it
+type Delivery struct {
+	*Message
+	ack  func()
+	nack func()
+	// nackWithDelay is set by brokers that can redeliver a message after a delay on their own
+	nackWithDelay func(delay time.Duration)
 }
 
-func (p MessageServiceImpl) CreateTopicWithSchema(topic string, tc pubsub.TopicConfig) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*100)
-	defer cancel()
-
-	c, _ := pubsub.NewClient(context.Background(), os.Getenv("GOOGLE_PROJECT_ID"))
-
-	t := c.Topic(topic)
-	if val, _ := t.Exists(context.Background()); !val {
-		_, err := p.C.CreateTopicWithConfig(ctx, topic, &tc)
-		if err != nil {
-			log.Fatalf("failed to create topic: %v, err: %v\n", topic, err)
-		}
-		log.Printf("topic created with schema successfully")
-	} else {
-		log.Printf("topic %v already exist\n", topic)
-	}
+func NewDelivery(msg *Message, ack func(), nack func()) Delivery {
+	return Delivery{Message: msg, ack: ack, nack: nack}
 }
 
-func (p MessageServiceImpl) GetTopic(ctx context.Context, topicId string) (*pubsub.Topic, error) {
-	t := p.C.Topic(topicId)
-
-	if b, err := t.Exists(ctx); err != nil {
-		return nil, fmt.Errorf("failed to get topic: %v, err: %w", topicId, err)
-	} else if !b {
-		return nil, fmt.Errorf("failed to get topic: %v, it is not found", topicId)
-	}
-	return t, nil
+func (d Delivery) Ack() {
+	d.ack()
 }
 
-func (p MessageServiceImpl) GetSubscription(ctx context.Context, id string) (*pubsub.Subscription, error) {
-	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
-	defer cancel()
-	s := p.C.Subscription(id)
-	if b, err := s.Exists(ctx); err != nil {
-		return nil, fmt.Errorf("failed to get subscription %v, err: %w", id, err)
-	} else if !b {
-		return nil, fmt.Errorf("subscription:%v not found", id)
-	}
-	return s, nil
+func (d Delivery) Nack() {
+	d.nack()
 }
 
-func (p MessageServiceImpl) PublishAsync(ctx context.Context, topicId string, msg *pubsub.Message) {
-	t, err := p.GetTopic(ctx, topicId)
-	if err != nil {
-		log.Printf("publish message on topic %s, err: %v\n", topicId, err)
+// NackWithDelay
+// asks for the message to be redelivered once the delay passed, it never blocks the caller.
+// Brokers without delayed redelivery get a plain nack and redeliver on their own terms (pubsub after the retry policy of the subscription)
+func (d Delivery) NackWithDelay(delay time.Duration) {
+	if d.nackWithDelay != nil {
+		d.nackWithDelay(delay)
 		return
 	}
-	result := t.Publish(ctx, msg)
-	ctxWithValue := context.WithValue(context.Background(), "topicId", topicId)
-	ctx, cancel := context.WithTimeout(ctxWithValue, time.Second*3)
-	go func() {
-		defer cancel()
-		srvId, err := result.Get(ctx)
-		if err != nil {
-			log.Printf("failed to publish message with ID: %v\n, srvId: %v, err: %v", msg.ID, srvId, err)
-		}
-	}()
+	d.nack()
 }
@@ -0,0 +1,180 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/nawafswe/orders-service/pkg/contextWrapper"
+	"github.com/nawafswe/orders-service/pkg/logger"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// WithCorrelationId
+// carries the correlation-id attribute of the message in the context, messages without one get a new id
+func WithCorrelationId() Middleware {
+	return func(_ string, next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			correlationId, ok := msg.Attributes["correlation-id"]
+			if !ok || correlationId == "" {
+				correlationId = uuid.New().String()
+			}
+			return next(contextWrapper.WithCorrelationId(ctx, correlationId), msg)
+		}
+	}
+}
+
+// WithTracing
+// starts a span per message, continuing the publisher trace when the message carries datadog propagation attributes.
+// The spanId attribute set by our publishers is kept as a tag to correlate both sides
+func WithTracing() Middleware {
+	return func(subscription string, next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			opts := []tracer.StartSpanOption{
+				tracer.ResourceName(subscription),
+				tracer.Tag("messaging.message_id", msg.ID),
+			}
+			if parent, err := tracer.Extract(tracer.TextMapCarrier(msg.Attributes)); err == nil {
+				opts = append(opts, tracer.ChildOf(parent))
+			}
+			if spanId, ok := msg.Attributes["spanId"]; ok {
+				opts = append(opts, tracer.Tag("messaging.publisher_span_id", spanId))
+			}
+			span, ctx := tracer.StartSpanFromContext(ctx, "pubsub.receive", opts...)
+			err := next(ctx, msg)
+			span.Finish(tracer.WithError(err))
+			return err
+		}
+	}
+}
+
+// WithLogging
+// logs the outcome and duration of every message
+func WithLogging(l logger.Logger) Middleware {
+	return func(subscription string, next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			start := time.Now()
+			err := next(ctx, msg)
+			data := map[string]any{
+				"process":        subscription,
+				"messageId":      msg.ID,
+				"correlation-id": ctx.Value("correlation-id"),
+				"time":           time.Since(start),
+			}
+			var dropErr DropErr
+			var skipErr SkipErr
+			switch {
+			case err == nil:
+				l.Info(data, "Message processed")
+			case errors.As(err, &skipErr):
+				data["error"] = err.Error()
+				l.Info(data, "Message skipped, it no longer applies")
+			case errors.As(err, &dropErr):
+				data["error"] = err.Error()
+				l.Warn(data, "Message failed permanently, it will not be retried")
+			default:
+				data["error"] = err.Error()
+				l.Error(data, "Message processing failed")
+			}
+			return err
+		}
+	}
+}
+
+// WithRecovery
+// turns a panicking handler into a failed message instead of crashing the service
+func WithRecovery(l logger.Logger) Middleware {
+	return func(subscription string, next Handler) Handler {
+		return func(ctx context.Context, msg *Message) (err error) {
+			defer func() {
+				if v := recover(); v != nil {
+					l.Error(map[string]any{
+						"process":   subscription,
+						"messageId": msg.ID,
+						"stack":     string(debug.Stack()),
+					}, fmt.Sprintf("recovered from panic: %v", v))
+					err = fmt.Errorf("handler panicked: %v", v)
+				}
+			}()
+			return next(ctx, msg)
+		}
+	}
+}
+
+// SubscriptionStats
+// counters of a single subscription, durations are summed over every handled message
+type SubscriptionStats struct {
+	Processed int64         `json:"processed"`
+	Failed    int64         `json:"failed"`
+	Dropped   int64         `json:"dropped"`
+	Skipped   int64         `json:"skipped"`
+	Duration  time.Duration `json:"duration_ns"`
+}
+
+// ConsumerMetrics
+// in memory counters of the messages handled per subscription
+type ConsumerMetrics struct {
+	mu    sync.Mutex
+	stats map[string]SubscriptionStats
+}
+
+func NewConsumerMetrics() *ConsumerMetrics {
+	return &ConsumerMetrics{stats: make(map[string]SubscriptionStats)}
+}
+
+func (m *ConsumerMetrics) record(subscription string, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.stats[subscription]
+	var dropErr DropErr
+	var skipErr SkipErr
+	switch {
+	case err == nil:
+		s.Processed++
+	case errors.As(err, &skipErr):
+		s.Skipped++
+	case errors.As(err, &dropErr):
+		s.Dropped++
+	default:
+		s.Failed++
+	}
+	s.Duration += d
+	m.stats[subscription] = s
+}
+
+func (m *ConsumerMetrics) Snapshot() map[string]SubscriptionStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := make(map[string]SubscriptionStats, len(m.stats))
+	for k, v := range m.stats {
+		snapshot[k] = v
+	}
+	return snapshot
+}
+
+// ServeHTTP
+// writes the stats of every subscription as json, keyed by subscription
+func (m *ConsumerMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(m.Snapshot()); err != nil {
+		http.Error(w, fmt.Sprintf("failed to encode consumer metrics, err: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// WithMetrics
+// records the outcome and duration of every message in m
+func WithMetrics(m *ConsumerMetrics) Middleware {
+	return func(subscription string, next Handler) Handler {
+		return func(ctx context.Context, msg *Message) error {
+			start := time.Now()
+			err := next(ctx, msg)
+			m.record(subscription, time.Since(start), err)
+			return err
+		}
+	}
+}
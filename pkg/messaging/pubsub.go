@@ -0,0 +1,194 @@
+package messaging
+
+import (
+	"cloud.google.com/go/pubsub"
+	"context"
+	"fmt"
+	"google.golang.org/grpc/status"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// PubSubMessageService
+// the GCP Pub/Sub adapter, topics and subscriptions map one to one to pubsub topics and subscriptions.
+// Topics are kept once they were found, so publishes share their batching goroutines and the ordering keys paused by a failed publish
+type PubSubMessageService struct {
+	C      *pubsub.Client
+	mu     sync.Mutex
+	topics map[string]*pubsub.Topic
+}
+
+func NewPubSub(ctx context.Context, projectId string) MessageService {
+	// we need a longed lived context to maintain client connection, using withCancel or timeout will cause unauthorized error, because the context going to be cancelled
+	c, err := pubsub.NewClient(ctx, projectId)
+	if err != nil {
+		log.Fatalf("failed to obtain a pubsub client for project: %v, err: %v\n", projectId, err)
+	}
+	return NewPubSubFromClient(c)
+}
+
+// NewPubSubFromClient
+// the adapter over an existing client, such as one connected to the pubsub emulator
+func NewPubSubFromClient(c *pubsub.Client) MessageService {
+	return &PubSubMessageService{C: c, topics: make(map[string]*pubsub.Topic)}
+}
+
+func (p *PubSubMessageService) CreateSub(subId string, topic string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// a nacked message is redelivered after the backoff of the retry policy, pubsub cannot delay a single nack
+	retry := DefaultRetryPolicy(subId)
+	sub, err := p.C.CreateSubscription(ctx, subId, pubsub.SubscriptionConfig{
+		Topic:       p.C.Topic(topic),
+		RetryPolicy: &pubsub.RetryPolicy{MinimumBackoff: retry.MinBackoff, MaximumBackoff: retry.MaxBackoff},
+	})
+
+	if err != nil {
+		if e, ok := status.FromError(err); !ok {
+			log.Fatalf("failed to create subscription: %v, err: %v\n", subId, err)
+		} else {
+			log.Printf("rpc error, err: %v", e)
+			return
+		}
+	}
+
+	log.Printf("Created a subscription with exactly once delivery enabled: %v\n", sub)
+
+}
+
+func (p *PubSubMessageService) CreateTopic(topic string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t := p.C.Topic(topic)
+	if val, _ := t.Exists(ctx); !val {
+		_, err := p.C.CreateTopic(ctx, topic)
+		if err != nil {
+			log.Fatalf("failed to create topic: %v, err: %v\n", topic, err)
+		}
+		log.Printf("topic created successfully")
+	} else {
+		log.Printf("topic %v already exist\n", topic)
+	}
+}
+
+func (p *PubSubMessageService) CreateTopicWithSchema(topic string, tc pubsub.TopicConfig) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*100)
+	defer cancel()
+
+	c, _ := pubsub.NewClient(context.Background(), os.Getenv("GOOGLE_PROJECT_ID"))
+
+	t := c.Topic(topic)
+	if val, _ := t.Exists(context.Background()); !val {
+		_, err := p.C.CreateTopicWithConfig(ctx, topic, &tc)
+		if err != nil {
+			log.Fatalf("failed to create topic: %v, err: %v\n", topic, err)
+		}
+		log.Printf("topic created with schema successfully")
+	} else {
+		log.Printf("topic %v already exist\n", topic)
+	}
+}
+
+// GetTopic
+// the topic is looked up once, then shared by every publish until the service is closed
+func (p *PubSubMessageService) GetTopic(ctx context.Context, topicId string) (*pubsub.Topic, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if t, ok := p.topics[topicId]; ok {
+		return t, nil
+	}
+	t := p.C.Topic(topicId)
+	if b, err := t.Exists(ctx); err != nil {
+		t.Stop()
+		return nil, fmt.Errorf("failed to get topic: %v, err: %w", topicId, err)
+	} else if !b {
+		t.Stop()
+		return nil, fmt.Errorf("failed to get topic: %v, it is not found", topicId)
+	}
+	// ordering has to be enabled before the first publish, messages without an ordering key are not affected
+	t.EnableMessageOrdering = true
+	p.topics[topicId] = t
+	return t, nil
+}
+
+func (p *PubSubMessageService) Subscribe(ctx context.Context, id string, f func(ctx context.Context, d Delivery)) error {
+	existsCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+	s := p.C.Subscription(id)
+	if b, err := s.Exists(existsCtx); err != nil {
+		return fmt.Errorf("failed to get subscription %v, err: %w", id, err)
+	} else if !b {
+		return fmt.Errorf("subscription:%v not found", id)
+	}
+	return s.Receive(ctx, func(ctx context.Context, msg *pubsub.Message) {
+		f(ctx, NewDelivery(fromPubSub(msg), msg.Ack, msg.Nack))
+	})
+}
+
+func (p *PubSubMessageService) PublishAsync(ctx context.Context, topicId string, msg *Message) {
+	t, err := p.GetTopic(ctx, topicId)
+	if err != nil {
+		log.Printf("publish message on topic %s, err: %v\n", topicId, err)
+		return
+	}
+	result := t.Publish(ctx, toPubSub(msg))
+	ctxWithValue := context.WithValue(context.Background(), "topicId", topicId)
+	ctx, cancel := context.WithTimeout(ctxWithValue, time.Second*3)
+	go func() {
+		defer cancel()
+		srvId, err := result.Get(ctx)
+		if err != nil {
+			log.Printf("failed to publish message with ID: %v\n, srvId: %v, err: %v", msg.ID, srvId, err)
+		}
+	}()
+}
+
+func (p *PubSubMessageService) Publish(ctx context.Context, topicId string, msg *Message) (string, error) {
+	t, err := p.GetTopic(ctx, topicId)
+	if err != nil {
+		return "", fmt.Errorf("publish message on topic %s, err: %w", topicId, err)
+	}
+	srvId, err := t.Publish(ctx, toPubSub(msg)).Get(ctx)
+	if err != nil {
+		if msg.OrderingKey != "" {
+			// a failed publish pauses the ordering key until it is resumed
+			t.ResumePublish(msg.OrderingKey)
+		}
+		return "", fmt.Errorf("failed to publish message on topic %s, err: %w", topicId, err)
+	}
+	return srvId, nil
+}
+
+// Close
+// flushes and stops the topics before closing the client
+func (p *PubSubMessageService) Close() error {
+	p.mu.Lock()
+	for id, t := range p.topics {
+		t.Stop()
+		delete(p.topics, id)
+	}
+	p.mu.Unlock()
+	return p.C.Close()
+}
+
+func toPubSub(msg *Message) *pubsub.Message {
+	return &pubsub.Message{Data: msg.Data, Attributes: msg.Attributes, OrderingKey: msg.OrderingKey}
+}
+
+func fromPubSub(msg *pubsub.Message) *Message {
+	m := &Message{
+		ID:          msg.ID,
+		Data:        msg.Data,
+		Attributes:  msg.Attributes,
+		OrderingKey: msg.OrderingKey,
+		PublishTime: msg.PublishTime,
+	}
+	// only set on subscriptions with a dead-letter policy
+	if msg.DeliveryAttempt != nil {
+		m.DeliveryAttempt = *msg.DeliveryAttempt
+	}
+	return m
+}
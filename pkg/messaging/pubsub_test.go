@@ -0,0 +1,53 @@
+package messaging_test
+
+import (
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"context"
+	"github.com/nawafswe/orders-service/pkg/messaging"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPubSubPublishReusesTheTopic(t *testing.T) {
+	srv := pstest.NewServer()
+	defer srv.Close()
+	var lookups atomic.Int32
+	conn, err := grpc.Dial(srv.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithUnaryInterceptor(
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			if method == "/google.pubsub.v1.Publisher/GetTopic" {
+				lookups.Add(1)
+			}
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	c, err := pubsub.NewClient(context.Background(), "orders", option.WithGRPCConn(conn))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	ms := messaging.NewPubSubFromClient(c)
+	ms.CreateTopic("orderStatusChanged")
+	lookups.Store(0)
+	for _, key := range []string{"1", "", "1"} {
+		if _, err := ms.Publish(context.Background(), "orderStatusChanged", &messaging.Message{Data: []byte("changed"), OrderingKey: key}); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	if n := lookups.Load(); n != 1 {
+		t.Errorf("expected the topic to be looked up once, but it was %d times", n)
+	}
+	if n := len(srv.Messages()); n != 3 {
+		t.Errorf("expected 3 published messages, but got %d", n)
+	}
+	if _, err := ms.Publish(context.Background(), "unknown", &messaging.Message{}); err == nil {
+		t.Errorf("expected publishing on an unknown topic to fail")
+	}
+	if err := ms.Close(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
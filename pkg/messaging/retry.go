@@ -0,0 +1,129 @@
+package messaging
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// attributes added to a message published on a dead-letter topic
+const (
+	DeadLetterReasonAttr       = "dead-letter-reason"
+	DeadLetterSubscriptionAttr = "dead-letter-subscription"
+	DeadLetterAttemptsAttr     = "dead-letter-attempts"
+	DeadLetterMessageIdAttr    = "dead-letter-message-id"
+)
+
+// RetryPolicy
+// how a subscription retries failed messages, once MaxAttempts deliveries failed the message is published to DeadLetterTopic
+type RetryPolicy struct {
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	// DeadLetterTopic when empty, messages are acked and lost after the last attempt
+	DeadLetterTopic string
+}
+
+func DefaultRetryPolicy(subscription string) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:     5,
+		MinBackoff:      time.Second,
+		MaxBackoff:      time.Minute,
+		DeadLetterTopic: subscription + "-dead-letter",
+	}
+}
+
+// Backoff
+// the delay before redelivering a message that failed for the given attempt, doubling from MinBackoff up to MaxBackoff
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	d := p.MinBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return d
+}
+
+// bounds of the in memory delivery attempts, a message that is not redelivered within the ttl
+// (acked by another replica, or expired by the broker) is forgotten, and the oldest one when the cap is reached
+const (
+	deliveryAttemptsTTL = time.Hour
+	maxDeliveryAttempts = 10000
+)
+
+// deliveryAttempts
+// counts failed deliveries per message, not every broker reports the delivery attempt (pubsub only does on subscriptions with a dead-letter policy)
+// so the count is kept in memory for the others and restarts from zero when the service restarts or the message is evicted
+type deliveryAttempts struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	max      int
+	attempts map[string]attemptCount
+}
+
+type attemptCount struct {
+	count      int
+	lastFailed time.Time
+}
+
+func newDeliveryAttempts(ttl time.Duration, max int) *deliveryAttempts {
+	return &deliveryAttempts{ttl: ttl, max: max, attempts: make(map[string]attemptCount)}
+}
+
+func (d *deliveryAttempts) failed(subscription string, msg *Message) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	key := subscription + "/" + msg.ID
+	a, ok := d.attempts[key]
+	if !ok && len(d.attempts) >= d.max {
+		d.evict(now)
+	}
+	a.count++
+	if msg.DeliveryAttempt > a.count {
+		a.count = msg.DeliveryAttempt
+	}
+	a.lastFailed = now
+	d.attempts[key] = a
+	return a.count
+}
+
+// evict
+// forgets the messages that last failed before the ttl, or the oldest one when none did
+func (d *deliveryAttempts) evict(now time.Time) {
+	oldestKey, oldest := "", now
+	for key, a := range d.attempts {
+		if now.Sub(a.lastFailed) > d.ttl {
+			delete(d.attempts, key)
+			continue
+		}
+		if !a.lastFailed.After(oldest) {
+			oldestKey, oldest = key, a.lastFailed
+		}
+	}
+	if len(d.attempts) >= d.max {
+		delete(d.attempts, oldestKey)
+	}
+}
+
+func (d *deliveryAttempts) done(subscription string, msg *Message) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.attempts, subscription+"/"+msg.ID)
+}
+
+// deadLetterMessage
+// copies the failed message with the failure reason and the number of attempts as extra attributes
+func deadLetterMessage(subscription string, msg *Message, attempts int, reason error) *Message {
+	attributes := make(map[string]string, len(msg.Attributes)+4)
+	for k, v := range msg.Attributes {
+		attributes[k] = v
+	}
+	attributes[DeadLetterReasonAttr] = reason.Error()
+	attributes[DeadLetterSubscriptionAttr] = subscription
+	attributes[DeadLetterAttemptsAttr] = strconv.Itoa(attempts)
+	attributes[DeadLetterMessageIdAttr] = msg.ID
+	return &Message{Data: msg.Data, Attributes: attributes}
+}
@@ -0,0 +1,121 @@
+package messaging_test
+
+import (
+	"context"
+	"errors"
+	messagesMock "github.com/nawafswe/orders-service/mocks/github.com/nawafswe/orders-service/pkg/messaging"
+	"github.com/nawafswe/orders-service/pkg/messaging"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := messaging.RetryPolicy{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	tests := map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 10: 5 * time.Second}
+	for attempt, expected := range tests {
+		if d := p.Backoff(attempt); d != expected {
+			t.Errorf("expected backoff of attempt %d to be %v, but got %v", attempt, expected, d)
+		}
+	}
+}
+
+func TestConsumerProcess(t *testing.T) {
+	policy := messaging.RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond, DeadLetterTopic: "approveOrder-dead-letter"}
+	tests := map[string]struct {
+		HandlerErr error
+		Deliveries int
+		PublishErr error
+		Expected   []messaging.Outcome
+		DeadLetter bool
+	}{
+		"success is acked": {
+			Deliveries: 1,
+			Expected:   []messaging.Outcome{messaging.Acked},
+		},
+		"transient failures are retried until max attempts": {
+			HandlerErr: errors.New("db is down"),
+			Deliveries: 3,
+			Expected:   []messaging.Outcome{messaging.Nacked, messaging.Nacked, messaging.DeadLettered},
+			DeadLetter: true,
+		},
+		"skipped message is acked without being dead-lettered": {
+			HandlerErr: messaging.Skip(errors.New("cannot change order status from 'Delivered' to 'Approved'")),
+			Deliveries: 1,
+			Expected:   []messaging.Outcome{messaging.Acked},
+		},
+		"permanent failure is dead-lettered right away": {
+			HandlerErr: messaging.Drop(errors.New("malformed payload")),
+			Deliveries: 1,
+			Expected:   []messaging.Outcome{messaging.DeadLettered},
+			DeadLetter: true,
+		},
+		"message is kept when dead-lettering fails": {
+			HandlerErr: messaging.Drop(errors.New("malformed payload")),
+			PublishErr: errors.New("topic not found"),
+			Deliveries: 1,
+			Expected:   []messaging.Outcome{messaging.Nacked},
+			DeadLetter: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			msMock := messagesMock.NewMockMessageService(t)
+			if test.DeadLetter {
+				msMock.EXPECT().Publish(mock.Anything, "approveOrder-dead-letter", mock.MatchedBy(func(msg *messaging.Message) bool {
+					return string(msg.Data) == "payload" &&
+						msg.Attributes["correlation-id"] == "abc" &&
+						msg.Attributes[messaging.DeadLetterReasonAttr] == test.HandlerErr.Error() &&
+						msg.Attributes[messaging.DeadLetterSubscriptionAttr] == "approveOrder" &&
+						msg.Attributes[messaging.DeadLetterAttemptsAttr] != "" &&
+						msg.Attributes[messaging.DeadLetterMessageIdAttr] == "msg-1"
+				})).Return("srv-1", test.PublishErr)
+			}
+			c := messaging.NewConsumer(msMock)
+			c.Register("approveOrder", func(ctx context.Context, msg *messaging.Message) error {
+				return test.HandlerErr
+			})
+			c.SetRetryPolicy("approveOrder", policy)
+			for i := 0; i < test.Deliveries; i++ {
+				msg := &messaging.Message{ID: "msg-1", Data: []byte("payload"), Attributes: map[string]string{"correlation-id": "abc"}}
+				acked, nacked := false, false
+				d := messaging.NewDelivery(msg, func() { acked = true }, func() { nacked = true })
+				outcome := c.Process(context.Background(), "approveOrder", d)
+				if outcome != test.Expected[i] {
+					t.Errorf("expected delivery %d to be %v, but got %v", i+1, test.Expected[i], outcome)
+				}
+				if nacked != (outcome == messaging.Nacked) || acked == nacked {
+					t.Errorf("expected delivery %d to be settled once as %v, but got acked %v nacked %v", i+1, outcome, acked, nacked)
+				}
+			}
+		})
+	}
+}
+
+func TestConsumerProcessNacksWithoutWaitingForTheBackoff(t *testing.T) {
+	c := messaging.NewConsumer(messagesMock.NewMockMessageService(t))
+	c.Register("approveOrder", func(ctx context.Context, msg *messaging.Message) error {
+		return errors.New("db is down")
+	})
+	c.SetRetryPolicy("approveOrder", messaging.RetryPolicy{MaxAttempts: 3, MinBackoff: time.Hour, MaxBackoff: time.Hour})
+	nacked := false
+	start := time.Now()
+	outcome := c.Process(context.Background(), "approveOrder", messaging.NewDelivery(&messaging.Message{ID: "msg-1"}, func() {}, func() { nacked = true }))
+	if outcome != messaging.Nacked || !nacked {
+		t.Errorf("expected the message to be nacked, but got %v", outcome)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the nack to leave the backoff to the broker, but it took %v", elapsed)
+	}
+}
+
+func TestProvisionDeadLetters(t *testing.T) {
+	msMock := messagesMock.NewMockMessageService(t)
+	msMock.EXPECT().CreateTopic("approveOrder-dead-letter").Return()
+	msMock.EXPECT().CreateSub("approveOrder-dead-letter-sub", "approveOrder-dead-letter").Return()
+	c := messaging.NewConsumer(msMock)
+	c.Register("approveOrder", func(ctx context.Context, msg *messaging.Message) error { return nil })
+	c.Register("rejectOrder", func(ctx context.Context, msg *messaging.Message) error { return nil })
+	c.SetRetryPolicy("rejectOrder", messaging.RetryPolicy{MaxAttempts: 1})
+	c.ProvisionDeadLetters()
+}
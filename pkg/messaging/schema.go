@@ -0,0 +1,116 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// SchemaVersionExtension
+// the cloudevents extension carrying the version of the schema of the event data, messages without it are version 1
+const (
+	SchemaVersionExtension = "schemaversion"
+	SchemaVersionAttr      = ceAttrPrefix + SchemaVersionExtension
+)
+
+// Upcaster
+// raises event data from one schema version to the next
+type Upcaster func(data []byte) ([]byte, error)
+
+// UpcasterRegistry
+// the upcasters of every schema a consumer accepts, so a handler only decodes the latest version
+type UpcasterRegistry struct {
+	mu        sync.RWMutex
+	upcasters map[string]map[int]Upcaster
+}
+
+func NewUpcasterRegistry() *UpcasterRegistry {
+	return &UpcasterRegistry{upcasters: make(map[string]map[int]Upcaster)}
+}
+
+// Register
+// sets the upcaster raising the schema from version from to from+1
+func (r *UpcasterRegistry) Register(schema string, from int, up Upcaster) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.upcasters[schema]; !ok {
+		r.upcasters[schema] = make(map[int]Upcaster)
+	}
+	r.upcasters[schema][from] = up
+}
+
+// Latest
+// the version every registered upcaster of the schema leads to
+func (r *UpcasterRegistry) Latest(schema string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	latest := 1
+	for from := range r.upcasters[schema] {
+		if from+1 > latest {
+			latest = from + 1
+		}
+	}
+	return latest
+}
+
+// Upcast
+// chains the upcasters of the schema from version to the latest, a version newer than the latest is rejected
+func (r *UpcasterRegistry) Upcast(schema string, version int, data []byte) ([]byte, int, error) {
+	latest := r.Latest(schema)
+	if version < 1 || version > latest {
+		return nil, version, fmt.Errorf("unsupported version %d of schema %v, latest is %d", version, schema, latest)
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for ; version < latest; version++ {
+		up, ok := r.upcasters[schema][version]
+		if !ok {
+			return nil, version, fmt.Errorf("no upcaster of schema %v from version %d", schema, version)
+		}
+		upcast, err := up(data)
+		if err != nil {
+			return nil, version, fmt.Errorf("failed to upcast schema %v from version %d, err: %w", schema, version, err)
+		}
+		data = upcast
+	}
+	return data, version, nil
+}
+
+// SchemaVersion
+// the schema version carried by the message, 1 when it has none
+func SchemaVersion(msg *Message) (int, error) {
+	v, ok := msg.Attributes[SchemaVersionAttr]
+	if !ok || v == "" {
+		return 1, nil
+	}
+	version, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version %v, err: %w", v, err)
+	}
+	return version, nil
+}
+
+// Upcast
+// hands next a copy of the message whose data is upcast to the latest version of the schema,
+// a message whose version is unknown or cannot be upcast is dropped
+func Upcast(registry *UpcasterRegistry, schema string, next Handler) Handler {
+	return func(ctx context.Context, msg *Message) error {
+		version, err := SchemaVersion(msg)
+		if err != nil {
+			return Drop(err)
+		}
+		data, latest, err := registry.Upcast(schema, version, msg.Data)
+		if err != nil {
+			return Drop(err)
+		}
+		upcast := *msg
+		upcast.Data = data
+		upcast.Attributes = make(map[string]string, len(msg.Attributes)+1)
+		for k, v := range msg.Attributes {
+			upcast.Attributes[k] = v
+		}
+		upcast.Attributes[SchemaVersionAttr] = strconv.Itoa(latest)
+		return next(ctx, &upcast)
+	}
+}
@@ -0,0 +1,89 @@
+package messaging_test
+
+import (
+	"context"
+	"errors"
+	"github.com/nawafswe/orders-service/pkg/messaging"
+	"testing"
+)
+
+// appendVersion
+// a toy upcaster appending the version it raises the data to
+func appendVersion(to string) messaging.Upcaster {
+	return func(data []byte) ([]byte, error) {
+		return append(append([]byte{}, data...), to...), nil
+	}
+}
+
+func TestUpcasterRegistry(t *testing.T) {
+	r := messaging.NewUpcasterRegistry()
+	r.Register("command", 1, appendVersion("2"))
+	r.Register("command", 2, appendVersion("3"))
+	tests := map[string]struct {
+		Schema          string
+		Version         int
+		ExpectedData    string
+		ExpectedVersion int
+		ExpectedErr     bool
+	}{
+		"chains every upcaster from an old version": {Schema: "command", Version: 1, ExpectedData: "v23", ExpectedVersion: 3},
+		"upcasts from an intermediate version":      {Schema: "command", Version: 2, ExpectedData: "v3", ExpectedVersion: 3},
+		"keeps the latest version as is":            {Schema: "command", Version: 3, ExpectedData: "v", ExpectedVersion: 3},
+		"schema without upcasters is version 1":     {Schema: "event", Version: 1, ExpectedData: "v", ExpectedVersion: 1},
+		"rejects a version newer than the latest":   {Schema: "command", Version: 4, ExpectedErr: true},
+		"rejects version 0":                         {Schema: "command", Version: 0, ExpectedErr: true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, version, err := r.Upcast(test.Schema, test.Version, []byte("v"))
+			if (err != nil) != test.ExpectedErr {
+				t.Fatalf("expected error %v, but got %v", test.ExpectedErr, err)
+			}
+			if err == nil && (string(data) != test.ExpectedData || version != test.ExpectedVersion) {
+				t.Errorf("expected %v at version %d, but got %v at version %d", test.ExpectedData, test.ExpectedVersion, string(data), version)
+			}
+		})
+	}
+}
+
+func TestUpcastHandler(t *testing.T) {
+	r := messaging.NewUpcasterRegistry()
+	r.Register("command", 1, appendVersion("2"))
+	r.Register("command", 2, func([]byte) ([]byte, error) { return nil, errors.New("corrupted") })
+	r.Register("command", 3, appendVersion("4"))
+	tests := map[string]struct {
+		Attributes   map[string]string
+		ExpectedData string
+		ExpectedDrop bool
+	}{
+		// a message without a version is read as version 1, whose upcasting fails at the corrupted version 2 upcaster
+		"drops a message without a version that fails to upcast": {Attributes: map[string]string{}, ExpectedDrop: true},
+		"upcasts version 3":        {Attributes: map[string]string{messaging.SchemaVersionAttr: "3"}, ExpectedData: "v4"},
+		"keeps the latest version": {Attributes: map[string]string{messaging.SchemaVersionAttr: "4"}, ExpectedData: "v"},
+		"drops an invalid version": {Attributes: map[string]string{messaging.SchemaVersionAttr: "two"}, ExpectedDrop: true},
+		"drops an unknown version": {Attributes: map[string]string{messaging.SchemaVersionAttr: "5"}, ExpectedDrop: true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			msg := &messaging.Message{ID: "msg-1", Data: []byte("v"), Attributes: test.Attributes}
+			var handled *messaging.Message
+			err := messaging.Upcast(r, "command", func(ctx context.Context, m *messaging.Message) error {
+				handled = m
+				return nil
+			})(context.Background(), msg)
+			var dropErr messaging.DropErr
+			if errors.As(err, &dropErr) != test.ExpectedDrop {
+				t.Fatalf("expected drop %v, but got %v", test.ExpectedDrop, err)
+			}
+			if test.ExpectedDrop {
+				return
+			}
+			if string(handled.Data) != test.ExpectedData || handled.Attributes[messaging.SchemaVersionAttr] != "4" || handled.ID != "msg-1" {
+				t.Errorf("unexpected upcast message %+v", handled)
+			}
+			if string(msg.Data) != "v" || len(msg.Attributes) != len(test.Attributes) {
+				t.Errorf("expected the received message to be left untouched, but got %+v", msg)
+			}
+		})
+	}
+}
@@ -25,6 +25,18 @@ const _ = grpc.SupportPackageIsVersion7
 type OrderServiceClient interface {
 	Create(ctx context.Context, in *Order, opts ...grpc.CallOption) (*Order, error)
 	ChangeOrderStatus(ctx context.Context, in *OrderStatus, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// cancels the order on behalf of its customer, as far as the cancellation policy allows
+	CancelOrder(ctx context.Context, in *CancelOrderRequest, opts ...grpc.CallOption) (*CancelOrderResponse, error)
+	GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*Order, error)
+	// the status transitions of the order, oldest first
+	GetOrderTimeline(ctx context.Context, in *GetOrderTimelineRequest, opts ...grpc.CallOption) (*OrderTimeline, error)
+	ListOrders(ctx context.Context, in *ListOrdersRequest, opts ...grpc.CallOption) (*ListOrdersResponse, error)
+	// the sales per currency and their total converted to the requested currency
+	GetSalesReport(ctx context.Context, in *SalesReportRequest, opts ...grpc.CallOption) (*SalesReport, error)
+	// streams the current order first, then every subsequent change
+	WatchOrder(ctx context.Context, in *WatchOrderRequest, opts ...grpc.CallOption) (OrderService_WatchOrderClient, error)
+	// streams the restaurant's in progress orders first, then every new order and change
+	WatchRestaurantOrders(ctx context.Context, in *WatchRestaurantOrdersRequest, opts ...grpc.CallOption) (OrderService_WatchRestaurantOrdersClient, error)
 }
 
 type orderServiceClient struct {
@@ -53,12 +65,133 @@ func (c *orderServiceClient) ChangeOrderStatus(ctx context.Context, in *OrderSta
 	return out, nil
 }
 
+func (c *orderServiceClient) CancelOrder(ctx context.Context, in *CancelOrderRequest, opts ...grpc.CallOption) (*CancelOrderResponse, error) {
+	out := new(CancelOrderResponse)
+	err := c.cc.Invoke(ctx, "/orders.OrderService/CancelOrder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderServiceClient) GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*Order, error) {
+	out := new(Order)
+	err := c.cc.Invoke(ctx, "/orders.OrderService/GetOrder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderServiceClient) GetOrderTimeline(ctx context.Context, in *GetOrderTimelineRequest, opts ...grpc.CallOption) (*OrderTimeline, error) {
+	out := new(OrderTimeline)
+	err := c.cc.Invoke(ctx, "/orders.OrderService/GetOrderTimeline", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderServiceClient) ListOrders(ctx context.Context, in *ListOrdersRequest, opts ...grpc.CallOption) (*ListOrdersResponse, error) {
+	out := new(ListOrdersResponse)
+	err := c.cc.Invoke(ctx, "/orders.OrderService/ListOrders", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderServiceClient) GetSalesReport(ctx context.Context, in *SalesReportRequest, opts ...grpc.CallOption) (*SalesReport, error) {
+	out := new(SalesReport)
+	err := c.cc.Invoke(ctx, "/orders.OrderService/GetSalesReport", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderServiceClient) WatchOrder(ctx context.Context, in *WatchOrderRequest, opts ...grpc.CallOption) (OrderService_WatchOrderClient, error) {
+	stream, err := c.cc.NewStream(ctx, &OrderService_ServiceDesc.Streams[0], "/orders.OrderService/WatchOrder", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &orderServiceWatchOrderClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type OrderService_WatchOrderClient interface {
+	Recv() (*Order, error)
+	grpc.ClientStream
+}
+
+type orderServiceWatchOrderClient struct {
+	grpc.ClientStream
+}
+
+func (x *orderServiceWatchOrderClient) Recv() (*Order, error) {
+	m := new(Order)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *orderServiceClient) WatchRestaurantOrders(ctx context.Context, in *WatchRestaurantOrdersRequest, opts ...grpc.CallOption) (OrderService_WatchRestaurantOrdersClient, error) {
+	stream, err := c.cc.NewStream(ctx, &OrderService_ServiceDesc.Streams[1], "/orders.OrderService/WatchRestaurantOrders", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &orderServiceWatchRestaurantOrdersClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type OrderService_WatchRestaurantOrdersClient interface {
+	Recv() (*Order, error)
+	grpc.ClientStream
+}
+
+type orderServiceWatchRestaurantOrdersClient struct {
+	grpc.ClientStream
+}
+
+func (x *orderServiceWatchRestaurantOrdersClient) Recv() (*Order, error) {
+	m := new(Order)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // OrderServiceServer is the server API for OrderService service.
 // All implementations must embed UnimplementedOrderServiceServer
 // for forward compatibility
 type OrderServiceServer interface {
 	Create(context.Context, *Order) (*Order, error)
 	ChangeOrderStatus(context.Context, *OrderStatus) (*emptypb.Empty, error)
+	// cancels the order on behalf of its customer, as far as the cancellation policy allows
+	CancelOrder(context.Context, *CancelOrderRequest) (*CancelOrderResponse, error)
+	GetOrder(context.Context, *GetOrderRequest) (*Order, error)
+	// the status transitions of the order, oldest first
+	GetOrderTimeline(context.Context, *GetOrderTimelineRequest) (*OrderTimeline, error)
+	ListOrders(context.Context, *ListOrdersRequest) (*ListOrdersResponse, error)
+	// the sales per currency and their total converted to the requested currency
+	GetSalesReport(context.Context, *SalesReportRequest) (*SalesReport, error)
+	// streams the current order first, then every subsequent change
+	WatchOrder(*WatchOrderRequest, OrderService_WatchOrderServer) error
+	// streams the restaurant's in progress orders first, then every new order and change
+	WatchRestaurantOrders(*WatchRestaurantOrdersRequest, OrderService_WatchRestaurantOrdersServer) error
 	mustEmbedUnimplementedOrderServiceServer()
 }
 
@@ -72,6 +205,27 @@ func (UnimplementedOrderServiceServer) Create(context.Context, *Order) (*Order,
 func (UnimplementedOrderServiceServer) ChangeOrderStatus(context.Context, *OrderStatus) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ChangeOrderStatus not implemented")
 }
+func (UnimplementedOrderServiceServer) CancelOrder(context.Context, *CancelOrderRequest) (*CancelOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelOrder not implemented")
+}
+func (UnimplementedOrderServiceServer) GetOrder(context.Context, *GetOrderRequest) (*Order, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrder not implemented")
+}
+func (UnimplementedOrderServiceServer) GetOrderTimeline(context.Context, *GetOrderTimelineRequest) (*OrderTimeline, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrderTimeline not implemented")
+}
+func (UnimplementedOrderServiceServer) ListOrders(context.Context, *ListOrdersRequest) (*ListOrdersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListOrders not implemented")
+}
+func (UnimplementedOrderServiceServer) GetSalesReport(context.Context, *SalesReportRequest) (*SalesReport, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSalesReport not implemented")
+}
+func (UnimplementedOrderServiceServer) WatchOrder(*WatchOrderRequest, OrderService_WatchOrderServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchOrder not implemented")
+}
+func (UnimplementedOrderServiceServer) WatchRestaurantOrders(*WatchRestaurantOrdersRequest, OrderService_WatchRestaurantOrdersServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchRestaurantOrders not implemented")
+}
 func (UnimplementedOrderServiceServer) mustEmbedUnimplementedOrderServiceServer() {}
 
 // UnsafeOrderServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -121,6 +275,138 @@ func _OrderService_ChangeOrderStatus_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _OrderService_CancelOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderServiceServer).CancelOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/orders.OrderService/CancelOrder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderServiceServer).CancelOrder(ctx, req.(*CancelOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderService_GetOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderServiceServer).GetOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/orders.OrderService/GetOrder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderServiceServer).GetOrder(ctx, req.(*GetOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderService_GetOrderTimeline_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrderTimelineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderServiceServer).GetOrderTimeline(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/orders.OrderService/GetOrderTimeline",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderServiceServer).GetOrderTimeline(ctx, req.(*GetOrderTimelineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderService_ListOrders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListOrdersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderServiceServer).ListOrders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/orders.OrderService/ListOrders",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderServiceServer).ListOrders(ctx, req.(*ListOrdersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderService_GetSalesReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SalesReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderServiceServer).GetSalesReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/orders.OrderService/GetSalesReport",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderServiceServer).GetSalesReport(ctx, req.(*SalesReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderService_WatchOrder_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchOrderRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrderServiceServer).WatchOrder(m, &orderServiceWatchOrderServer{stream})
+}
+
+type OrderService_WatchOrderServer interface {
+	Send(*Order) error
+	grpc.ServerStream
+}
+
+type orderServiceWatchOrderServer struct {
+	grpc.ServerStream
+}
+
+func (x *orderServiceWatchOrderServer) Send(m *Order) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _OrderService_WatchRestaurantOrders_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRestaurantOrdersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrderServiceServer).WatchRestaurantOrders(m, &orderServiceWatchRestaurantOrdersServer{stream})
+}
+
+type OrderService_WatchRestaurantOrdersServer interface {
+	Send(*Order) error
+	grpc.ServerStream
+}
+
+type orderServiceWatchRestaurantOrdersServer struct {
+	grpc.ServerStream
+}
+
+func (x *orderServiceWatchRestaurantOrdersServer) Send(m *Order) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // OrderService_ServiceDesc is the grpc.ServiceDesc for OrderService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -136,7 +422,38 @@ var OrderService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ChangeOrderStatus",
 			Handler:    _OrderService_ChangeOrderStatus_Handler,
 		},
+		{
+			MethodName: "CancelOrder",
+			Handler:    _OrderService_CancelOrder_Handler,
+		},
+		{
+			MethodName: "GetOrder",
+			Handler:    _OrderService_GetOrder_Handler,
+		},
+		{
+			MethodName: "GetOrderTimeline",
+			Handler:    _OrderService_GetOrderTimeline_Handler,
+		},
+		{
+			MethodName: "ListOrders",
+			Handler:    _OrderService_ListOrders_Handler,
+		},
+		{
+			MethodName: "GetSalesReport",
+			Handler:    _OrderService_GetSalesReport_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchOrder",
+			Handler:       _OrderService_WatchOrder_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchRestaurantOrders",
+			Handler:       _OrderService_WatchRestaurantOrders_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "orders.proto",
 }